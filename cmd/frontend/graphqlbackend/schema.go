@@ -352,6 +352,22 @@ type Mutation {
         # with this new value.
         input: String!
     ): Boolean!
+    # Validates a proposed site configuration without saving it. Returns messages describing validation problems
+    # in the same form as SiteConfiguration.validationMessages.
+    #
+    # Only site admins may perform this mutation.
+    validateSiteConfiguration(
+        # A JSON object containing the entire proposed site configuration.
+        input: String!
+    ): [String!]!
+    # Restores the site configuration to a previous revision, after validating it. Returns whether or not a
+    # restart is required for the rollback to be applied.
+    #
+    # Only site admins may perform this mutation.
+    rollbackSiteConfiguration(
+        # The ID of the site configuration revision to restore (see SiteConfiguration.history).
+        id: Int!
+    ): Boolean!
     # Manages discussions.
     discussions: DiscussionsMutation
         @deprecated(
@@ -3700,6 +3716,45 @@ type SiteConfiguration {
     # This includes both JSON Schema validation problems and other messages that perform more advanced checks
     # on the configuration (that can't be expressed in the JSON Schema).
     validationMessages: [String!]!
+    # Warnings about configuration that is valid but likely a mistake, such as an externalURL that uses http
+    # instead of https. Unlike validationMessages, these don't need to be fixed for the configuration to work.
+    lintWarnings: [String!]!
+    # The most recent revisions of the site configuration, newest first.
+    history(
+        # Returns the first n revisions. Defaults to 20.
+        first: Int
+    ): [SiteConfigurationRevision!]!
+}
+
+# A recorded revision of the site configuration.
+type SiteConfigurationRevision {
+    # The unique identifier of this site configuration version.
+    id: Int!
+    # The author, or null if there is no author or the authoring user was deleted.
+    author: User
+    # Where the edit came from: "ui" for the web app, "api" for other API clients, "file" for a
+    # configuration file such as SITE_CONFIG_FILE, or "package:<name>" for a programmatic edit by a
+    # Sourcegraph package. Null if unknown.
+    source: String
+    # The time when this revision was created.
+    createdAt: DateTime!
+    # The configuration JSON before this revision.
+    previousContents: JSONCString!
+    # The configuration JSON as of this revision.
+    contents: JSONCString!
+    # The properties changed by this revision, sorted by path. Secrets are redacted.
+    changes: [SiteConfigurationChange!]!
+}
+
+# A change to a single site configuration property in a revision. Objects are compared property by
+# property, so a change to "email.smtp.port" is reported on its own. Arrays are compared as a whole.
+type SiteConfigurationChange {
+    # The dot-separated path of the property, such as "email.smtp.port" or "auth.providers".
+    path: String!
+    # The value before the revision, or null if the property was added.
+    oldValue: JSONValue
+    # The value after the revision, or null if the property was removed.
+    newValue: JSONValue
 }
 
 # The critical configuration for a site.
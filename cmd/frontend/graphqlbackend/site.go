@@ -13,9 +13,11 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/siteid"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/env"
+	"github.com/sourcegraph/sourcegraph/internal/errcode"
 	"github.com/sourcegraph/sourcegraph/internal/version"
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/globals"
@@ -144,16 +146,137 @@ func (r *siteConfigurationResolver) EffectiveContents(ctx context.Context) (JSON
 	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
 		return JSONCString(""), err
 	}
-	siteConfig := globals.ConfigurationServerFrontendOnly.Raw().Site
+	siteConfig, err := conf.RedactSecrets(globals.ConfigurationServerFrontendOnly.Raw().Site)
+	if err != nil {
+		return JSONCString(""), err
+	}
 	return JSONCString(siteConfig), nil
 }
 
 func (r *siteConfigurationResolver) ValidationMessages(ctx context.Context) ([]string, error) {
-	contents, err := r.EffectiveContents(ctx)
+	// 🚨 SECURITY: Validation messages may reveal parts of the site configuration,
+	// so only admins may view them.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+	// Validate the unredacted configuration, so that redacted secrets don't
+	// produce spurious messages.
+	return conf.ValidateSite(globals.ConfigurationServerFrontendOnly.Raw().Site)
+}
+
+func (r *siteConfigurationResolver) LintWarnings(ctx context.Context) ([]string, error) {
+	// 🚨 SECURITY: Lint warnings may reveal parts of the site configuration, so
+	// only admins may view them.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return conf.Lint(globals.ConfigurationServerFrontendOnly.Raw().Site)
+}
+
+func (r *siteConfigurationResolver) History(ctx context.Context, args *struct {
+	First *int32
+}) ([]*siteConfigurationRevisionResolver, error) {
+	// 🚨 SECURITY: The site configuration contains secret tokens and credentials,
+	// so only admins may view it.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	limit := 20
+	if args.First != nil {
+		if *args.First < 0 {
+			return nil, fmt.Errorf("history: 'first' must not be negative, got %d", *args.First)
+		}
+		limit = int(*args.First)
+	}
+
+	revisions, err := globals.ConfigurationServerFrontendOnly.History(ctx, limit)
 	if err != nil {
 		return nil, err
 	}
-	return conf.ValidateSite(string(contents))
+
+	resolvers := make([]*siteConfigurationRevisionResolver, 0, len(revisions))
+	for _, rev := range revisions {
+		resolvers = append(resolvers, &siteConfigurationRevisionResolver{revision: rev})
+	}
+	return resolvers, nil
+}
+
+type siteConfigurationRevisionResolver struct {
+	revision *conf.Revision
+}
+
+func (r *siteConfigurationRevisionResolver) ID() int32 { return r.revision.ID }
+
+func (r *siteConfigurationRevisionResolver) Author(ctx context.Context) (*UserResolver, error) {
+	if r.revision.AuthorUserID == nil {
+		return nil, nil
+	}
+	user, err := UserByIDInt32(ctx, *r.revision.AuthorUserID)
+	if err != nil && errcode.IsNotFound(err) {
+		// Don't throw an error if a user has been deleted.
+		return nil, nil
+	}
+	return user, err
+}
+
+func (r *siteConfigurationRevisionResolver) Source() *string {
+	if r.revision.EditSource == "" {
+		return nil
+	}
+	source := string(r.revision.EditSource)
+	return &source
+}
+
+func (r *siteConfigurationRevisionResolver) CreatedAt() DateTime {
+	return DateTime{Time: r.revision.CreatedAt}
+}
+
+func (r *siteConfigurationRevisionResolver) PreviousContents() (JSONCString, error) {
+	contents, err := conf.RedactSecrets(r.revision.Previous)
+	return JSONCString(contents), err
+}
+
+func (r *siteConfigurationRevisionResolver) Contents() (JSONCString, error) {
+	contents, err := conf.RedactSecrets(r.revision.Contents)
+	return JSONCString(contents), err
+}
+
+func (r *siteConfigurationRevisionResolver) Changes() ([]*siteConfigurationChangeResolver, error) {
+	previous := r.revision.Previous
+	if strings.TrimSpace(previous) == "" {
+		// The first revision has no previous contents.
+		previous = "{}"
+	}
+	changes, err := conf.Diff(previous, r.revision.Contents)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*siteConfigurationChangeResolver, len(changes))
+	for i := range changes {
+		resolvers[i] = &siteConfigurationChangeResolver{change: changes[i]}
+	}
+	return resolvers, nil
+}
+
+type siteConfigurationChangeResolver struct {
+	change conf.FieldChange
+}
+
+func (r *siteConfigurationChangeResolver) Path() string { return r.change.Path }
+
+func (r *siteConfigurationChangeResolver) OldValue() *JSONValue {
+	if r.change.Old == nil {
+		return nil
+	}
+	return &JSONValue{r.change.Old}
+}
+
+func (r *siteConfigurationChangeResolver) NewValue() *JSONValue {
+	if r.change.New == nil {
+		return nil
+	}
+	return &JSONValue{r.change.New}
 }
 
 var siteConfigAllowEdits, _ = strconv.ParseBool(env.Get("SITE_CONFIG_ALLOW_EDITS", "false", "When SITE_CONFIG_FILE is in use, allow edits in the application to be made which will be overwritten on next process restart"))
@@ -174,14 +297,67 @@ func (r *schemaResolver) UpdateSiteConfiguration(ctx context.Context, args *stru
 		return false, fmt.Errorf("blank site configuration is invalid (you can clear the site configuration by entering an empty JSON object: {})")
 	}
 	prev := globals.ConfigurationServerFrontendOnly.Raw()
+	// The input is usually based on the redacted EffectiveContents. Write keeps
+	// the existing values of secrets that were left unchanged.
 	prev.Site = args.Input
 	// TODO(slimsag): future: actually pass lastID through to prevent race conditions
-	if err := globals.ConfigurationServerFrontendOnly.Write(ctx, prev); err != nil {
+	if err := globals.ConfigurationServerFrontendOnly.Write(withEditSourceFromActor(ctx), prev); err != nil {
+		return false, err
+	}
+	return globals.ConfigurationServerFrontendOnly.NeedServerRestart(), nil
+}
+
+func (r *schemaResolver) ValidateSiteConfiguration(ctx context.Context, args *struct {
+	Input string
+}) ([]string, error) {
+	// 🚨 SECURITY: Validation messages may reveal parts of the site configuration,
+	// so only admins may validate it.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+	// The input is usually based on the redacted EffectiveContents, so validate
+	// it with the existing values of secrets that were left unchanged.
+	site, err := conf.UnredactSecrets(args.Input, globals.ConfigurationServerFrontendOnly.Raw().Site)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := conf.ValidateSite(site)
+	if err != nil {
+		return nil, err
+	}
+	if messages == nil {
+		messages = []string{}
+	}
+	return messages, nil
+}
+
+func (r *schemaResolver) RollbackSiteConfiguration(ctx context.Context, args *struct {
+	ID int32
+}) (bool, error) {
+	// 🚨 SECURITY: The site configuration contains secret tokens and credentials,
+	// so only admins may modify it.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return false, err
+	}
+	if os.Getenv("SITE_CONFIG_FILE") != "" && !siteConfigAllowEdits {
+		return false, errors.New("updating site configuration not allowed when using SITE_CONFIG_FILE")
+	}
+	if err := globals.ConfigurationServerFrontendOnly.Rollback(withEditSourceFromActor(ctx), args.ID); err != nil {
 		return false, err
 	}
 	return globals.ConfigurationServerFrontendOnly.NeedServerRestart(), nil
 }
 
+// withEditSourceFromActor records configuration writes made with ctx as coming
+// from the web app if the actor was authenticated with a session cookie, and
+// from the API otherwise.
+func withEditSourceFromActor(ctx context.Context) context.Context {
+	if actor.FromContext(ctx).FromSessionCookie {
+		return conf.WithEditSource(ctx, conf.EditSourceUI)
+	}
+	return conf.WithEditSource(ctx, conf.EditSourceAPI)
+}
+
 type criticalConfigurationResolver struct{}
 
 func (r *criticalConfigurationResolver) ID(ctx context.Context) (int32, error) {
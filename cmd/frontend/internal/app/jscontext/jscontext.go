@@ -176,10 +176,7 @@ func NewJSContextFromRequest(req *http.Request) JSContext {
 // configuration that is necessary for the web app and is not sensitive/secret.
 func publicSiteConfiguration() schema.SiteConfiguration {
 	c := conf.Get()
-	updateChannel := c.UpdateChannel
-	if updateChannel == "" {
-		updateChannel = "release"
-	}
+	updateChannel := conf.UpdateChannel()
 	return schema.SiteConfiguration{
 		CampaignsReadAccessEnabled: c.CampaignsReadAccessEnabled,
 		AuthPublic:                 c.AuthPublic,
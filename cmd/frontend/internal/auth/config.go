@@ -1,9 +1,47 @@
 package auth
 
-import "github.com/sourcegraph/sourcegraph/internal/conf"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/auth/providers"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+)
 
 func init() {
 	conf.ContributeValidator(validateConfig)
+	conf.ContributeHealthCheck("auth.providers", checkAuthProviders)
+}
+
+// checkAuthProviders fails unless an auth provider of the reloaded
+// configuration is registered, so that users can still sign in. The provider
+// packages register their providers when they see the new configuration, so it
+// waits for one until ctx is done.
+func checkAuthProviders(ctx context.Context, c *conf.Unified) error {
+	want := map[string]bool{}
+	for _, p := range c.AuthProviders {
+		k, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		want[string(k)] = true
+	}
+
+	for {
+		for _, p := range providers.Providers() {
+			if k, err := json.Marshal(p.Config()); err == nil && want[string(k)] {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.New("no auth provider of the new configuration was registered (no one could sign in)")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
 }
 
 func validateConfig(c conf.Unified) (problems conf.Problems) {
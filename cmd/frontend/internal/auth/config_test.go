@@ -1,8 +1,11 @@
 package auth
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/auth/providers"
 	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/schema"
 )
@@ -44,3 +47,33 @@ func TestValidateCustom(t *testing.T) {
 		})
 	}
 }
+
+type mockProvider struct {
+	providers.Provider
+	c schema.AuthProviders
+}
+
+func (p mockProvider) Config() schema.AuthProviders { return p.c }
+
+func TestCheckAuthProviders(t *testing.T) {
+	builtin := schema.AuthProviders{Builtin: &schema.BuiltinAuthProvider{Type: "builtin"}}
+	c := &conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		AuthProviders: []schema.AuthProviders{builtin},
+	}}
+
+	providers.MockProviders = []providers.Provider{mockProvider{c: builtin}}
+	defer func() { providers.MockProviders = nil }()
+	if err := checkAuthProviders(context.Background(), c); err != nil {
+		t.Errorf("got error %v with a registered auth provider", err)
+	}
+
+	// The registered providers are still those of an older configuration.
+	providers.MockProviders = []providers.Provider{mockProvider{c: schema.AuthProviders{
+		HttpHeader: &schema.HTTPHeaderAuthProvider{Type: "http-header", UsernameHeader: "X-User"},
+	}}}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := checkAuthProviders(ctx, c); err == nil {
+		t.Error("got no error without a registered auth provider of the configuration")
+	}
+}
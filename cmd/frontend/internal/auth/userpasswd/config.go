@@ -45,6 +45,7 @@ func handleEnabledCheck(w http.ResponseWriter) (handled bool) {
 
 func init() {
 	conf.ContributeValidator(validateConfig)
+	conf.ContributeLintRule(lintConfig)
 }
 
 func validateConfig(c conf.Unified) (problems conf.Problems) {
@@ -59,3 +60,12 @@ func validateConfig(c conf.Unified) (problems conf.Problems) {
 	}
 	return problems
 }
+
+func lintConfig(c conf.Unified) (warnings []string) {
+	for _, p := range c.AuthProviders {
+		if p.Builtin != nil && p.Builtin.AllowSignup && c.EmailSmtp == nil {
+			warnings = append(warnings, "the builtin auth provider allows sign-up, but email.smtp is not set, so users who sign up can't verify their email address or reset a forgotten password")
+		}
+	}
+	return warnings
+}
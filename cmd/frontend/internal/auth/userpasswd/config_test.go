@@ -36,3 +36,17 @@ func TestValidateCustom(t *testing.T) {
 		})
 	}
 }
+
+func TestLintConfig(t *testing.T) {
+	signup := []schema.AuthProviders{{Builtin: &schema.BuiltinAuthProvider{Type: "builtin", AllowSignup: true}}}
+	if warnings := lintConfig(conf.Unified{SiteConfiguration: schema.SiteConfiguration{AuthProviders: signup}}); len(warnings) != 1 {
+		t.Errorf("got warnings %q, want 1 warning for sign-up without email", warnings)
+	}
+	withEmail := conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		AuthProviders: signup,
+		EmailSmtp:     &schema.SMTPServerConfig{Host: "smtp.example.com"},
+	}}
+	if warnings := lintConfig(withEmail); len(warnings) != 0 {
+		t.Errorf("got warnings %q, want none", warnings)
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/globals"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/internal/actor"
 	"github.com/sourcegraph/sourcegraph/internal/api"
 	"github.com/sourcegraph/sourcegraph/internal/conf"
 	"github.com/sourcegraph/sourcegraph/internal/conf/conftypes"
@@ -79,7 +80,7 @@ func handleConfigOverrides() error {
 		}
 
 		if overrideCriticalConfig != "" || overrideSiteConfig != "" {
-			err := (&configurationSource{}).Write(ctx, raw)
+			err := (&configurationSource{}).Write(conf.WithEditSource(ctx, conf.EditSourceFile), raw)
 			if err != nil {
 				return errors.Wrap(err, "writing critical/site config overrides to database")
 			}
@@ -231,25 +232,127 @@ func (c configurationSource) Read(ctx context.Context) (conftypes.RawUnified, er
 }
 
 func (c configurationSource) Write(ctx context.Context, input conftypes.RawUnified) error {
-	// TODO(slimsag): future: pass lastID through for race prevention
+	_, version, err := c.ReadVersion(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = c.WriteIfUpToDate(ctx, input, version)
+	return err
+}
+
+// ReadVersion implements conf.VersionedConfigurationSource. The version holds
+// the IDs of the latest critical and site configs.
+func (c configurationSource) ReadVersion(ctx context.Context) (conftypes.RawUnified, conf.ConfigurationVersion, error) {
 	critical, err := confdb.CriticalGetLatest(ctx)
 	if err != nil {
-		return errors.Wrap(err, "confdb.CriticalGetLatest")
+		return conftypes.RawUnified{}, conf.ConfigurationVersion{}, errors.Wrap(err, "confdb.CriticalGetLatest")
 	}
 	site, err := confdb.SiteGetLatest(ctx)
 	if err != nil {
-		return errors.Wrap(err, "confdb.SiteGetLatest")
+		return conftypes.RawUnified{}, conf.ConfigurationVersion{}, errors.Wrap(err, "confdb.SiteGetLatest")
 	}
+	return conftypes.RawUnified{
+		Critical: critical.Contents,
+		Site:     site.Contents,
+
+		ServiceConnections: serviceConnections(),
+	}, conf.ConfigurationVersion{Critical: critical.ID, Site: site.ID}, nil
+}
 
-	_, err = confdb.CriticalCreateIfUpToDate(ctx, &critical.ID, input.Critical)
+// WriteIfUpToDate implements conf.VersionedConfigurationSource.
+func (c configurationSource) WriteIfUpToDate(ctx context.Context, input conftypes.RawUnified, version conf.ConfigurationVersion) (conf.ConfigurationVersion, error) {
+	var authorUserID *int32
+	if a := actor.FromContext(ctx); a.IsAuthenticated() {
+		authorUserID = &a.UID
+	}
+	editSource := string(conf.EditSourceFromContext(ctx))
+
+	// Both configs are written in one transaction, so that a write by someone
+	// else in the meantime fails with ErrNewerEdit before either is saved.
+	critical, site, err := confdb.CreateIfUpToDate(ctx, &version.Critical, &version.Site, authorUserID, editSource, input.Critical, input.Site)
+	if err == confdb.ErrNewerEdit {
+		return conf.ConfigurationVersion{}, conf.ErrNewerEdit
+	}
 	if err != nil {
-		return errors.Wrap(err, "confdb.CriticalCreateIfUpToDate")
+		return conf.ConfigurationVersion{}, errors.Wrap(err, "confdb.CreateIfUpToDate")
 	}
-	_, err = confdb.SiteCreateIfUpToDate(ctx, &site.ID, input.Site)
+	return conf.ConfigurationVersion{Critical: critical.ID, Site: site.ID}, nil
+}
+
+func (c configurationSource) History(ctx context.Context, limit int) ([]*conf.Revision, error) {
+	// Fetch one extra config so that the oldest revision has its previous
+	// contents.
+	configs, err := confdb.SiteListHistory(ctx, limit+1)
 	if err != nil {
-		return errors.Wrap(err, "confdb.SiteCreateIfUpToDate")
+		return nil, errors.Wrap(err, "confdb.SiteListHistory")
 	}
-	return nil
+
+	revisions := make([]*conf.Revision, 0, len(configs))
+	for i, c := range configs {
+		if i == limit {
+			break
+		}
+		r := &conf.Revision{
+			ID:           c.ID,
+			AuthorUserID: c.AuthorUserID,
+			EditSource:   conf.EditSource(c.EditSource),
+			Contents:     c.Contents,
+			CreatedAt:    c.CreatedAt,
+		}
+		if i+1 < len(configs) {
+			r.Previous = configs[i+1].Contents
+		}
+		revisions = append(revisions, r)
+	}
+	return revisions, nil
+}
+
+func (c configurationSource) Revision(ctx context.Context, id int32) (*conf.Revision, error) {
+	site, err := confdb.SiteGetByID(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "confdb.SiteGetByID")
+	}
+	if site == nil {
+		return nil, nil
+	}
+	return &conf.Revision{
+		ID:           site.ID,
+		AuthorUserID: site.AuthorUserID,
+		EditSource:   conf.EditSource(site.EditSource),
+		Contents:     site.Contents,
+		CreatedAt:    site.CreatedAt,
+	}, nil
+}
+
+var (
+	configChangesOnce        sync.Once
+	configChangesMu          sync.Mutex
+	configChangesSubscribers []chan<- struct{}
+)
+
+// NotifyChanges implements conf.ChangeNotifier. Writes by any frontend are
+// announced with PostgreSQL notifications, so that every replica picks up a
+// change within seconds.
+func (c configurationSource) NotifyChanges(ch chan<- struct{}) {
+	configChangesOnce.Do(func() {
+		err := confdb.ListenForChanges("", func() {
+			configChangesMu.Lock()
+			defer configChangesMu.Unlock()
+			for _, s := range configChangesSubscribers {
+				select {
+				case s <- struct{}{}:
+				default:
+				}
+			}
+		})
+		if err != nil {
+			log15.Warn("Unable to listen for site configuration changes. Changes made by other frontend replicas are picked up when the configuration is next polled.", "error", err)
+		}
+	})
+
+	configChangesMu.Lock()
+	configChangesSubscribers = append(configChangesSubscribers, ch)
+	configChangesMu.Unlock()
 }
 
 var (
@@ -13,6 +13,8 @@ const (
 	SrcCliVersion  = "src-cli.version"
 	SrcCliDownload = "src-cli.download"
 
+	SiteConfigurationSchema = "site-configuration.schema"
+
 	Registry = "registry"
 
 	RepoShield  = "repo.shield"
@@ -67,6 +69,7 @@ func New(base *mux.Router) *mux.Router {
 	base.Path("/lsif/upload").Methods("POST").Name(LSIFUpload)
 	base.Path("/src-cli/version").Methods("GET").Name(SrcCliVersion)
 	base.Path("/src-cli/{rest:.*}").Methods("GET").Name(SrcCliDownload)
+	base.Path("/site-configuration/schema").Methods("GET").Name(SiteConfigurationSchema)
 
 	// repo contains routes that are NOT specific to a revision. In these routes, the URL may not contain a revspec after the repo (that is, no "github.com/foo/bar@myrevspec").
 	repoPath := `/repos/` + routevar.Repo
@@ -0,0 +1,39 @@
+package httpapi
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/internal/conf"
+	"github.com/sourcegraph/sourcegraph/internal/version"
+)
+
+// serveSiteConfigurationSchema serves the site configuration JSON Schema,
+// including properties contributed at runtime, for the site configuration
+// editor's autocompletion. The ETag changes when a property is contributed, so
+// the editor can cheaply check for a newer schema.
+func serveSiteConfigurationSchema(w http.ResponseWriter, r *http.Request) error {
+	// 🚨 SECURITY: Only site admins can edit the site configuration, so only
+	// they need its schema.
+	if err := backend.CheckCurrentUserIsSiteAdmin(r.Context()); err != nil {
+		if err == backend.ErrMustBeSiteAdmin {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return nil
+		}
+		return err
+	}
+
+	schemaJSON, schemaVersion := conf.SiteSchema()
+	etag := fmt.Sprintf("%q", fmt.Sprintf("%s-%d", version.Version(), schemaVersion))
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+
+	w.Header().Set("Content-Type", "application/schema+json; charset=utf-8")
+	_, err := w.Write([]byte(schemaJSON))
+	return err
+}
@@ -0,0 +1,52 @@
+package httpapi
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+func TestServeSiteConfigurationSchema(t *testing.T) {
+	c := newTest()
+	defer func() { db.Mocks.Users = db.MockUsers{} }()
+
+	t.Run("non-admin", func(t *testing.T) {
+		db.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+			return &types.User{ID: 1}, nil
+		}
+		resp, err := c.Get("/site-configuration/schema")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("got status %d, want %d", resp.StatusCode, http.StatusForbidden)
+		}
+	})
+
+	t.Run("admin", func(t *testing.T) {
+		db.Mocks.Users.GetByCurrentAuthUser = func(context.Context) (*types.User, error) {
+			return &types.User{ID: 1, SiteAdmin: true}, nil
+		}
+		resp, err := c.GetOK("/site-configuration/schema")
+		if err != nil {
+			t.Fatal(err)
+		}
+		etag := resp.Header.Get("ETag")
+		if etag == "" {
+			t.Fatal("got no ETag")
+		}
+
+		req, _ := http.NewRequest("GET", "/site-configuration/schema", nil)
+		req.Header.Set("If-None-Match", etag)
+		resp, err = c.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusNotModified {
+			t.Errorf("got status %d for unchanged schema, want %d", resp.StatusCode, http.StatusNotModified)
+		}
+	})
+}
@@ -225,7 +225,7 @@ func SetActor(w http.ResponseWriter, r *http.Request, actor *actor.Actor, expiry
 	var value *sessionInfo
 	if actor != nil {
 		if expiryPeriod == 0 {
-			if cfgExpiry, err := time.ParseDuration(conf.Get().AuthSessionExpiry); err == nil {
+			if cfgExpiry, err := time.ParseDuration(conf.AuthSessionExpiry()); err == nil {
 				expiryPeriod = cfgExpiry
 			} else { // if there is no valid session duration, fall back to the default one
 				expiryPeriod = defaultExpiryPeriod
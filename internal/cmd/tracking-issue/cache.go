@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// cacheTransport caches the responses to GraphQL queries in a directory, keyed
+// by the endpoint, the token, the query and its variables. It is meant for
+// iterating on the rendering locally without spending rate limit points on
+// every run. Mutations and failed queries are never cached.
+type cacheTransport struct {
+	base http.RoundTripper
+	dir  string
+	ttl  time.Duration // If positive, how long cached responses are used
+	salt string        // Distinguishes the responses to different tokens
+}
+
+func (t *cacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodPost || req.Body == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	// Round trippers must not modify the request, so the body is re-read
+	// from a copy.
+	req = req.Clone(req.Context())
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if isMutation(body) {
+		return t.base.RoundTrip(req)
+	}
+
+	path := t.path(req.URL.String(), body)
+	if data, ok := t.read(path, time.Now()); ok {
+		return &http.Response{
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": []string{"application/json"}},
+			Body:          ioutil.NopCloser(bytes.NewReader(data)),
+			ContentLength: int64(len(data)),
+			Request:       req,
+		}, nil
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(data))
+
+	if cached, ok := cacheableResponse(data); ok {
+		if err := t.write(path, cached); err != nil {
+			log.Printf("failed to cache GitHub API response: %v", err)
+		}
+	}
+
+	return resp, nil
+}
+
+func (t *cacheTransport) path(url string, body []byte) string {
+	h := sha256.New()
+	for _, s := range []string{t.salt, url} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	h.Write(body)
+	return filepath.Join(t.dir, hex.EncodeToString(h.Sum(nil))+".json")
+}
+
+func (t *cacheTransport) read(path string, now time.Time) ([]byte, bool) {
+	fi, err := os.Stat(path)
+	if err != nil || (t.ttl > 0 && now.Sub(fi.ModTime()) > t.ttl) {
+		return nil, false
+	}
+
+	data, err := ioutil.ReadFile(path)
+	return data, err == nil
+}
+
+func (t *cacheTransport) write(path string, data []byte) error {
+	if err := os.MkdirAll(t.dir, 0755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first, so that concurrent runs never read a
+	// partially written response.
+	f, err := ioutil.TempFile(t.dir, "tmp-")
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+
+	if err != nil {
+		os.Remove(f.Name())
+	}
+
+	return err
+}
+
+// isMutation reports whether the GraphQL request body is a mutation.
+func isMutation(body []byte) bool {
+	var req struct{ Query string }
+	if err := json.Unmarshal(body, &req); err != nil {
+		return true // Don't cache what we don't understand.
+	}
+	return strings.HasPrefix(strings.TrimSpace(req.Query), "mutation")
+}
+
+// cacheableResponse returns the response to cache, and whether it can be
+// cached. Responses with errors aren't cached, and cached responses cost no
+// rate limit points, so that they don't count towards -max-cost.
+func cacheableResponse(data []byte) ([]byte, bool) {
+	var resp struct {
+		Data   map[string]json.RawMessage `json:"data"`
+		Errors json.RawMessage            `json:"errors"`
+	}
+
+	if err := json.Unmarshal(data, &resp); err != nil || resp.Data == nil {
+		return nil, false
+	}
+
+	if len(resp.Errors) > 0 && string(resp.Errors) != "null" {
+		return nil, false
+	}
+
+	if _, ok := resp.Data["rateLimit"]; ok {
+		resp.Data["rateLimit"] = json.RawMessage(`{"cost":0}`)
+	}
+
+	cached, err := json.Marshal(struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}{resp.Data})
+
+	return cached, err == nil
+}
+
+// checkCacheDir returns an error if -cache-dir is set for a run that acts on
+// what it loads instead of only rendering it locally. Cached responses can be
+// up to -cache-ttl old, so such a run would overwrite recent edits of tracking
+// issues, ignore the webhook events that serve refreshes for, or nudge the
+// assignees of issues that were updated since.
+func checkCacheDir(cfg *Config, opts options, serve bool) error {
+	switch {
+	case opts.CacheDir == "":
+		return nil
+	case serve:
+		return fmt.Errorf("-cache-dir can't be combined with serve")
+	case opts.Nudge:
+		return fmt.Errorf("-cache-dir can't be combined with -nudge")
+	case opts.Dry || opts.Diff:
+		return nil
+	}
+
+	for _, ic := range cfg.Issues {
+		if ic.Format == "markdown" {
+			return fmt.Errorf("-cache-dir requires -dry, -diff or a format other than markdown, so that tracking issues aren't updated from cached responses")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/machinebox/graphql"
+)
+
+func TestCacheTransport(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := ioutil.ReadAll(r.Body)
+		if strings.Contains(string(body), "fail") {
+			_, _ = w.Write([]byte(`{"errors": [{"message": "something failed"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": {"rateLimit": {"cost": 2}, "viewer": {"login": "alice"}}}`))
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "tracking-issue-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	transport := &cacheTransport{base: http.DefaultTransport, dir: dir, ttl: time.Hour, salt: "token"}
+	cli := newTestClient(srv.URL)
+	cli.cli = graphql.NewClient(srv.URL, graphql.WithHTTPClient(&http.Client{Transport: transport}))
+
+	ctx := context.Background()
+	query := func(q string) {
+		t.Helper()
+		var data struct{ Viewer struct{ Login string } }
+		if err := cli.Run(ctx, graphql.NewRequest(q), &data); err != nil && !strings.Contains(q, "fail") {
+			t.Fatal(err)
+		} else if err == nil && data.Viewer.Login != "alice" {
+			t.Errorf("login: have %q, want %q", data.Viewer.Login, "alice")
+		}
+	}
+
+	for _, tc := range []struct {
+		query    string
+		requests int
+		cost     int
+	}{
+		{"query { rateLimit { cost } viewer { login } }", 1, 2},
+		{"query { rateLimit { cost } viewer { login } }", 1, 2}, // cached, free
+		{"mutation { viewer { login } }", 2, 4},
+		{"mutation { viewer { login } }", 3, 6},
+		{"query { fail }", 4, 6},
+		{"query { fail }", 5, 6},
+	} {
+		query(tc.query)
+
+		if requests != tc.requests {
+			t.Errorf("%s: have %d requests, want %d", tc.query, requests, tc.requests)
+		}
+
+		if have := cli.Cost(); have != tc.cost {
+			t.Errorf("%s: have cost %d, want %d", tc.query, have, tc.cost)
+		}
+	}
+
+	// Expired responses are fetched again.
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(paths) != 1 {
+		t.Fatalf("have %d cached responses, want 1", len(paths))
+	}
+
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(paths[0], old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	query("query { rateLimit { cost } viewer { login } }")
+	if requests != 6 {
+		t.Errorf("expired response: have %d requests, want 6", requests)
+	}
+}
+
+func TestCheckCacheDir(t *testing.T) {
+	markdown := &Config{Issues: []*IssueConfig{{Format: "markdown"}, {Format: "json"}}}
+	json := &Config{Issues: []*IssueConfig{{Format: "json"}, {Format: "csv"}}}
+
+	for _, tc := range []struct {
+		name  string
+		cfg   *Config
+		opts  options
+		serve bool
+		err   string
+	}{
+		{name: "no cache", cfg: markdown},
+		{name: "dry", cfg: markdown, opts: options{CacheDir: "cache", Dry: true}},
+		{name: "diff", cfg: markdown, opts: options{CacheDir: "cache", Diff: true}},
+		{name: "only exported", cfg: json, opts: options{CacheDir: "cache"}},
+		{
+			name: "updates tracking issues",
+			cfg:  markdown,
+			opts: options{CacheDir: "cache"},
+			err:  "-cache-dir requires -dry, -diff or a format other than markdown, so that tracking issues aren't updated from cached responses",
+		},
+		{
+			name:  "serve",
+			cfg:   json,
+			opts:  options{CacheDir: "cache", Dry: true},
+			serve: true,
+			err:   "-cache-dir can't be combined with serve",
+		},
+		{
+			name: "nudge",
+			cfg:  json,
+			opts: options{CacheDir: "cache", Dry: true, Nudge: true},
+			err:  "-cache-dir can't be combined with -nudge",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var have string
+			if err := checkCacheDir(tc.cfg, tc.opts, tc.serve); err != nil {
+				have = err.Error()
+			}
+			if have != tc.err {
+				t.Errorf("error: have %q, want %q", have, tc.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/machinebox/graphql"
+	"golang.org/x/sync/errgroup"
+)
+
+// loadCarryOver marks the open issues of each tracking issue that were listed
+// in the tracking issue of the previous milestone as carried over, for the
+// definitions with carry-over detection enabled. previousBody returns the body
+// of the tracking issue of the previous milestone, or "" if there is none.
+func loadCarryOver(ctx context.Context, tracking []*TrackingIssue, previousBody func(ctx context.Context, t *TrackingIssue, previous string) (string, error)) error {
+	g, gctx := errgroup.WithContext(ctx)
+
+	for _, t := range tracking {
+		if t.Config == nil || !t.Config.CarryOver {
+			continue
+		}
+
+		previous := t.Config.PreviousMilestone
+		if previous == "" {
+			previous = PreviousMilestone(t.Milestone)
+		}
+
+		if previous == "" {
+			continue
+		}
+
+		t := t
+		g.Go(func() error {
+			body, err := previousBody(gctx, t, previous)
+			if err != nil || body == "" {
+				return err
+			}
+
+			markers := t.Config.Markers
+			if work, ok := between(body, markers.Begin, markers.End); ok {
+				body = work
+			}
+
+			listed := listedIssueURLs(body)
+			for _, issue := range t.Issues {
+				if listed[issue.URL] && !issue.Closed() {
+					issue.CarriedOver = true
+				}
+			}
+
+			return nil
+		})
+	}
+
+	return g.Wait()
+}
+
+// PreviousMilestone returns the milestone before a milestone named like a
+// MAJOR.MINOR version, such as 3.13 for 3.14. It returns "" if the previous
+// milestone can't be derived.
+func PreviousMilestone(milestone string) string {
+	parts := strings.Split(milestone, ".")
+	if len(parts) != 2 {
+		return ""
+	}
+
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil || minor <= 0 {
+		return ""
+	}
+
+	if _, err := strconv.Atoi(parts[0]); err != nil {
+		return ""
+	}
+
+	return parts[0] + "." + strconv.Itoa(minor-1)
+}
+
+// maxPreviousCandidates is the number of tracking issues in the previous
+// milestone that are searched for the one with the same labels.
+const maxPreviousCandidates = 100
+
+// previousTrackingIssueBody returns the body of the tracking issue with the same
+// labels as t in the previous milestone, or "" if there is none.
+func previousTrackingIssueBody(ctx context.Context, cli *Client, t *TrackingIssue, previous string) (string, error) {
+	var q strings.Builder
+	q.WriteString("query($previousCount: Int!, $previousCursor: String, $previousQuery: String!) {\n")
+	q.WriteString(rateLimitGraphQLQuery)
+	q.WriteString(searchGraphQLQuery("previous", searchFields{}))
+	q.WriteString("}")
+
+	r := graphql.NewRequest(q.String())
+
+	query := fmt.Sprintf("org:%q label:tracking milestone:%q", t.Config.Org, previous)
+	for _, label := range t.Labels {
+		if label != "" && label != "tracking" {
+			query += fmt.Sprintf(" label:%q", label)
+		}
+	}
+
+	r.Var("previousCount", maxPreviousCandidates)
+	r.Var("previousQuery", query)
+
+	var data struct{ Previous search }
+	if err := cli.Run(ctx, r, &data); err != nil {
+		return "", err
+	}
+
+	issues, _ := unmarshalSearchNodes(data.Previous.Nodes)
+	if previous := sameLabels(issues, t.Labels); previous != nil {
+		return previous.Body, nil
+	}
+	return "", nil
+}
+
+// sameLabels returns the first of the tracking issues whose work labels are
+// exactly the given ones, or nil if there is none. The searches also match
+// tracking issues with more labels, such as those of other teams.
+func sameLabels(issues []*Issue, labels []string) *Issue {
+	want := map[string]bool{}
+	for _, label := range workLabels(labels) {
+		want[label] = true
+	}
+
+	for _, issue := range issues {
+		have := map[string]bool{}
+		for _, label := range workLabels(issue.Labels) {
+			have[label] = true
+		}
+		if reflect.DeepEqual(have, want) {
+			return issue
+		}
+	}
+	return nil
+}
+
+// between returns the text between the markers in s.
+func between(s, opening, closing string) (string, bool) {
+	start := strings.Index(s, opening)
+	if start == -1 {
+		return "", false
+	}
+
+	s = s[start+len(opening):]
+	end := strings.Index(s, closing)
+	if end == -1 {
+		return "", false
+	}
+
+	return s[:end], true
+}
+
+var listedIssueURL = regexp.MustCompile(`\]\((https?://[^)\s]+/issues/\d+)\)`)
+
+// listedIssueURLs returns the URLs of the issues linked in a rendered work
+// section.
+func listedIssueURLs(work string) map[string]bool {
+	urls := map[string]bool{}
+	for _, m := range listedIssueURL.FindAllStringSubmatch(work, -1) {
+		urls[m[1]] = true
+	}
+	return urls
+}
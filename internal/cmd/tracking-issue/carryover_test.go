@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPreviousMilestone(t *testing.T) {
+	for milestone, want := range map[string]string{
+		"3.14":    "3.13",
+		"3.1":     "3.0",
+		"3.0":     "",
+		"4":       "",
+		"backlog": "",
+		"v3.14":   "",
+	} {
+		if have := PreviousMilestone(milestone); have != want {
+			t.Errorf("%q: have %q, want %q", milestone, have, want)
+		}
+	}
+}
+
+func TestLoadCarryOver(t *testing.T) {
+	const previousBody = "Plan\n<!-- BEGIN WORK -->\n" +
+		"- [ ] a [#1](https://github.com/sourcegraph/sourcegraph/issues/1) __2d__ \n" +
+		"- [x] b [#2](https://github.com/sourcegraph/sourcegraph/issues/2) __1d__ \n" +
+		"<!-- END WORK -->\nSee also [#3](https://github.com/sourcegraph/sourcegraph/issues/3)"
+
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ Variables map[string]interface{} }
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+			return
+		}
+		query, _ = req.Variables["previousQuery"].(string)
+
+		labels := func(names ...string) map[string]interface{} {
+			nodes := make([]map[string]string, 0, len(names))
+			for _, name := range names {
+				nodes = append(nodes, map[string]string{"name": name})
+			}
+			return map[string]interface{}{"nodes": nodes}
+		}
+
+		// The search also matches the tracking issue of a team with more
+		// labels, which comes first.
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{
+			"previous": map[string]interface{}{
+				"nodes": []map[string]interface{}{
+					{"__typename": "Issue", "body": "- [ ] [#3](https://github.com/sourcegraph/sourcegraph/issues/3)", "labels": labels("tracking", "team/search", "team/web")},
+					{"__typename": "Issue", "body": previousBody, "labels": labels("tracking", "team/search")},
+				},
+			},
+		}})
+	}))
+	defer srv.Close()
+
+	issue := func(number int, state string) *Issue {
+		return &Issue{
+			Number:    number,
+			URL:       fmt.Sprintf("https://github.com/sourcegraph/sourcegraph/issues/%d", number),
+			State:     state,
+			Milestone: "3.14",
+			Assignees: []string{"alice"},
+			Labels:    []string{"estimate/2d"},
+		}
+	}
+
+	ti := &TrackingIssue{
+		Issue:  &Issue{Milestone: "3.14", Labels: []string{"tracking", "team/search"}},
+		Issues: []*Issue{issue(1, "OPEN"), issue(2, "CLOSED"), issue(3, "OPEN")},
+		Config: &IssueConfig{Org: "sourcegraph", CarryOver: true, Markers: defaultMarkers},
+	}
+
+	cli := newTestClient(srv.URL)
+	previousBodyOf := func(ctx context.Context, ti *TrackingIssue, previous string) (string, error) {
+		return previousTrackingIssueBody(ctx, cli, ti, previous)
+	}
+
+	if err := loadCarryOver(context.Background(), []*TrackingIssue{ti}, previousBodyOf); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := `org:"sourcegraph" label:tracking milestone:"3.13" label:"team/search"`; query != want {
+		t.Errorf("query: have %q, want %q", query, want)
+	}
+
+	for i, want := range []bool{true, false, false} {
+		if have := ti.Issues[i].CarriedOver; have != want {
+			t.Errorf("issue #%d carried over: have %t, want %t", ti.Issues[i].Number, have, want)
+		}
+	}
+
+	md := ti.Workloads().Markdown()
+	for _, want := range []string{
+		"@alice: __6.00d__ (2.00d carried over)",
+		"__2d__ ↩️\n",
+		"↩️ __2.00d__ carried over from the previous milestone",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown doesn't contain %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestSameLabels(t *testing.T) {
+	team := &Issue{Number: 1, Labels: []string{"tracking", "team/search"}}
+	rollUp := &Issue{Number: 2, Labels: []string{"tracking"}}
+	candidates := []*Issue{team, rollUp}
+
+	for _, tc := range []struct {
+		labels []string
+		want   *Issue
+	}{
+		{[]string{"tracking", "team/search"}, team},
+		{[]string{"tracking"}, rollUp},
+		{[]string{"tracking", "team/web"}, nil},
+	} {
+		if have := sameLabels(candidates, tc.labels); have != tc.want {
+			t.Errorf("%q: have %+v, want %+v", tc.labels, have, tc.want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CompletedWeek is the work completed in a week.
+type CompletedWeek struct {
+	Week         time.Time // Monday of the week, in UTC
+	Issues       []*Issue
+	PullRequests []*PullRequest
+}
+
+// weekOf returns the Monday of the week of t, in UTC.
+func weekOf(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// completedWeeks groups the closed issues and merged pull requests of the
+// workloads by the week they were completed in, most recent week first.
+// Deprioritised issues and issues that are only done according to their
+// project status, without a closing time, are left out.
+func (ws Workloads) completedWeeks() []CompletedWeek {
+	byWeek := map[time.Time]*CompletedWeek{}
+	week := func(t time.Time) *CompletedWeek {
+		w := weekOf(t)
+		if byWeek[w] == nil {
+			byWeek[w] = &CompletedWeek{Week: w}
+		}
+		return byWeek[w]
+	}
+
+	for _, wl := range ws.ByAssignee {
+		for _, issue := range wl.Issues {
+			if issue.Closed() && !issue.Deprioritised && !issue.ClosedAt.IsZero() {
+				w := week(issue.ClosedAt)
+				w.Issues = append(w.Issues, issue)
+			}
+		}
+
+		for _, pr := range wl.PullRequests {
+			if strings.EqualFold(pr.State, "merged") && !pr.ClosedAt.IsZero() {
+				w := week(pr.ClosedAt)
+				w.PullRequests = append(w.PullRequests, pr)
+			}
+		}
+	}
+
+	weeks := make([]CompletedWeek, 0, len(byWeek))
+	for _, w := range byWeek {
+		sort.SliceStable(w.Issues, func(i, j int) bool { return w.Issues[i].ClosedAt.Before(w.Issues[j].ClosedAt) })
+		sort.SliceStable(w.PullRequests, func(i, j int) bool { return w.PullRequests[i].ClosedAt.Before(w.PullRequests[j].ClosedAt) })
+		weeks = append(weeks, *w)
+	}
+
+	sort.Slice(weeks, func(i, j int) bool { return weeks[i].Week.After(weeks[j].Week) })
+	return weeks
+}
+
+// completedMarkdown renders the completed work by week.
+func completedMarkdown(w io.Writer, weeks []CompletedWeek) {
+	if len(weeks) == 0 {
+		return
+	}
+
+	io.WriteString(w, "\n🗓️ __Completed__\n")
+
+	for _, week := range weeks {
+		fmt.Fprintf(w, "\n_Week of %s_\n\n", week.Week.Format("2006-01-02"))
+		for _, issue := range week.Issues {
+			io.WriteString(w, issue.Markdown())
+		}
+		for _, pr := range week.PullRequests {
+			io.WriteString(w, pr.Markdown())
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWeekOf(t *testing.T) {
+	for _, tc := range []struct{ t, want string }{
+		{"2020-06-08T00:00:00Z", "2020-06-08"}, // Monday
+		{"2020-06-14T23:59:00Z", "2020-06-08"}, // Sunday
+		{"2020-06-15T01:00:00+02:00", "2020-06-08"},
+		{"2020-07-01T12:00:00Z", "2020-06-29"},
+	} {
+		ts, err := time.Parse(time.RFC3339, tc.t)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if have := weekOf(ts).Format("2006-01-02"); have != tc.want {
+			t.Errorf("weekOf(%s): have %s, want %s", tc.t, have, tc.want)
+		}
+	}
+}
+
+func TestCompletedByWeek(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2020, 6, d, 12, 0, 0, 0, time.UTC) }
+
+	issue := func(number int, state string, closedAt time.Time, milestone string) *Issue {
+		return &Issue{
+			Title:     fmt.Sprintf("issue %d", number),
+			Number:    number,
+			URL:       fmt.Sprintf("https://github.com/sourcegraph/sourcegraph/issues/%d", number),
+			State:     state,
+			Milestone: milestone,
+			ClosedAt:  closedAt,
+			Assignees: []string{"alice"},
+			Labels:    []string{"estimate/1d"},
+		}
+	}
+
+	ti := &TrackingIssue{
+		Issue: &Issue{Milestone: "3.17"},
+		Issues: []*Issue{
+			issue(1, "CLOSED", day(10), "3.17"),
+			issue(2, "CLOSED", day(2), "3.17"),
+			issue(3, "OPEN", time.Time{}, "3.17"),
+			issue(4, "CLOSED", day(11), "3.18"), // Deprioritised
+			issue(5, "CLOSED", day(9), "3.17"),
+		},
+		PRs: []*PullRequest{
+			{Title: "merged", Number: 6, URL: "https://github.com/sourcegraph/sourcegraph/pull/6", State: "MERGED", Author: "bob", ClosedAt: day(3)},
+			{Title: "closed", Number: 7, URL: "https://github.com/sourcegraph/sourcegraph/pull/7", State: "CLOSED", Author: "bob", ClosedAt: day(3)},
+		},
+		Config: &IssueConfig{Org: "sourcegraph", CompletedByWeek: true},
+	}
+
+	md := ti.Workloads().Markdown()
+	i := strings.Index(md, "🗓️ __Completed__")
+	if i == -1 {
+		t.Fatalf("no completed section:\n%s", md)
+	}
+
+	want := `🗓️ __Completed__
+
+_Week of 2020-06-08_
+
+- [x] issue 5 [#5](https://github.com/sourcegraph/sourcegraph/issues/5) __1d__ 
+- [x] issue 1 [#1](https://github.com/sourcegraph/sourcegraph/issues/1) __1d__ 
+
+_Week of 2020-06-01_
+
+- [x] issue 2 [#2](https://github.com/sourcegraph/sourcegraph/issues/2) __1d__ 
+- [x] merged [#6](https://github.com/sourcegraph/sourcegraph/pull/6) :shipit:
+`
+
+	if have := md[i:]; have != want {
+		t.Errorf("completed section:\n%s", UnifiedDiff("want", "have", want, have))
+	}
+
+	ti.Config.CompletedByWeek = false
+	ti.workloads = nil
+	if md := ti.Workloads().Markdown(); strings.Contains(md, "Completed") {
+		t.Errorf("completed section rendered without completedByWeek:\n%s", md)
+	}
+}
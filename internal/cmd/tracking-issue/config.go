@@ -0,0 +1,456 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Config is the configuration of a run, usually read from a
+// tracking-issue.yaml file such as:
+//
+//	issues:
+//	  - org: sourcegraph
+//	    orgs: [sourcegraph-enterprise]
+//	    repos: [acme/sourcegraph-fork]
+//	    milestone: "3.14"
+//	    labels: [team/core-services]
+//	    labelAllowlist: [team/core-services, customer]
+//	    labelExpression: NOT icebox
+//	    project: 12
+//	    column: In Progress
+//	    capacity:
+//	      kzh: 8
+//	      mrnugget: 10
+//	    rotations:
+//	      - name: on-call
+//	        assignees: [kzh]
+//	        start: 2020-06-08
+//	        end: 2020-06-12
+//	        milestone: "3.14"
+//	    reviewCost: 0.25
+//	    carryOver: true
+//	    timeline:
+//	      inProgressLabel: in progress
+//	    categories:
+//	      roadmap: planned
+//	      debt: planned
+//	      bug: reactive
+//	    completedByWeek: true
+//	    staleDays: 7
+//	    projectFields:
+//	      project: 12
+//	      estimate: Estimate
+//	      status: Status
+//	      done: [Done, Shipped]
+//	    customers:
+//	      - label: ^customer/(.+)$
+//	        url: https://crm.example.com/companies/$1
+//	        emoji: 🏢
+//	    estimates:
+//	      label: size/
+//	      unit: pts
+//	      sizes: {S: 1, M: 3, L: 5}
+//	    markers:
+//	      begin: "<!-- BEGIN WORK -->"
+//	      end: "<!-- END WORK -->"
+//	    sort:
+//	      assignees: remaining
+//	      issues: estimate
+//	    templates: tracking-issue.tmpl
+//	    format: markdown
+type Config struct {
+	// Issues defines the tracking issues to update.
+	Issues []*IssueConfig `yaml:"issues"`
+}
+
+// IssueConfig defines a set of tracking issues and how to update them.
+type IssueConfig struct {
+	// Org is the GitHub organization to list tracking issues and their work
+	// from, or the GitLab group with -provider=gitlab.
+	Org string `yaml:"org"`
+
+	// Orgs and Repos (owner/name) add to the orgs that the work of the
+	// tracking issues is tracked in, for teams whose work spans several orgs
+	// or private repositories elsewhere. The issues and pull requests of all
+	// of them are merged into each tracking issue, and rendered with their
+	// repository.
+	Orgs  []string `yaml:"orgs"`
+	Repos []string `yaml:"repos"`
+
+	// Milestone, if set, limits the definition to the tracking issues of
+	// this milestone.
+	Milestone string `yaml:"milestone"`
+
+	// Labels, if set, limits the definition to the tracking issues that have
+	// all of these labels (besides "tracking").
+	Labels []string `yaml:"labels"`
+
+	// LabelAllowlist, if set, limits the tracked work to the issues and pull
+	// requests that have at least one of these labels.
+	LabelAllowlist []string `yaml:"labelAllowlist"`
+
+	// LabelExpression, if set, limits the tracked work to the issues and pull
+	// requests whose labels satisfy this boolean expression, such as
+	// "team/code-intelligence AND NOT icebox OR customer". See LabelExpr.
+	LabelExpression string `yaml:"labelExpression"`
+
+	// Project, if set, limits the tracked work to the items of the GitHub
+	// project with this number. With Project or labels, tracking issues
+	// without a milestone can track long-running initiatives.
+	Project int `yaml:"project"`
+
+	// Column, if set, limits the tracked issues to those with this status in
+	// Project, read from the ProjectFields status field ("Status" by
+	// default). Pull requests are tracked as long as they are in the project.
+	Column string `yaml:"column"`
+
+	// Capacity is the number of days (or the unit of the estimates) each
+	// assignee is available in the milestone, keyed by GitHub login.
+	// Assignees whose estimated work exceeds their capacity are flagged as
+	// overcommitted. A capacity of 0 means the assignee isn't available,
+	// while assignees without a capacity are never flagged.
+	Capacity map[string]float64 `yaml:"capacity"`
+
+	// Rotations reduce the capacity of their assignees in the milestone of
+	// the rotation by the weekdays they are on a rotation, such as on-call or
+	// support, and annotate their workloads.
+	Rotations []*Rotation `yaml:"rotations"`
+
+	// ReviewCost, if positive, is the number of days that reviewing an open
+	// pull request adds to the workload of each requested reviewer.
+	ReviewCost float64 `yaml:"reviewCost"`
+
+	// Customers annotate the issues and pull requests of customers or
+	// partners. If unset, customer issues are linked to Sourcegraph's HubSpot.
+	Customers []*CustomerMapping `yaml:"customers"`
+
+	// CarryOver enables the detection of open issues that were listed in the
+	// tracking issue of the previous milestone. They are marked with ↩️ and
+	// their estimates are summed separately.
+	CarryOver bool `yaml:"carryOver"`
+
+	// PreviousMilestone is the milestone before Milestone. By default, it is
+	// derived from milestones named like versions, such as 3.13 for 3.14.
+	PreviousMilestone string `yaml:"previousMilestone"`
+
+	// Categories maps labels to categories, such as "planned" or "reactive".
+	// If set, the estimates are also summed by category. Issues are counted in
+	// the category of their first mapped label, or in "other".
+	Categories map[string]string `yaml:"categories"`
+
+	// StaleDays, if positive, flags the open issues that are assigned and
+	// planned for the milestone, but had no activity for this many days, with
+	// 💤. With -nudge, their assignees are reminded in a comment.
+	StaleDays int `yaml:"staleDays"`
+
+	// CompletedByWeek appends the closed issues and merged pull requests,
+	// grouped by the week they were completed in, below the planned work, so
+	// that the tracking issue doubles as a changelog of the iteration.
+	CompletedByWeek bool `yaml:"completedByWeek"`
+
+	// ProjectFields, if set, reads the estimates and statuses of issues from
+	// the fields of a GitHub project instead of labels.
+	ProjectFields *ProjectFields `yaml:"projectFields"`
+
+	// Timeline, if set, fetches the timelines of issues to measure how long
+	// they waited and were in progress. The cycle times are summarized in a
+	// table below the workloads.
+	Timeline *TimelineMetrics `yaml:"timeline"`
+
+	// Estimates configures the estimate labels and their unit.
+	Estimates Estimates `yaml:"estimates"`
+
+	// Markers delimit the work section in the tracking issue body.
+	Markers Markers `yaml:"markers"`
+
+	// Sort orders the assignee sections and their issues.
+	Sort Sort `yaml:"sort"`
+
+	// Templates is the path of a file with Go templates that override how
+	// parts of the work section are rendered, such as the issue lines. A
+	// relative path is relative to the configuration file. See
+	// templateRenderer.
+	Templates string `yaml:"templates"`
+
+	// Format is the output format. With "markdown", the default, the work
+	// section in the tracking issue body is updated. With "json" or "csv",
+	// the workloads are written to stdout instead, for use in dashboards and
+	// spreadsheets, and the tracking issue is left untouched.
+	Format string `yaml:"format"`
+
+	labelExpr *LabelExpr
+	renderer  Renderer
+}
+
+// Matches reports whether an issue or pull request with the given labels is
+// part of the tracked work.
+func (ic *IssueConfig) Matches(labels []string) bool {
+	if len(ic.LabelAllowlist) > 0 {
+		var allowed bool
+		for _, label := range ic.LabelAllowlist {
+			if has(label, labels) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+
+	return ic.labelExpr == nil || ic.labelExpr.Matches(labels)
+}
+
+// SearchQualifiers returns the GitHub search qualifiers that narrow down the
+// searches for the tracked work, if a project or label expression is set.
+func (ic *IssueConfig) SearchQualifiers() string {
+	var q string
+
+	// Multiple org and repo qualifiers match the union of their work.
+	for _, org := range ic.Orgs {
+		q += fmt.Sprintf(" org:%q", org)
+	}
+	for _, repo := range ic.Repos {
+		q += fmt.Sprintf(" repo:%q", repo)
+	}
+
+	return q + ic.filterQualifiers()
+}
+
+// filterQualifiers returns the qualifiers of the project and label expression
+// of the definition, which narrow down the work within its orgs and repos.
+func (ic *IssueConfig) filterQualifiers() string {
+	var q string
+	if ic.Project != 0 {
+		q += fmt.Sprintf(" project:%s/%d", ic.Org, ic.Project)
+	}
+	if ic.labelExpr != nil {
+		q += ic.labelExpr.SearchQualifiers()
+	}
+	return q
+}
+
+// narrowsWork reports whether the project or label expression of the
+// definition restrict the work to a subset of its orgs and repos. Excluded
+// labels alone don't, because everything else still matches.
+func (ic *IssueConfig) narrowsWork() bool {
+	if ic.Project != 0 {
+		return true
+	}
+	if ic.labelExpr == nil {
+		return false
+	}
+	include, _ := ic.labelExpr.constraints()
+	return len(include) > 0
+}
+
+// Aggregated reports whether the work is tracked in more than one org or
+// repository set.
+func (ic *IssueConfig) Aggregated() bool {
+	return len(ic.Orgs) > 0 || len(ic.Repos) > 0
+}
+
+// TracksOrg reports whether work in the org can be tracked by the definition.
+func (ic *IssueConfig) TracksOrg(org string) bool {
+	if strings.EqualFold(org, ic.Org) {
+		return true
+	}
+	for _, o := range ic.Orgs {
+		if strings.EqualFold(org, o) {
+			return true
+		}
+	}
+	for _, repo := range ic.Repos {
+		if owner := strings.SplitN(repo, "/", 2)[0]; strings.EqualFold(org, owner) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ic *IssueConfig) searchFields() searchFields {
+	return searchFields{
+		projectItems: ic.ProjectFields != nil,
+		timeline:     ic.Timeline != nil,
+	}
+}
+
+// InColumn reports whether the issue is part of the tracked work according to
+// its project status, if a column is set.
+func (ic *IssueConfig) InColumn(issue *Issue) bool {
+	return ic.Column == "" || strings.EqualFold(issue.status, ic.Column)
+}
+
+// Markers delimit the section of a tracking issue body that is replaced on
+// each run. Text outside the markers is left untouched.
+type Markers struct {
+	Begin string `yaml:"begin"`
+	End   string `yaml:"end"`
+}
+
+var defaultMarkers = Markers{
+	Begin: "<!-- BEGIN WORK -->",
+	End:   "<!-- END WORK -->",
+}
+
+// ReadConfig reads the YAML configuration file at path.
+func ReadConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", path, err)
+	}
+
+	for _, ic := range cfg.Issues {
+		if ic != nil && ic.Templates != "" && !filepath.IsAbs(ic.Templates) {
+			ic.Templates = filepath.Join(filepath.Dir(path), ic.Templates)
+		}
+	}
+
+	if err := cfg.init(); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	return &cfg, nil
+}
+
+// init validates the configuration and fills in defaults.
+func (c *Config) init() error {
+	if len(c.Issues) == 0 {
+		return fmt.Errorf("no issues defined")
+	}
+
+	for i, ic := range c.Issues {
+		if ic == nil {
+			return fmt.Errorf("issues[%d]: empty definition", i)
+		}
+
+		if err := ic.init(); err != nil {
+			return fmt.Errorf("issues[%d]: %v", i, err)
+		}
+	}
+
+	return nil
+}
+
+func (ic *IssueConfig) init() error {
+	if ic.Org == "" {
+		return fmt.Errorf("no org given")
+	}
+
+	for _, repo := range ic.Repos {
+		if parts := strings.Split(repo, "/"); len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("invalid repo %q, want owner/name", repo)
+		}
+	}
+
+	if ic.LabelExpression != "" {
+		e, err := ParseLabelExpr(ic.LabelExpression)
+		if err != nil {
+			return err
+		}
+		ic.labelExpr = e
+	}
+
+	for assignee, days := range ic.Capacity {
+		if days < 0 {
+			return fmt.Errorf("negative capacity for %s", assignee)
+		}
+	}
+
+	for i, m := range ic.Customers {
+		if m == nil {
+			return fmt.Errorf("customers[%d]: empty mapping", i)
+		}
+		if err := m.compile(); err != nil {
+			return fmt.Errorf("customers[%d]: %v", i, err)
+		}
+	}
+
+	for label, category := range ic.Categories {
+		if category == "" {
+			return fmt.Errorf("empty category for label %s", label)
+		}
+	}
+
+	if ic.ReviewCost < 0 {
+		return fmt.Errorf("negative reviewCost")
+	}
+
+	if ic.Column != "" {
+		if ic.Project == 0 {
+			return fmt.Errorf("column needs a project")
+		}
+		if ic.ProjectFields == nil {
+			ic.ProjectFields = &ProjectFields{}
+		}
+		if ic.ProjectFields.Project == 0 {
+			ic.ProjectFields.Project = ic.Project
+		}
+		if ic.ProjectFields.Status == "" {
+			ic.ProjectFields.Status = "Status"
+		}
+	}
+
+	if pf := ic.ProjectFields; pf != nil {
+		if pf.Estimate == "" && pf.Status == "" {
+			return fmt.Errorf("projectFields needs an estimate or status field")
+		}
+		if len(pf.Done) == 0 {
+			pf.Done = []string{"Done"}
+		}
+	}
+
+	if ic.StaleDays < 0 {
+		return fmt.Errorf("negative staleDays")
+	}
+
+	if err := ic.Estimates.init(); err != nil {
+		return fmt.Errorf("estimates: %v", err)
+	}
+
+	for i, r := range ic.Rotations {
+		if r == nil {
+			return fmt.Errorf("rotations[%d]: empty rotation", i)
+		}
+		if err := r.init(ic.Estimates.unit()); err != nil {
+			return fmt.Errorf("rotations[%d]: %v", i, err)
+		}
+	}
+
+	if ic.Markers.Begin == "" && ic.Markers.End == "" {
+		ic.Markers = defaultMarkers
+	} else if ic.Markers.Begin == "" || ic.Markers.End == "" {
+		return fmt.Errorf("markers must have both begin and end")
+	}
+
+	if err := ic.Sort.init(); err != nil {
+		return err
+	}
+
+	if ic.Templates != "" {
+		r, err := parseTemplates(ic.Templates)
+		if err != nil {
+			return fmt.Errorf("templates: %v", err)
+		}
+		ic.renderer = r
+	}
+
+	switch ic.Format {
+	case "":
+		ic.Format = "markdown"
+	case "markdown", "json", "csv":
+	default:
+		return fmt.Errorf("unsupported format %q", ic.Format)
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadConfig(t *testing.T) {
+	cfg, err := ReadConfig(filepath.Join("testdata", "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Config{Issues: []*IssueConfig{
+		{
+			Org:            "sourcegraph",
+			Milestone:      "3.14",
+			Labels:         []string{"team/core-services"},
+			LabelAllowlist: []string{"team/core-services", "customer"},
+			Estimates:      defaultEstimates,
+			Markers:        defaultMarkers,
+			Sort:           Sort{Assignees: "name", Issues: "search"},
+			Format:         "markdown",
+		},
+		{
+			Org:       "sourcegraph",
+			Labels:    []string{"team/search"},
+			Estimates: defaultEstimates,
+			Markers: Markers{
+				Begin: "<!-- BEGIN SEARCH WORK -->",
+				End:   "<!-- END SEARCH WORK -->",
+			},
+			Sort:   Sort{Assignees: "name", Issues: "search"},
+			Format: "markdown",
+		},
+	}}
+
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("have %+v, want %+v", cfg, want)
+	}
+}
+
+func TestConfigInit(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cfg  Config
+		err  string
+	}{
+		{name: "no issues", err: "no issues defined"},
+		{
+			name: "no org",
+			cfg:  Config{Issues: []*IssueConfig{{Milestone: "3.14"}}},
+			err:  "issues[0]: no org given",
+		},
+		{
+			name: "one marker",
+			cfg:  Config{Issues: []*IssueConfig{{Org: "sourcegraph", Markers: Markers{Begin: "<!-- BEGIN -->"}}}},
+			err:  "issues[0]: markers must have both begin and end",
+		},
+		{
+			name: "empty project fields",
+			cfg:  Config{Issues: []*IssueConfig{{Org: "sourcegraph", ProjectFields: &ProjectFields{Project: 1}}}},
+			err:  "issues[0]: projectFields needs an estimate or status field",
+		},
+		{
+			name: "invalid repo",
+			cfg:  Config{Issues: []*IssueConfig{{Org: "sourcegraph", Repos: []string{"enterprise"}}}},
+			err:  `issues[0]: invalid repo "enterprise", want owner/name`,
+		},
+		{
+			name: "column without project",
+			cfg:  Config{Issues: []*IssueConfig{{Org: "sourcegraph", Column: "In Progress"}}},
+			err:  "issues[0]: column needs a project",
+		},
+		{
+			name: "unsupported sort order",
+			cfg:  Config{Issues: []*IssueConfig{{Org: "sourcegraph", Sort: Sort{Issues: "title"}}}},
+			err:  `issues[0]: unsupported issue sort order "title"`,
+		},
+		{
+			name: "negative size",
+			cfg:  Config{Issues: []*IssueConfig{{Org: "sourcegraph", Estimates: Estimates{Sizes: map[string]float64{"XS": -1}}}}},
+			err:  "issues[0]: estimates: negative estimate for size XS",
+		},
+		{
+			name: "sizes differing by case",
+			cfg:  Config{Issues: []*IssueConfig{{Org: "sourcegraph", Estimates: Estimates{Sizes: map[string]float64{"s": 2, "S": 1}}}}},
+			err:  "issues[0]: estimates: sizes S and s differ only by case",
+		},
+		{
+			name: "unsupported format",
+			cfg:  Config{Issues: []*IssueConfig{{Org: "sourcegraph", Format: "yaml"}}},
+			err:  `issues[0]: unsupported format "yaml"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.init()
+			if err == nil || err.Error() != tc.err {
+				t.Errorf("error: have %v, want %q", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestIssueConfigProject(t *testing.T) {
+	ic := &IssueConfig{
+		Org:             "sourcegraph",
+		Project:         12,
+		Column:          "In Progress",
+		LabelExpression: "NOT icebox",
+	}
+
+	if err := ic.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := &ProjectFields{Project: 12, Status: "Status", Done: []string{"Done"}}
+	if !reflect.DeepEqual(ic.ProjectFields, want) {
+		t.Errorf("project fields: have %+v, want %+v", ic.ProjectFields, want)
+	}
+
+	if have, want := ic.SearchQualifiers(), ` project:sourcegraph/12 -label:"icebox"`; have != want {
+		t.Errorf("search qualifiers: have %q, want %q", have, want)
+	}
+
+	for status, want := range map[string]bool{"in progress": true, "Done": false, "": false} {
+		if have := ic.InColumn(&Issue{status: status}); have != want {
+			t.Errorf("status %q: have in column %t, want %t", status, have, want)
+		}
+	}
+}
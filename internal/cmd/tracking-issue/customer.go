@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// CustomerMapping annotates the issues and pull requests of a customer or
+// partner with an emoji, linked to the customer in a CRM or another tracker
+// when the link is known.
+type CustomerMapping struct {
+	// Label is a regular expression matched against the labels, such as
+	// "^customer/(.+)$". Either Label or Repository must be set, and the
+	// mapping applies if either matches.
+	Label string `yaml:"label"`
+
+	// Repository is a regular expression matched against the repository name
+	// (owner/name), for repositories that only contain customer issues.
+	Repository string `yaml:"repository"`
+
+	// URL is a template for the link, in which $1, ${name} etc. are replaced
+	// with the submatches of Label, such as "https://crm.example.com/$1".
+	URL string `yaml:"url"`
+
+	// BodyURL is a regular expression matching the link in the body of the
+	// issue or pull request. It is used if URL is empty.
+	BodyURL string `yaml:"bodyURL"`
+
+	// Emoji is the annotation. It defaults to 👩.
+	Emoji string `yaml:"emoji"`
+
+	label, repository, bodyURL *regexp.Regexp
+}
+
+// defaultCustomerMappings link Sourcegraph customer issues to HubSpot.
+var defaultCustomerMappings = mustCompileCustomerMappings(
+	&CustomerMapping{
+		Repository: `^sourcegraph/customer$`,
+		BodyURL:    `https://app\.hubspot\.com/contacts/2762526/company/\d+`,
+	},
+	&CustomerMapping{
+		Label:   `^customer$`,
+		BodyURL: `https://app\.hubspot\.com/contacts/2762526/company/\d+`,
+	},
+)
+
+func mustCompileCustomerMappings(ms ...*CustomerMapping) []*CustomerMapping {
+	for _, m := range ms {
+		if err := m.compile(); err != nil {
+			panic(err)
+		}
+	}
+	return ms
+}
+
+func (m *CustomerMapping) compile() (err error) {
+	if m.Label == "" && m.Repository == "" {
+		return fmt.Errorf("customer mapping needs a label or repository")
+	}
+
+	compile := func(field, expr string) *regexp.Regexp {
+		if expr == "" || err != nil {
+			return nil
+		}
+		re, cerr := regexp.Compile(expr)
+		if cerr != nil {
+			err = fmt.Errorf("customer mapping %s: %v", field, cerr)
+		}
+		return re
+	}
+
+	m.label = compile("label", m.Label)
+	m.repository = compile("repository", m.Repository)
+	m.bodyURL = compile("bodyURL", m.BodyURL)
+
+	if m.Emoji == "" {
+		m.Emoji = "👩"
+	}
+
+	return err
+}
+
+// Annotation returns the annotation of an issue or pull request, and whether
+// the mapping applies to it.
+func (m *CustomerMapping) Annotation(labels []string, repository, body string) (string, bool) {
+	var url string
+
+	switch {
+	case m.repository != nil && m.repository.MatchString(repository):
+		if m.bodyURL != nil {
+			url = m.bodyURL.FindString(body)
+		}
+	case m.label != nil:
+		var match []int
+		var label string
+		for _, label = range labels {
+			if match = m.label.FindStringSubmatchIndex(label); match != nil {
+				break
+			}
+		}
+
+		if match == nil {
+			return "", false
+		}
+
+		if m.URL != "" {
+			url = string(m.label.ExpandString(nil, m.URL, label, match))
+		} else if m.bodyURL != nil {
+			url = m.bodyURL.FindString(body)
+		}
+	default:
+		return "", false
+	}
+
+	if url == "" {
+		return m.Emoji, true
+	}
+	return "[" + m.Emoji + "](" + url + ")", true
+}
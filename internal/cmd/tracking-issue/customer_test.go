@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+func TestCategoriesCustomers(t *testing.T) {
+	const hubspot = "https://app.hubspot.com/contacts/2762526/company/42"
+
+	partners := mustCompileCustomerMappings(
+		&CustomerMapping{Label: `^customer/(?P<name>.+)$`, URL: "https://crm.example.com/${name}", Emoji: "🏢"},
+		&CustomerMapping{Label: `^partner$`, Emoji: "🤝"},
+	)
+
+	for _, tc := range []struct {
+		name       string
+		labels     []string
+		repository string
+		body       string
+		customers  []*CustomerMapping
+		want       string
+	}{
+		{
+			name:   "default label with link",
+			labels: []string{"customer", "bug"},
+			body:   "Reported by " + hubspot,
+			want:   "[👩](" + hubspot + ")🐛",
+		},
+		{
+			name:       "default repository and label",
+			labels:     []string{"customer"},
+			repository: "sourcegraph/customer",
+			want:       "👩",
+		},
+		{
+			name:   "default ignores other labels",
+			labels: []string{"customer/acme"},
+			want:   "",
+		},
+		{
+			name:      "url template",
+			labels:    []string{"customer/acme", "partner"},
+			customers: partners,
+			want:      "[🏢](https://crm.example.com/acme)🤝",
+		},
+		{
+			name:      "configured mappings replace the defaults",
+			labels:    []string{"customer"},
+			customers: partners,
+			want:      "",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			have := Emojis(Categories(tc.labels, tc.repository, tc.body, tc.customers))
+			if have != tc.want {
+				t.Errorf("have %q, want %q", have, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines around the changes in a hunk.
+const diffContext = 3
+
+// UnifiedDiff returns the unified diff of the lines of a and b, or "" if they
+// are equal.
+func UnifiedDiff(aName, bName, a, b string) string {
+	if a == b {
+		return ""
+	}
+
+	as, bs := splitLines(a), splitLines(b)
+	edits := diffLines(as, bs)
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", aName, bName)
+
+	for i := 0; i < len(edits); {
+		// Skip to the next change.
+		if edits[i].op == ' ' {
+			i++
+			continue
+		}
+
+		// A hunk starts with up to diffContext unchanged lines, and extends
+		// over changes that are at most 2*diffContext unchanged lines apart.
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+
+		end := i
+		for end < len(edits) {
+			if edits[end].op != ' ' {
+				end++
+				continue
+			}
+
+			next := end
+			for next < len(edits) && edits[next].op == ' ' {
+				next++
+			}
+
+			if next == len(edits) || next-end > 2*diffContext {
+				end += diffContext
+				if end > len(edits) {
+					end = len(edits)
+				}
+				break
+			}
+			end = next
+		}
+
+		hunk := edits[start:end]
+
+		aStart, bStart := hunk[0].a+1, hunk[0].b+1
+		var aLen, bLen int
+		for _, e := range hunk {
+			if e.op != '+' {
+				aLen++
+			}
+			if e.op != '-' {
+				bLen++
+			}
+		}
+
+		// Empty ranges start at the line before them.
+		if aLen == 0 {
+			aStart--
+		}
+		if bLen == 0 {
+			bStart--
+		}
+
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+		for _, e := range hunk {
+			out.WriteByte(e.op)
+			out.WriteString(e.line)
+			out.WriteByte('\n')
+		}
+
+		i = end
+	}
+
+	return out.String()
+}
+
+// lineEdit is a line of a diff. a and b are the indexes of the line in the
+// old and new lines, or of the next line if it isn't part of them.
+type lineEdit struct {
+	op   byte // ' ', '-' or '+'
+	line string
+	a, b int
+}
+
+// diffLines returns the edits from a to b, based on their longest common
+// subsequence.
+func diffLines(a, b []string) []lineEdit {
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and
+	// b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var edits []lineEdit
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, lineEdit{' ', a[i], i, j})
+			i++
+			j++
+		case j == len(b) || (i < len(a) && lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, lineEdit{'-', a[i], i, j})
+			i++
+		default:
+			edits = append(edits, lineEdit{'+', b[j], i, j})
+			j++
+		}
+	}
+
+	return edits
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
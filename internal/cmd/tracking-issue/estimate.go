@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Estimates configures the estimate labels of issues and the unit their sums
+// are rendered in, for teams that don't estimate in days.
+type Estimates struct {
+	// Label is the prefix of the estimate labels. It defaults to estimate/,
+	// as in estimate/3d. Other teams use labels like sp/5 or size/L.
+	Label string `yaml:"label"`
+
+	// Unit is appended to estimates and their sums, such as d (the default)
+	// or pts. A trailing unit is stripped from the labels before they are
+	// parsed as numbers, so both estimate/3d and estimate/3 count as 3d.
+	Unit string `yaml:"unit"`
+
+	// Sizes maps the values of estimate labels that aren't numbers to
+	// estimates in the unit, such as L: 5 for size/L.
+	Sizes map[string]float64 `yaml:"sizes"`
+}
+
+// defaultEstimates are the estimates of issues without a configuration.
+var defaultEstimates = Estimates{Label: "estimate/", Unit: "d"}
+
+func (e *Estimates) init() error {
+	if e.Label == "" {
+		e.Label = defaultEstimates.Label
+	}
+
+	if e.Unit == "" {
+		e.Unit = defaultEstimates.Unit
+	}
+
+	// Sizes are matched case insensitively, so sizes that differ only by case
+	// would make the estimate ambiguous.
+	sizes := map[string]string{}
+	for _, size := range sortedSizes(e.Sizes) {
+		if e.Sizes[size] < 0 {
+			return fmt.Errorf("negative estimate for size %s", size)
+		}
+		if other, ok := sizes[strings.ToLower(size)]; ok {
+			return fmt.Errorf("sizes %s and %s differ only by case", other, size)
+		}
+		sizes[strings.ToLower(size)] = size
+	}
+
+	return nil
+}
+
+// unit returns the unit, or the default unit of estimates that weren't
+// initialized.
+func (e *Estimates) unit() string {
+	if e.Unit == "" {
+		return defaultEstimates.Unit
+	}
+	return e.Unit
+}
+
+// Estimate returns the value of the first estimate label, such as 3d for
+// estimate/3d, or "" if there is none.
+func (e *Estimates) Estimate(labels []string) string {
+	prefix := e.Label
+	if prefix == "" {
+		prefix = defaultEstimates.Label
+	}
+
+	for _, label := range labels {
+		if strings.HasPrefix(label, prefix) {
+			return label[len(prefix):]
+		}
+	}
+	return ""
+}
+
+// Value returns the estimate in the unit, or 0 if it is unknown.
+func (e *Estimates) Value(estimate string) float64 {
+	if v, ok := e.Sizes[estimate]; ok {
+		return v
+	}
+
+	// Sizes are matched case insensitively if there's no exact match. init
+	// rejects sizes that differ only by case, so at most one matches.
+	for size, v := range e.Sizes {
+		if strings.EqualFold(size, estimate) {
+			return v
+		}
+	}
+
+	v, _ := strconv.ParseFloat(strings.TrimSuffix(estimate, e.unit()), 64)
+	return v
+}
+
+// sortedSizes returns the sizes in order.
+func sortedSizes(sizes map[string]float64) []string {
+	names := make([]string, 0, len(sizes))
+	for size := range sizes {
+		names = append(names, size)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// formatEstimate renders a sum of estimates in the unit, such as 2.50d.
+func formatEstimate(v float64, unit string) string {
+	return strconv.FormatFloat(v, 'f', 2, 64) + unit
+}
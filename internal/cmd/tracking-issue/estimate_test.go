@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestEstimates(t *testing.T) {
+	sizes := Estimates{Label: "size/", Unit: "pts", Sizes: map[string]float64{"S": 1, "M": 3, "L": 5}}
+
+	for _, tc := range []struct {
+		name      string
+		estimates Estimates
+		labels    []string
+		estimate  string
+		value     float64
+	}{
+		{name: "default", labels: []string{"bug", "estimate/3d"}, estimate: "3d", value: 3},
+		{name: "default without unit", labels: []string{"estimate/0.5"}, estimate: "0.5", value: 0.5},
+		{name: "points", estimates: Estimates{Label: "sp/", Unit: "pts"}, labels: []string{"sp/5"}, estimate: "5", value: 5},
+		{name: "points with unit", estimates: Estimates{Label: "sp/", Unit: "pts"}, labels: []string{"sp/8pts"}, estimate: "8pts", value: 8},
+		{name: "size", estimates: sizes, labels: []string{"size/L"}, estimate: "L", value: 5},
+		{name: "size case insensitive", estimates: sizes, labels: []string{"size/m"}, estimate: "m", value: 3},
+		{name: "unknown size", estimates: sizes, labels: []string{"size/XXL"}, estimate: "XXL"},
+		{name: "other prefix", estimates: sizes, labels: []string{"estimate/2d"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.estimates.init(); err != nil {
+				t.Fatal(err)
+			}
+
+			issue := &Issue{Labels: tc.labels, estimates: &tc.estimates}
+			if have := issue.Estimate(); have != tc.estimate {
+				t.Errorf("estimate: have %q, want %q", have, tc.estimate)
+			}
+			if have := issue.estimateValue(); have != tc.value {
+				t.Errorf("value: have %v, want %v", have, tc.value)
+			}
+		})
+	}
+}
+
+func TestEstimatesUnit(t *testing.T) {
+	issue := func(number int, size string) *Issue {
+		return &Issue{
+			Number:    number,
+			URL:       fmt.Sprintf("https://github.com/sourcegraph/sourcegraph/issues/%d", number),
+			State:     "OPEN",
+			Milestone: "3.17",
+			Assignees: []string{"alice"},
+			Labels:    []string{"size/" + size},
+		}
+	}
+
+	ti := &TrackingIssue{
+		Issue:  &Issue{Milestone: "3.17"},
+		Issues: []*Issue{issue(1, "S"), issue(2, "L")},
+		Config: &IssueConfig{
+			Org:       "sourcegraph",
+			Capacity:  map[string]float64{"alice": 5},
+			Estimates: Estimates{Label: "size/", Unit: "pts", Sizes: map[string]float64{"S": 1, "L": 5}},
+		},
+	}
+
+	if err := ti.Config.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	md := ti.Workloads().Markdown()
+	for _, want := range []string{
+		"- @alice: __6.00pts__ estimated, 5.00pts available\n",
+		"@alice: __6.00pts__ of 5.00pts ⚠️\n",
+		"[#2](https://github.com/sourcegraph/sourcegraph/issues/2) __L__ \n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown doesn't contain %q:\n%s", want, md)
+		}
+	}
+
+	if have, want := SlackSummary(ti, nil), "6.00pts estimated, 6.00pts remaining"; !strings.Contains(have, want) {
+		t.Errorf("Slack summary doesn't contain %q:\n%s", want, have)
+	}
+}
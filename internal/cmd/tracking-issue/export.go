@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ExportedTrackingIssue is the JSON representation of a tracking issue and its
+// workloads.
+type ExportedTrackingIssue struct {
+	Number    int                `json:"number"`
+	Title     string             `json:"title"`
+	URL       string             `json:"url"`
+	Milestone string             `json:"milestone,omitempty"`
+	Days      float64            `json:"days"`
+	Workloads []ExportedWorkload `json:"workloads"`
+}
+
+type ExportedWorkload struct {
+	Assignee      string             `json:"assignee"`
+	Days          float64            `json:"days"`
+	CarriedOver   float64            `json:"carriedOver,omitempty"`
+	Categories    map[string]float64 `json:"categories,omitempty"`
+	ReviewDays    float64            `json:"reviewDays,omitempty"`
+	Capacity      *float64           `json:"capacity,omitempty"` // nil if unknown
+	RotationDays  float64            `json:"rotationDays,omitempty"`
+	Overcommitted bool               `json:"overcommitted"`
+	Issues        []ExportedItem     `json:"issues"`
+	PullRequests  []ExportedItem     `json:"pullRequests"`
+}
+
+// ExportedItem is an issue or pull request. Linked holds the URLs of the pull
+// requests linked to an issue, or of the issues linked to a pull request.
+type ExportedItem struct {
+	Number        int      `json:"number"`
+	Title         string   `json:"title"`
+	URL           string   `json:"url"`
+	Repository    string   `json:"repository"`
+	State         string   `json:"state"`
+	Labels        []string `json:"labels"`
+	Estimate      float64  `json:"estimate,omitempty"`
+	Deprioritised bool     `json:"deprioritised,omitempty"`
+	CarriedOver   bool     `json:"carriedOver,omitempty"`
+	Linked        []string `json:"linked"`
+}
+
+// Export returns the workloads of the tracking issue.
+func (t *TrackingIssue) Export() ExportedTrackingIssue {
+	e := ExportedTrackingIssue{
+		Number:    t.Number,
+		Title:     t.Title,
+		URL:       t.URL,
+		Milestone: t.Milestone,
+		Workloads: []ExportedWorkload{},
+	}
+
+	workloads := t.Workloads().ByAssignee
+
+	assignees := make([]string, 0, len(workloads))
+	for assignee := range workloads {
+		assignees = append(assignees, assignee)
+	}
+
+	sort.Strings(assignees)
+
+	for _, assignee := range assignees {
+		wl := workloads[assignee]
+		ew := ExportedWorkload{
+			Assignee:      wl.Assignee,
+			Days:          wl.Days,
+			CarriedOver:   wl.CarriedOver,
+			Categories:    wl.Categories,
+			ReviewDays:    wl.ReviewDays,
+			RotationDays:  wl.RotationDays,
+			Overcommitted: wl.Overcommitted(),
+			Issues:        make([]ExportedItem, 0, len(wl.Issues)),
+			PullRequests:  make([]ExportedItem, 0, len(wl.PullRequests)),
+		}
+
+		if wl.HasCapacity {
+			capacity := wl.Capacity
+			ew.Capacity = &capacity
+		}
+
+		for _, issue := range wl.Issues {
+			item := ExportedItem{
+				Number:        issue.Number,
+				Title:         exportedTitle(issue.Title, issue.Repository, issue.Private),
+				URL:           issue.URL,
+				Repository:    issue.Repository,
+				State:         issue.State,
+				Labels:        issue.Labels,
+				Estimate:      issue.estimateValue(),
+				Deprioritised: issue.Deprioritised,
+				CarriedOver:   issue.CarriedOver,
+				Linked:        make([]string, 0, len(issue.LinkedPRs)),
+			}
+			for _, pr := range issue.LinkedPRs {
+				item.Linked = append(item.Linked, pr.URL)
+			}
+			ew.Issues = append(ew.Issues, item)
+		}
+
+		for _, pr := range wl.PullRequests {
+			item := ExportedItem{
+				Number:     pr.Number,
+				Title:      exportedTitle(pr.Title, pr.Repository, pr.Private),
+				URL:        pr.URL,
+				Repository: pr.Repository,
+				State:      pr.State,
+				Labels:     pr.Labels,
+				Linked:     make([]string, 0, len(pr.LinkedIssues)),
+			}
+			for _, issue := range pr.LinkedIssues {
+				item.Linked = append(item.Linked, issue.URL)
+			}
+			ew.PullRequests = append(ew.PullRequests, item)
+		}
+
+		e.Days += ew.Days
+		e.Workloads = append(e.Workloads, ew)
+	}
+
+	return e
+}
+
+// exportedTitle hides the titles of private issues and pull requests, like the
+// Markdown does.
+func exportedTitle(title, repository string, private bool) string {
+	if private {
+		return repository
+	}
+	return title
+}
+
+func writeJSON(w io.Writer, issues []*TrackingIssue) error {
+	exported := make([]ExportedTrackingIssue, 0, len(issues))
+	for _, issue := range issues {
+		exported = append(exported, issue.Export())
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(exported)
+}
+
+var csvHeader = []string{
+	"tracking_issue", "assignee", "type", "number", "title", "url",
+	"repository", "state", "labels", "estimate", "deprioritised", "linked",
+}
+
+// writeCSV writes one row per issue and pull request of each workload.
+func writeCSV(w io.Writer, issues []*TrackingIssue) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, issue := range issues {
+		e := issue.Export()
+		for _, wl := range e.Workloads {
+			write := func(typ string, item ExportedItem) error {
+				var estimate string
+				if item.Estimate > 0 {
+					estimate = strconv.FormatFloat(item.Estimate, 'f', -1, 64)
+				}
+
+				return cw.Write([]string{
+					e.URL,
+					wl.Assignee,
+					typ,
+					strconv.Itoa(item.Number),
+					item.Title,
+					item.URL,
+					item.Repository,
+					item.State,
+					strings.Join(item.Labels, " "),
+					estimate,
+					strconv.FormatBool(item.Deprioritised),
+					strings.Join(item.Linked, " "),
+				})
+			}
+
+			for _, item := range wl.Issues {
+				if err := write("issue", item); err != nil {
+					return err
+				}
+			}
+
+			for _, item := range wl.PullRequests {
+				if err := write("pull-request", item); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteCSV(t *testing.T) {
+	ti := &TrackingIssue{
+		Issue: &Issue{Number: 10, URL: "https://github.com/sourcegraph/sourcegraph/issues/10", Milestone: "3.14"},
+		Issues: []*Issue{
+			{
+				Number:     1,
+				Title:      "a, with a comma",
+				URL:        "https://github.com/sourcegraph/sourcegraph/issues/1",
+				Repository: "sourcegraph/sourcegraph",
+				State:      "OPEN",
+				Milestone:  "3.14",
+				Assignees:  []string{"alice"},
+				Labels:     []string{"estimate/0.5d", "bug"},
+			},
+			{
+				Number:     2,
+				Title:      "secret",
+				URL:        "https://github.com/sourcegraph/customer/issues/2",
+				Repository: "sourcegraph/customer",
+				Private:    true,
+				State:      "CLOSED",
+				Assignees:  []string{"alice"},
+			},
+		},
+		PRs: []*PullRequest{
+			{
+				Number:     3,
+				Title:      "fix a",
+				URL:        "https://github.com/sourcegraph/sourcegraph/pull/3",
+				Body:       "Fixes #1",
+				Repository: "sourcegraph/sourcegraph",
+				State:      "MERGED",
+				Author:     "alice",
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := writeCSV(&buf, []*TrackingIssue{ti}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `tracking_issue,assignee,type,number,title,url,repository,state,labels,estimate,deprioritised,linked
+https://github.com/sourcegraph/sourcegraph/issues/10,alice,issue,1,"a, with a comma",https://github.com/sourcegraph/sourcegraph/issues/1,sourcegraph/sourcegraph,OPEN,estimate/0.5d bug,0.5,false,https://github.com/sourcegraph/sourcegraph/pull/3
+https://github.com/sourcegraph/sourcegraph/issues/10,alice,issue,2,sourcegraph/customer,https://github.com/sourcegraph/customer/issues/2,sourcegraph/customer,CLOSED,,,true,
+https://github.com/sourcegraph/sourcegraph/issues/10,alice,pull-request,3,fix a,https://github.com/sourcegraph/sourcegraph/pull/3,sourcegraph/sourcegraph,MERGED,,,false,https://github.com/sourcegraph/sourcegraph/issues/1
+`
+
+	if have := buf.String(); have != want {
+		t.Errorf("have:\n%s\nwant:\n%s", have, want)
+	}
+}
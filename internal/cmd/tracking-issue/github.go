@@ -0,0 +1,331 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/machinebox/graphql"
+	"golang.org/x/oauth2"
+)
+
+// Client runs GraphQL requests against the GitHub API. It retries requests
+// that fail because of rate limits and keeps track of the cost of queries.
+type Client struct {
+	cli *graphql.Client
+
+	// Concurrency, if positive, is the maximum number of requests that run at
+	// the same time. It may not be changed after the first request.
+	Concurrency int
+
+	// MaxCost, if positive, is the rate limit budget of a run. Once the
+	// queries ran so far cost that many points, further requests fail with
+	// ErrMaxCostExceeded.
+	MaxCost int
+
+	// MaxRetries is the number of times a rate limited request is retried.
+	MaxRetries int
+
+	// minBackoff is how long to wait before the first retry of a rate limited
+	// request, if the response doesn't say.
+	minBackoff time.Duration
+
+	semOnce sync.Once
+	sem     chan struct{}
+
+	mu   sync.Mutex
+	cost int
+}
+
+// ErrMaxCostExceeded is returned by (*Client).Run when the queries exceeded
+// the -max-cost budget.
+var ErrMaxCostExceeded = errors.New("GitHub API rate limit budget (-max-cost) exceeded")
+
+// newClient returns a client for the GitHub instance at githubURL, such as
+// https://github.com or the URL of a GitHub Enterprise instance. If cacheDir is
+// set, the responses to queries are cached there for cacheTTL.
+func newClient(ctx context.Context, token, githubURL, cacheDir string, cacheTTL time.Duration) (*Client, error) {
+	endpoint, err := graphqlEndpoint(githubURL)
+	if err != nil {
+		return nil, err
+	}
+
+	hc := oauth2.NewClient(ctx, oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: token},
+	))
+	hc.Transport = &rateLimitTransport{base: hc.Transport}
+
+	if cacheDir != "" {
+		hc.Transport = &cacheTransport{base: hc.Transport, dir: cacheDir, ttl: cacheTTL, salt: token}
+	}
+
+	return &Client{
+		cli:        graphql.NewClient(endpoint, graphql.WithHTTPClient(hc)),
+		MaxRetries: 5,
+		minBackoff: time.Second,
+	}, nil
+}
+
+// rateLimitGraphQLQuery selects the cost of a query. Queries include it so
+// that (*Client).Run can keep track of the budget.
+const rateLimitGraphQLQuery = "rateLimit { cost }\n"
+
+// Run runs the request and decodes the data of the response into resp,
+// retrying with exponential backoff if the request is rate limited.
+func (c *Client) Run(ctx context.Context, r *graphql.Request, resp interface{}) error {
+	backoff := c.minBackoff
+
+	for attempt := 0; ; attempt++ {
+		if c.MaxCost > 0 && c.Cost() >= c.MaxCost {
+			return ErrMaxCostExceeded
+		}
+
+		var data json.RawMessage
+		err := c.run(ctx, r, &data)
+
+		wait, limited := rateLimited(err)
+		if !limited {
+			if err != nil {
+				return err
+			}
+			return c.decode(data, resp)
+		}
+
+		if attempt >= c.MaxRetries {
+			return err
+		}
+
+		if wait <= 0 {
+			wait = backoff
+			backoff *= 2
+		}
+
+		log.Printf("GitHub API request rate limited (%v), retrying in %s.", err, wait.Round(time.Second))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// run runs the request once Concurrency allows it.
+func (c *Client) run(ctx context.Context, r *graphql.Request, data *json.RawMessage) error {
+	c.semOnce.Do(func() {
+		if c.Concurrency > 0 {
+			c.sem = make(chan struct{}, c.Concurrency)
+		}
+	})
+
+	if c.sem != nil {
+		select {
+		case c.sem <- struct{}{}:
+			defer func() { <-c.sem }()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return c.cli.Run(ctx, r, data)
+}
+
+// Cost returns the total cost of the queries run so far.
+func (c *Client) Cost() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cost
+}
+
+func (c *Client) decode(data json.RawMessage, resp interface{}) error {
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+
+	var rl struct {
+		RateLimit *struct{ Cost int }
+	}
+
+	if err := json.Unmarshal(data, &rl); err == nil && rl.RateLimit != nil {
+		c.mu.Lock()
+		c.cost += rl.RateLimit.Cost
+		c.mu.Unlock()
+	}
+
+	if resp == nil {
+		return nil
+	}
+
+	return json.Unmarshal(data, resp)
+}
+
+// rateLimited reports whether err is caused by a rate limit, and how long to
+// wait before retrying if that is known.
+func rateLimited(err error) (time.Duration, bool) {
+	if err == nil {
+		return 0, false
+	}
+
+	var e *rateLimitError
+	if errors.As(err, &e) {
+		return e.retryAfter, true
+	}
+
+	// Primary rate limit errors are reported as GraphQL errors of type
+	// RATE_LIMITED, but the client only exposes their message.
+	msg := strings.ToLower(err.Error())
+	return 0, strings.Contains(msg, "rate limit")
+}
+
+// graphqlEndpoint returns the GraphQL API endpoint of the GitHub instance at
+// githubURL. GitHub.com serves the API on a separate host, while GitHub
+// Enterprise serves it under /api.
+func graphqlEndpoint(githubURL string) (string, error) {
+	u, err := url.Parse(githubURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid GitHub URL %q: %v", githubURL, err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return "", fmt.Errorf("invalid GitHub URL %q: must be an absolute http(s) URL", githubURL)
+	}
+
+	switch strings.ToLower(u.Hostname()) {
+	case "github.com", "www.github.com", "api.github.com":
+		return "https://api.github.com/graphql", nil
+	}
+
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.Path = strings.TrimSuffix(u.Path, "/api/graphql")
+	u.Path = strings.TrimSuffix(u.Path, "/api")
+	u.Path += "/api/graphql"
+	u.RawQuery, u.Fragment = "", ""
+
+	return u.String(), nil
+}
+
+// rateLimitTransport keeps track of the rate limit reported in the response
+// headers and waits for it to reset before sending a request once it is
+// exhausted. GitHub Enterprise instances with rate limiting disabled don't
+// send the headers, in which case requests are never delayed.
+//
+// Responses that are rejected because of a (secondary) rate limit are turned
+// into a *rateLimitError, since their body isn't a GraphQL response.
+type rateLimitTransport struct {
+	base http.RoundTripper
+
+	mu        sync.Mutex
+	remaining int
+	reset     time.Time
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if wait := t.wait(time.Now()); wait > 0 {
+		log.Printf("GitHub API rate limit exhausted, waiting %s for it to reset.", wait.Round(time.Second))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	t.update(resp.Header)
+
+	if resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<16))
+		resp.Body.Close()
+
+		if err := t.rateLimitError(resp, body); err != nil {
+			return nil, err
+		}
+
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	return resp, nil
+}
+
+// rateLimitError returns a *rateLimitError if the rejected response was caused
+// by a rate limit, or nil otherwise.
+func (t *rateLimitTransport) rateLimitError(resp *http.Response, body []byte) error {
+	var retryAfter time.Duration
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		retryAfter = time.Duration(secs) * time.Second
+	}
+
+	if retryAfter == 0 && resp.Header.Get("X-RateLimit-Remaining") != "0" &&
+		!bytes.Contains(bytes.ToLower(body), []byte("rate limit")) {
+		return nil
+	}
+
+	if retryAfter == 0 {
+		retryAfter = t.wait(time.Now())
+	}
+
+	return &rateLimitError{status: resp.Status, retryAfter: retryAfter}
+}
+
+type rateLimitError struct {
+	status     string
+	retryAfter time.Duration
+}
+
+func (e *rateLimitError) Error() string {
+	return "rate limited: " + e.status
+}
+
+// wait returns how long to wait before sending the next request.
+func (t *rateLimitTransport) wait(now time.Time) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.reset.IsZero() || t.remaining > 0 || !now.Before(t.reset) {
+		return 0
+	}
+
+	return t.reset.Sub(now)
+}
+
+func (t *rateLimitTransport) update(h http.Header) {
+	remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+
+	reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.remaining = remaining
+	t.reset = time.Unix(reset, 0)
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/machinebox/graphql"
+)
+
+func TestGraphQLEndpoint(t *testing.T) {
+	for githubURL, want := range map[string]string{
+		"https://github.com":                  "https://api.github.com/graphql",
+		"https://api.github.com/":             "https://api.github.com/graphql",
+		"https://ghe.example.com":             "https://ghe.example.com/api/graphql",
+		"https://ghe.example.com/":            "https://ghe.example.com/api/graphql",
+		"https://ghe.example.com/api/graphql": "https://ghe.example.com/api/graphql",
+		"http://ghe.example.com:8080/api":     "http://ghe.example.com:8080/api/graphql",
+	} {
+		have, err := graphqlEndpoint(githubURL)
+		if err != nil {
+			t.Errorf("%s: %v", githubURL, err)
+		} else if have != want {
+			t.Errorf("%s: have %q, want %q", githubURL, have, want)
+		}
+	}
+
+	for _, githubURL := range []string{"", "ghe.example.com", "ftp://ghe.example.com"} {
+		if _, err := graphqlEndpoint(githubURL); err == nil {
+			t.Errorf("%q: expected error", githubURL)
+		}
+	}
+}
+
+func TestRateLimitTransportWait(t *testing.T) {
+	now := time.Unix(1000, 0)
+
+	var rt rateLimitTransport
+	if wait := rt.wait(now); wait != 0 {
+		t.Errorf("without headers: have wait %s, want 0", wait)
+	}
+
+	rt.update(http.Header{"X-Ratelimit-Remaining": {"10"}, "X-Ratelimit-Reset": {"1060"}})
+	if wait := rt.wait(now); wait != 0 {
+		t.Errorf("with remaining requests: have wait %s, want 0", wait)
+	}
+
+	rt.update(http.Header{"X-Ratelimit-Remaining": {"0"}, "X-Ratelimit-Reset": {"1060"}})
+	if wait := rt.wait(now); wait != time.Minute {
+		t.Errorf("exhausted: have wait %s, want %s", wait, time.Minute)
+	}
+
+	if wait := rt.wait(now.Add(2 * time.Minute)); wait != 0 {
+		t.Errorf("after reset: have wait %s, want 0", wait)
+	}
+}
+
+func TestClientRun(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch requests {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message": "You have exceeded a secondary rate limit."}`)
+		case 2:
+			fmt.Fprint(w, `{"errors": [{"type": "RATE_LIMITED", "message": "API rate limit exceeded"}]}`)
+		default:
+			fmt.Fprint(w, `{"data": {"rateLimit": {"cost": 3}, "viewer": {"login": "alice"}}}`)
+		}
+	}))
+	defer srv.Close()
+
+	cli := newTestClient(srv.URL)
+	cli.MaxCost = 5
+	cli.MaxRetries = 2
+
+	ctx := context.Background()
+
+	var data struct{ Viewer struct{ Login string } }
+	if err := cli.Run(ctx, graphql.NewRequest("query { viewer { login } }"), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := data.Viewer.Login, "alice"; have != want {
+		t.Errorf("login: have %q, want %q", have, want)
+	}
+
+	if have, want := requests, 3; have != want {
+		t.Errorf("requests: have %d, want %d", have, want)
+	}
+
+	if err := cli.Run(ctx, graphql.NewRequest("query { viewer { login } }"), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := cli.Cost(), 6; have != want {
+		t.Errorf("cost: have %d, want %d", have, want)
+	}
+
+	if err := cli.Run(ctx, graphql.NewRequest("query { viewer { login } }"), nil); err != ErrMaxCostExceeded {
+		t.Errorf("error: have %v, want %v", err, ErrMaxCostExceeded)
+	}
+}
+
+// newTestClient returns a client for the fake GitHub API at url.
+func newTestClient(url string) *Client {
+	return &Client{
+		cli:        graphql.NewClient(url, graphql.WithHTTPClient(&http.Client{Transport: &rateLimitTransport{}})),
+		minBackoff: time.Millisecond,
+	}
+}
@@ -0,0 +1,549 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// gitLabProvider loads tracking issues with the GitLab REST API. The org of a
+// definition is a GitLab group, its repos are projects, and pull requests are
+// merge requests.
+type gitLabProvider struct {
+	cli      *http.Client
+	endpoint string // Such as https://gitlab.com/api/v4
+	token    string
+
+	// sem bounds the number of concurrent requests, if not nil.
+	sem chan struct{}
+
+	// maxRetries is the number of times a rate limited request is retried.
+	maxRetries int
+
+	// visibility caches the visibility of projects by ID.
+	mu         sync.Mutex
+	visibility map[int]string
+}
+
+// newGitLabProvider returns a provider for the GitLab instance at gitlabURL,
+// such as https://gitlab.com.
+func newGitLabProvider(gitlabURL, token string, concurrency int) (*gitLabProvider, error) {
+	u, err := url.Parse(gitlabURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GitLab URL %q: %v", gitlabURL, err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid GitLab URL %q: must be an absolute http(s) URL", gitlabURL)
+	}
+
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.Path = strings.TrimSuffix(u.Path, "/api/v4")
+	u.Path += "/api/v4"
+	u.RawQuery, u.Fragment = "", ""
+
+	p := &gitLabProvider{
+		cli:        &http.Client{Timeout: time.Minute},
+		endpoint:   u.String(),
+		token:      token,
+		maxRetries: 5,
+		visibility: map[int]string{},
+	}
+
+	if concurrency > 0 {
+		p.sem = make(chan struct{}, concurrency)
+	}
+
+	return p, nil
+}
+
+// gitLabSupports returns an error if the definition uses features that only
+// exist on GitHub.
+func gitLabSupports(ic *IssueConfig) error {
+	switch {
+	case ic.Project != 0:
+		return fmt.Errorf("org %s: project is not supported with -provider=gitlab", ic.Org)
+	case ic.ProjectFields != nil:
+		return fmt.Errorf("org %s: projectFields is not supported with -provider=gitlab", ic.Org)
+	case ic.Timeline != nil:
+		return fmt.Errorf("org %s: timeline is not supported with -provider=gitlab", ic.Org)
+	}
+	return nil
+}
+
+func (p *gitLabProvider) Load(ctx context.Context, cfg *Config, include func(*TrackingIssue) bool) ([]*TrackingIssue, error) {
+	for _, ic := range cfg.Issues {
+		if err := gitLabSupports(ic); err != nil {
+			return nil, err
+		}
+	}
+
+	listed := make([][]*Issue, len(cfg.Issues))
+
+	g, gctx := errgroup.WithContext(ctx)
+	for i, ic := range cfg.Issues {
+		i, ic := i, ic
+		g.Go(func() (err error) {
+			listed[i], err = p.listTrackingIssues(gctx, ic)
+			return err
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	tracking := trackingIssues(cfg, listed, include)
+
+	g, gctx = errgroup.WithContext(ctx)
+	for _, t := range tracking {
+		t := t
+		g.Go(func() error {
+			return p.loadWork(gctx, t)
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return tracking, loadCarryOver(ctx, tracking, p.previousTrackingIssueBody)
+}
+
+func (p *gitLabProvider) listTrackingIssues(ctx context.Context, ic *IssueConfig) ([]*Issue, error) {
+	issues, _, err := p.list(ctx, "groups", ic.Org, "issues", gitLabSearch{
+		labels:    append([]string{"tracking"}, workLabels(ic.Labels)...),
+		milestone: ic.Milestone,
+		state:     "opened",
+	})
+	return issues, err
+}
+
+// previousTrackingIssueBody returns the body of the tracking issue with the same
+// labels as t in the previous milestone, or "" if there is none.
+func (p *gitLabProvider) previousTrackingIssueBody(ctx context.Context, t *TrackingIssue, previous string) (string, error) {
+	issues, _, err := p.list(ctx, "groups", t.Config.Org, "issues", gitLabSearch{
+		labels:    append([]string{"tracking"}, workLabels(t.Labels)...),
+		milestone: previous,
+	})
+	if err != nil {
+		return "", err
+	}
+	if previous := sameLabels(issues, t.Labels); previous != nil {
+		return previous.Body, nil
+	}
+	return "", nil
+}
+
+// loadWork lists the issues and merge requests tracked by t in its groups and
+// projects, like the searches of the GitHub provider.
+func (p *gitLabProvider) loadWork(ctx context.Context, t *TrackingIssue) error {
+	labels := workLabels(t.Labels)
+
+	// Without a milestone or labels, a tracking issue would track all work in
+	// the group.
+	if t.Milestone == "" && len(labels) == 0 {
+		log.Printf("skipping %q %s: no milestone or labels to find its work by", t.Title, t.URL)
+		return nil
+	}
+
+	searches := []gitLabSearch{{labels: labels, milestone: t.Milestone}}
+	if t.Milestone != "" {
+		searches = append(searches, gitLabSearch{
+			labels:       append(append([]string(nil), labels...), "planned/"+t.Milestone),
+			notMilestone: t.Milestone,
+		})
+	}
+
+	type source struct{ kind, id string }
+	sources := []source{{"groups", t.Config.Org}}
+	for _, org := range t.Config.Orgs {
+		sources = append(sources, source{"groups", org})
+	}
+	for _, repo := range t.Config.Repos {
+		sources = append(sources, source{"projects", repo})
+	}
+
+	// Groups include the work of their subgroups, so the same issue may be
+	// listed more than once.
+	seen := map[string]bool{}
+	for _, s := range sources {
+		for _, search := range searches {
+			for _, resource := range []string{"issues", "merge_requests"} {
+				issues, prs, err := p.list(ctx, s.kind, s.id, resource, search)
+				if err != nil {
+					return err
+				}
+
+				for _, issue := range issues {
+					if !seen["issue"+issue.ID] {
+						seen["issue"+issue.ID] = true
+						t.Issues = append(t.Issues, issue)
+					}
+				}
+
+				for _, pr := range prs {
+					if !seen["pr"+pr.ID] {
+						seen["pr"+pr.ID] = true
+						t.PRs = append(t.PRs, pr)
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *gitLabProvider) UpdateIssues(ctx context.Context, issues []*Issue) error {
+	for _, issue := range issues {
+		path := fmt.Sprintf("/projects/%s/issues/%d", url.PathEscape(issue.Repository), issue.Number)
+		body := struct {
+			Description string `json:"description"`
+		}{issue.Body}
+
+		if _, err := p.do(ctx, http.MethodPut, path, nil, body, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *gitLabProvider) Comment(ctx context.Context, issue *Issue, body string) error {
+	path := fmt.Sprintf("/projects/%s/issues/%d/notes", url.PathEscape(issue.Repository), issue.Number)
+	note := struct {
+		Body string `json:"body"`
+	}{body}
+
+	_, err := p.do(ctx, http.MethodPost, path, nil, note, nil)
+	return err
+}
+
+// Labels returns the labels of the groups and projects of the definition,
+// including those inherited from ancestor groups, sorted.
+func (p *gitLabProvider) Labels(ctx context.Context, ic *IssueConfig) ([]string, error) {
+	labels := map[string]bool{}
+
+	var paths []string
+	for _, group := range append([]string{ic.Org}, ic.Orgs...) {
+		paths = append(paths, "/groups/"+url.PathEscape(group)+"/labels")
+	}
+	for _, project := range ic.Repos {
+		paths = append(paths, "/projects/"+url.PathEscape(project)+"/labels")
+	}
+
+	for _, path := range paths {
+		params := url.Values{}
+		params.Set("include_ancestor_groups", "true")
+		params.Set("per_page", "100")
+
+		for page := "1"; page != ""; {
+			params.Set("page", page)
+
+			var data []struct{ Name string }
+			h, err := p.do(ctx, http.MethodGet, path, params, nil, &data)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, label := range data {
+				labels[label.Name] = true
+			}
+
+			page = h.Get("X-Next-Page")
+		}
+	}
+
+	return sortedKeys(labels), nil
+}
+
+// gitLabSearch are the parameters of a list of issues or merge requests.
+type gitLabSearch struct {
+	labels       []string // All of them
+	milestone    string
+	notMilestone string
+	state        string // opened, closed, or all if empty
+}
+
+func (s gitLabSearch) params() url.Values {
+	params := url.Values{}
+	if len(s.labels) > 0 {
+		params.Set("labels", strings.Join(s.labels, ","))
+	}
+	if s.milestone != "" {
+		params.Set("milestone", s.milestone)
+	}
+	if s.notMilestone != "" {
+		params.Set("not[milestone]", s.notMilestone)
+	}
+	if s.state != "" {
+		params.Set("state", s.state)
+	}
+	params.Set("scope", "all")
+	params.Set("per_page", "100")
+	return params
+}
+
+// list returns all pages of the issues or merge requests of a group or project
+// matching the search.
+func (p *gitLabProvider) list(ctx context.Context, kind, id, resource string, s gitLabSearch) (issues []*Issue, prs []*PullRequest, _ error) {
+	path := fmt.Sprintf("/%s/%s/%s", kind, url.PathEscape(id), resource)
+	params := s.params()
+
+	for page := "1"; page != ""; {
+		params.Set("page", page)
+
+		var data []gitLabIssue
+		h, err := p.do(ctx, http.MethodGet, path, params, nil, &data)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, n := range data {
+			private, err := p.private(ctx, n.ProjectID)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if resource == "merge_requests" {
+				pr := n.pullRequest()
+				pr.Private = private
+				prs = append(prs, pr)
+			} else {
+				issue := n.issue()
+				issue.Private = issue.Private || private
+				issues = append(issues, issue)
+			}
+		}
+
+		page = h.Get("X-Next-Page")
+	}
+
+	return issues, prs, nil
+}
+
+// private reports whether the project with the given ID is private or
+// internal, so that its issues and merge requests are redacted like those of
+// private GitHub repositories.
+func (p *gitLabProvider) private(ctx context.Context, projectID int) (bool, error) {
+	p.mu.Lock()
+	visibility, ok := p.visibility[projectID]
+	p.mu.Unlock()
+
+	if !ok {
+		var project struct{ Visibility string }
+		if _, err := p.do(ctx, http.MethodGet, "/projects/"+strconv.Itoa(projectID), nil, nil, &project); err != nil {
+			return false, err
+		}
+
+		visibility = project.Visibility
+		p.mu.Lock()
+		p.visibility[projectID] = visibility
+		p.mu.Unlock()
+	}
+
+	return visibility != "public", nil
+}
+
+// do sends a request to the GitLab API and decodes the JSON response into out,
+// retrying once the rate limit resets if the request is rate limited.
+func (p *gitLabProvider) do(ctx context.Context, method, path string, params url.Values, in, out interface{}) (http.Header, error) {
+	u := p.endpoint + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.sem != nil {
+		select {
+		case p.sem <- struct{}{}:
+			defer func() { <-p.sem }()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequest(method, u, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+
+		req = req.WithContext(ctx)
+		req.Header.Set("Private-Token", p.token)
+		if in != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := p.cli.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < p.maxRetries {
+			wait := time.Second << uint(attempt)
+			if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+				wait = time.Duration(secs) * time.Second
+			}
+
+			log.Printf("GitLab API request rate limited, retrying in %s.", wait.Round(time.Second))
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+				continue
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, ctx.Err()
+			}
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			if len(data) > 1024 {
+				data = data[:1024]
+			}
+			return nil, fmt.Errorf("GitLab API %s %s: %s: %s", method, path, resp.Status, bytes.TrimSpace(data))
+		}
+
+		if out != nil {
+			if err := json.NewDecoder(bytes.NewReader(data)).Decode(out); err != nil && err != io.EOF {
+				return nil, fmt.Errorf("GitLab API %s %s: decoding response: %v", method, path, err)
+			}
+		}
+
+		return resp.Header, nil
+	}
+}
+
+// gitLabIssue is an issue or merge request in a GitLab API response.
+type gitLabIssue struct {
+	ID           int
+	IID          int `json:"iid"`
+	ProjectID    int `json:"project_id"`
+	Title        string
+	Description  string
+	State        string
+	WebURL       string `json:"web_url"`
+	Confidential bool
+	Labels       []string
+	Assignees    []struct{ Username string }
+	Reviewers    []struct{ Username string }
+	Milestone    struct{ Title string }
+	Author       struct{ Username string }
+	References   struct{ Full string }
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	ClosedAt     time.Time `json:"closed_at"`
+	MergedAt     time.Time `json:"merged_at"`
+}
+
+// repository returns the path of the project of the issue, such as
+// sourcegraph/sourcegraph for the reference sourcegraph/sourcegraph#123.
+func (n *gitLabIssue) repository() string {
+	if i := strings.LastIndexAny(n.References.Full, "#!"); i > 0 {
+		return n.References.Full[:i]
+	}
+	return n.References.Full
+}
+
+// state returns the state of the issue, spelled like GitHub's.
+func (n *gitLabIssue) state() string {
+	switch n.State {
+	case "opened", "locked":
+		return "OPEN"
+	default:
+		return strings.ToUpper(n.State)
+	}
+}
+
+func usernames(users []struct{ Username string }) []string {
+	names := make([]string, 0, len(users))
+	for _, u := range users {
+		names = append(names, u.Username)
+	}
+	return names
+}
+
+func (n *gitLabIssue) issue() *Issue {
+	return &Issue{
+		ID:         strconv.Itoa(n.ID),
+		Title:      n.Title,
+		Body:       n.Description,
+		State:      n.state(),
+		Number:     n.IID,
+		URL:        n.WebURL,
+		Repository: n.repository(),
+		Private:    n.Confidential,
+		Labels:     append(make([]string, 0, len(n.Labels)), n.Labels...),
+		Assignees:  usernames(n.Assignees),
+		Milestone:  n.Milestone.Title,
+		Author:     n.Author.Username,
+		CreatedAt:  n.CreatedAt,
+		UpdatedAt:  n.UpdatedAt,
+		ClosedAt:   n.ClosedAt,
+	}
+}
+
+// pullRequest returns the merge request as a pull request. The time of its
+// first commit isn't listed, so its BeganAt is zero.
+func (n *gitLabIssue) pullRequest() *PullRequest {
+	pr := &PullRequest{
+		ID:         strconv.Itoa(n.ID),
+		Title:      n.Title,
+		Body:       n.Description,
+		State:      n.state(),
+		Number:     n.IID,
+		URL:        n.WebURL,
+		Repository: n.repository(),
+		Labels:     append(make([]string, 0, len(n.Labels)), n.Labels...),
+		Assignees:  usernames(n.Assignees),
+		Milestone:  n.Milestone.Title,
+		Author:     n.Author.Username,
+		CreatedAt:  n.CreatedAt,
+		UpdatedAt:  n.UpdatedAt,
+		ClosedAt:   n.ClosedAt,
+		Reviewers:  usernames(n.Reviewers),
+	}
+
+	// Merged merge requests have no closing time.
+	if pr.ClosedAt.IsZero() {
+		pr.ClosedAt = n.MergedAt
+	}
+
+	return pr
+}
+
+// workLabels returns the labels of a tracking issue that narrow down its work.
+func workLabels(labels []string) (work []string) {
+	for _, label := range labels {
+		if label != "" && label != "tracking" {
+			work = append(work, label)
+		}
+	}
+	return work
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGitLabProvider(t *testing.T) {
+	var updated []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Private-Token") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		q := r.URL.Query()
+		var nodes []map[string]interface{}
+
+		switch path := r.URL.EscapedPath(); {
+		case path == "/api/v4/projects/1":
+			_ = json.NewEncoder(w).Encode(map[string]string{"visibility": "public"})
+			return
+
+		case path == "/api/v4/projects/2":
+			_ = json.NewEncoder(w).Encode(map[string]string{"visibility": "internal"})
+			return
+
+		case r.Method == http.MethodPut:
+			body, _ := ioutil.ReadAll(r.Body)
+			updated = append(updated, path+" "+string(body))
+
+		case path == "/api/v4/groups/sourcegraph/issues" && q.Get("labels") == "tracking":
+			nodes = append(nodes, map[string]interface{}{
+				"id": 1, "iid": 10, "project_id": 1, "title": "Tracking issue", "state": "opened",
+				"description": "<!-- BEGIN WORK --><!-- END WORK -->",
+				"web_url":     "https://gitlab.com/sourcegraph/sourcegraph/-/issues/10",
+				"labels":      []string{"tracking"},
+				"milestone":   map[string]string{"title": "3.18"},
+				"references":  map[string]string{"full": "sourcegraph/sourcegraph#10"},
+			})
+
+		case path == "/api/v4/groups/sourcegraph/issues" && q.Get("milestone") == "3.18":
+			// The second page of the milestone's issues.
+			if q.Get("page") == "1" {
+				w.Header().Set("X-Next-Page", "2")
+				break
+			}
+
+			nodes = append(nodes, map[string]interface{}{
+				"id": 2, "iid": 11, "project_id": 1, "title": "Planned", "state": "closed",
+				"web_url":    "https://gitlab.com/sourcegraph/sourcegraph/-/issues/11",
+				"labels":     []string{"estimate/2d"},
+				"assignees":  []map[string]string{{"username": "alice"}},
+				"milestone":  map[string]string{"title": "3.18"},
+				"references": map[string]string{"full": "sourcegraph/sourcegraph#11"},
+				"closed_at":  "2020-06-10T00:00:00Z",
+			})
+
+		case path == "/api/v4/groups/sourcegraph/issues" && q.Get("not[milestone]") == "3.18":
+			nodes = append(nodes, map[string]interface{}{
+				"id": 3, "iid": 12, "project_id": 1, "title": "Moved", "state": "opened",
+				"web_url":      "https://gitlab.com/sourcegraph/sourcegraph/-/issues/12",
+				"confidential": true,
+				"labels":       []string{"planned/3.18", "estimate/1d"},
+				"assignees":    []map[string]string{{"username": "bob"}},
+				"references":   map[string]string{"full": "sourcegraph/sourcegraph#12"},
+			})
+
+		case path == "/api/v4/groups/sourcegraph/merge_requests" && q.Get("milestone") == "3.18":
+			nodes = append(nodes, map[string]interface{}{
+				"id": 4, "iid": 7, "project_id": 1, "title": "Fix it", "state": "merged",
+				"description": "Closes #11",
+				"web_url":     "https://gitlab.com/sourcegraph/sourcegraph/-/merge_requests/7",
+				"author":      map[string]string{"username": "alice"},
+				"reviewers":   []map[string]string{{"username": "bob"}},
+				"milestone":   map[string]string{"title": "3.18"},
+				"references":  map[string]string{"full": "sourcegraph/sourcegraph!7"},
+				"merged_at":   "2020-06-09T00:00:00Z",
+			}, map[string]interface{}{
+				"id": 5, "iid": 3, "project_id": 2, "title": "Rotate the keys", "state": "opened",
+				"web_url":    "https://gitlab.com/sourcegraph/security/-/merge_requests/3",
+				"author":     map[string]string{"username": "alice"},
+				"labels":     []string{"security"},
+				"milestone":  map[string]string{"title": "3.18"},
+				"references": map[string]string{"full": "sourcegraph/security!3"},
+			})
+		}
+
+		_ = json.NewEncoder(w).Encode(nodes)
+	}))
+	defer srv.Close()
+
+	p, err := newGitLabProvider(srv.URL, "secret", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &Config{Issues: []*IssueConfig{{Org: "sourcegraph"}}}
+	if err := cfg.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	tracking, err := p.Load(ctx, cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tracking) != 1 {
+		t.Fatalf("have %d tracking issues, want 1", len(tracking))
+	}
+
+	ti := tracking[0]
+	if have, want := ti.Repository, "sourcegraph/sourcegraph"; have != want {
+		t.Errorf("repository: have %q, want %q", have, want)
+	}
+
+	var titles []string
+	for _, issue := range ti.Issues {
+		titles = append(titles, issue.Title+" "+issue.State)
+	}
+	if want := []string{"Planned CLOSED", "Moved OPEN"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("issues: have %q, want %q", titles, want)
+	}
+
+	if !ti.Issues[1].Private {
+		t.Error("confidential issue isn't private")
+	}
+
+	if len(ti.PRs) != 2 {
+		t.Fatalf("have %d merge requests, want 2", len(ti.PRs))
+	}
+
+	if ti.PRs[0].Private || !ti.PRs[1].Private {
+		t.Error("only the merge request of the internal project should be private")
+	}
+
+	pr := ti.PRs[0]
+	if pr.State != "MERGED" || pr.ClosedAt.IsZero() || !reflect.DeepEqual(pr.Reviewers, []string{"bob"}) {
+		t.Errorf("merge request: %+v", pr)
+	}
+
+	md := ti.Workloads().Markdown()
+	for _, want := range []string{
+		"- [ ] sourcegraph/security [#3](https://gitlab.com/sourcegraph/security/-/merge_requests/3) :shipit:",
+		"@alice: __2.00d__",
+		"- [x] Planned [#11](https://gitlab.com/sourcegraph/sourcegraph/-/issues/11) __2d__",
+		"  - [x] Fix it [#7](https://gitlab.com/sourcegraph/sourcegraph/-/merge_requests/7) :shipit:",
+		"- [ ] ~sourcegraph/sourcegraph~ [#12](https://gitlab.com/sourcegraph/sourcegraph/-/issues/12) __1d__",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown doesn't contain %q:\n%s", want, md)
+		}
+	}
+
+	ti.Body = "updated"
+	if err := p.UpdateIssues(ctx, []*Issue{ti.Issue}); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{`/api/v4/projects/sourcegraph%2Fsourcegraph/issues/10 {"description":"updated"}`}; !reflect.DeepEqual(updated, want) {
+		t.Errorf("updates: have %q, want %q", updated, want)
+	}
+}
+
+func TestGitLabSupports(t *testing.T) {
+	for _, ic := range []*IssueConfig{
+		{Org: "sourcegraph", Project: 3},
+		{Org: "sourcegraph", ProjectFields: &ProjectFields{Estimate: "Estimate"}},
+		{Org: "sourcegraph", Timeline: &TimelineMetrics{}},
+	} {
+		if err := gitLabSupports(ic); err == nil {
+			t.Errorf("%+v: no error", ic)
+		}
+	}
+
+	if err := gitLabSupports(&IssueConfig{Org: "sourcegraph", Repos: []string{"sourcegraph/about"}}); err != nil {
+		t.Error(err)
+	}
+}
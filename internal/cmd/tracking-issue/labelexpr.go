@@ -0,0 +1,293 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// LabelExpr is a boolean expression over labels, such as
+//
+//	team/code-intelligence AND NOT icebox OR customer
+//
+// NOT binds tighter than AND, which binds tighter than OR. Parentheses group
+// subexpressions, and labels containing spaces or parentheses are quoted with
+// double quotes. The operators are case-sensitive, so labels such as "or" need
+// quoting only when written in upper case.
+type LabelExpr struct {
+	op       string // "AND", "OR", "NOT" or "" for a label
+	label    string
+	operands []*LabelExpr
+}
+
+// ParseLabelExpr parses a label expression.
+func ParseLabelExpr(s string) (*LabelExpr, error) {
+	tokens, err := tokenizeLabelExpr(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty label expression")
+	}
+
+	p := labelExprParser{tokens: tokens}
+	e, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in label expression %q", p.tokens[p.pos].text, s)
+	}
+
+	return e, nil
+}
+
+// Matches reports whether the labels satisfy the expression.
+func (e *LabelExpr) Matches(labels []string) bool {
+	switch e.op {
+	case "AND":
+		for _, o := range e.operands {
+			if !o.Matches(labels) {
+				return false
+			}
+		}
+		return true
+	case "OR":
+		for _, o := range e.operands {
+			if o.Matches(labels) {
+				return true
+			}
+		}
+		return false
+	case "NOT":
+		return !e.operands[0].Matches(labels)
+	default:
+		return has(e.label, labels)
+	}
+}
+
+// SearchQualifiers returns the GitHub search qualifiers that all matching
+// issues satisfy, such as ` label:"a" -label:"b"`. GitHub search can't express
+// arbitrary boolean expressions, so the results still have to be filtered with
+// Matches.
+func (e *LabelExpr) SearchQualifiers() string {
+	include, exclude := e.constraints()
+
+	var q strings.Builder
+	for _, label := range sortedKeys(include) {
+		fmt.Fprintf(&q, " label:%q", label)
+	}
+	for _, label := range sortedKeys(exclude) {
+		fmt.Fprintf(&q, " -label:%q", label)
+	}
+	return q.String()
+}
+
+// constraints returns the labels that every match has, and the labels that no
+// match has.
+func (e *LabelExpr) constraints() (include, exclude map[string]bool) {
+	switch e.op {
+	case "AND":
+		include, exclude = map[string]bool{}, map[string]bool{}
+		for _, o := range e.operands {
+			in, ex := o.constraints()
+			for label := range in {
+				include[label] = true
+			}
+			for label := range ex {
+				exclude[label] = true
+			}
+		}
+		return include, exclude
+	case "OR":
+		for i, o := range e.operands {
+			in, ex := o.constraints()
+			if i == 0 {
+				include, exclude = in, ex
+				continue
+			}
+			include, exclude = intersect(include, in), intersect(exclude, ex)
+		}
+		return include, exclude
+	case "NOT":
+		if o := e.operands[0]; o.op == "" {
+			return nil, map[string]bool{o.label: true}
+		}
+		return nil, nil
+	default:
+		return map[string]bool{e.label: true}, nil
+	}
+}
+
+func (e *LabelExpr) String() string {
+	switch e.op {
+	case "AND", "OR":
+		parts := make([]string, 0, len(e.operands))
+		for _, o := range e.operands {
+			parts = append(parts, o.String())
+		}
+		return "(" + strings.Join(parts, " "+e.op+" ") + ")"
+	case "NOT":
+		return "NOT " + e.operands[0].String()
+	default:
+		return fmt.Sprintf("%q", e.label)
+	}
+}
+
+func intersect(a, b map[string]bool) map[string]bool {
+	m := map[string]bool{}
+	for k := range a {
+		if b[k] {
+			m[k] = true
+		}
+	}
+	return m
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+type labelExprToken struct {
+	text   string
+	quoted bool
+}
+
+func (t labelExprToken) is(keyword string) bool {
+	return !t.quoted && t.text == keyword
+}
+
+func tokenizeLabelExpr(s string) (tokens []labelExprToken, _ error) {
+	rs := []rune(s)
+	for i := 0; i < len(rs); {
+		switch r := rs[i]; {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, labelExprToken{text: string(r)})
+			i++
+		case r == '"':
+			j := i + 1
+			for j < len(rs) && rs[j] != '"' {
+				j++
+			}
+			if j == len(rs) {
+				return nil, fmt.Errorf("unterminated quote in label expression %q", s)
+			}
+			tokens = append(tokens, labelExprToken{text: string(rs[i+1 : j]), quoted: true})
+			i = j + 1
+		default:
+			j := i
+			for j < len(rs) && !unicode.IsSpace(rs[j]) && rs[j] != '(' && rs[j] != ')' && rs[j] != '"' {
+				j++
+			}
+			tokens = append(tokens, labelExprToken{text: string(rs[i:j])})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+type labelExprParser struct {
+	tokens []labelExprToken
+	pos    int
+}
+
+func (p *labelExprParser) peek(keyword string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].is(keyword)
+}
+
+func (p *labelExprParser) or() (*LabelExpr, error) {
+	return p.binary("OR", p.and)
+}
+
+func (p *labelExprParser) and() (*LabelExpr, error) {
+	return p.binary("AND", p.not)
+}
+
+func (p *labelExprParser) binary(op string, operand func() (*LabelExpr, error)) (*LabelExpr, error) {
+	e, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.peek(op) {
+		return e, nil
+	}
+
+	operands := []*LabelExpr{e}
+	for p.peek(op) {
+		p.pos++
+		o, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, o)
+	}
+
+	return &LabelExpr{op: op, operands: operands}, nil
+}
+
+func (p *labelExprParser) not() (*LabelExpr, error) {
+	if p.peek("NOT") {
+		p.pos++
+		e, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return &LabelExpr{op: "NOT", operands: []*LabelExpr{e}}, nil
+	}
+	return p.primary()
+}
+
+func (p *labelExprParser) primary() (*LabelExpr, error) {
+	if p.pos == len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of label expression")
+	}
+
+	t := p.tokens[p.pos]
+	switch {
+	case t.is("("):
+		p.pos++
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peek(")") {
+			return nil, fmt.Errorf("missing ) in label expression")
+		}
+		p.pos++
+		return e, nil
+	case t.is(")"), t.is("AND"), t.is("OR"), t.is("NOT"):
+		return nil, fmt.Errorf("unexpected %q in label expression", t.text)
+	default:
+		p.pos++
+		return &LabelExpr{label: t.text}, nil
+	}
+}
+
+// Labels returns the labels in the expression, sorted.
+func (e *LabelExpr) Labels() []string {
+	labels := map[string]bool{}
+
+	var walk func(*LabelExpr)
+	walk = func(e *LabelExpr) {
+		if e.op == "" {
+			labels[e.label] = true
+		}
+		for _, o := range e.operands {
+			walk(o)
+		}
+	}
+
+	walk(e)
+	return sortedKeys(labels)
+}
@@ -0,0 +1,92 @@
+package main
+
+import "testing"
+
+func TestLabelExpr(t *testing.T) {
+	for _, tc := range []struct {
+		expr       string
+		parsed     string
+		qualifiers string
+		matches    [][]string
+		rejects    [][]string
+	}{
+		{
+			expr:       "team/code-intelligence AND NOT icebox OR customer",
+			parsed:     `(("team/code-intelligence" AND NOT "icebox") OR "customer")`,
+			qualifiers: "",
+			matches:    [][]string{{"team/code-intelligence"}, {"customer", "icebox"}},
+			rejects:    [][]string{{"team/code-intelligence", "icebox"}, {"bug"}, nil},
+		},
+		{
+			expr:       "team/search AND (bug OR debt) AND NOT icebox",
+			parsed:     `("team/search" AND ("bug" OR "debt") AND NOT "icebox")`,
+			qualifiers: ` label:"team/search" -label:"icebox"`,
+			matches:    [][]string{{"team/search", "debt"}},
+			rejects:    [][]string{{"team/search"}, {"team/search", "bug", "icebox"}},
+		},
+		{
+			expr:       `(a AND b) OR (a AND "c d")`,
+			parsed:     `(("a" AND "b") OR ("a" AND "c d"))`,
+			qualifiers: ` label:"a"`,
+			matches:    [][]string{{"a", "c d"}},
+			rejects:    [][]string{{"a"}, {"c d"}},
+		},
+		{
+			expr:       `NOT (a OR b)`,
+			parsed:     `NOT ("a" OR "b")`,
+			qualifiers: "",
+			matches:    [][]string{{"c"}},
+			rejects:    [][]string{{"b"}},
+		},
+		{
+			expr:       `"AND"`,
+			parsed:     `"AND"`,
+			qualifiers: ` label:"AND"`,
+			matches:    [][]string{{"AND"}},
+		},
+	} {
+		t.Run(tc.expr, func(t *testing.T) {
+			e, err := ParseLabelExpr(tc.expr)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if have := e.String(); have != tc.parsed {
+				t.Errorf("parsed: have %s, want %s", have, tc.parsed)
+			}
+
+			if have := e.SearchQualifiers(); have != tc.qualifiers {
+				t.Errorf("qualifiers: have %q, want %q", have, tc.qualifiers)
+			}
+
+			for _, labels := range tc.matches {
+				if !e.Matches(labels) {
+					t.Errorf("expected %q to match", labels)
+				}
+			}
+
+			for _, labels := range tc.rejects {
+				if e.Matches(labels) {
+					t.Errorf("expected %q not to match", labels)
+				}
+			}
+		})
+	}
+}
+
+func TestParseLabelExprErrors(t *testing.T) {
+	for expr, want := range map[string]string{
+		"":          "empty label expression",
+		"a AND":     "unexpected end of label expression",
+		"(a OR b":   "missing ) in label expression",
+		"a b":       `unexpected "b" in label expression "a b"`,
+		`"a`:        `unterminated quote in label expression "\"a"`,
+		"OR a":      `unexpected "OR" in label expression`,
+		"a AND ) b": `unexpected ")" in label expression`,
+	} {
+		_, err := ParseLabelExpr(expr)
+		if err == nil || err.Error() != want {
+			t.Errorf("%q: have error %v, want %q", expr, err, want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// trackedLabels returns the labels the definition depends on, sorted. If one
+// of them doesn't exist, such as because of a typo, the tracking issues or
+// their sections silently stay empty.
+func (ic *IssueConfig) trackedLabels() []string {
+	labels := map[string]bool{"tracking": true}
+	for _, label := range ic.Labels {
+		labels[label] = true
+	}
+	for _, label := range ic.LabelAllowlist {
+		labels[label] = true
+	}
+	for label := range ic.Categories {
+		labels[label] = true
+	}
+	if ic.labelExpr != nil {
+		for _, label := range ic.labelExpr.Labels() {
+			labels[label] = true
+		}
+	}
+	delete(labels, "")
+	return sortedKeys(labels)
+}
+
+// LabelProblem is a tracked label that doesn't exist.
+type LabelProblem struct {
+	Label      string
+	Suggestion string // The closest existing label, or "" if none is close
+}
+
+func (lp LabelProblem) String() string {
+	if lp.Suggestion == "" {
+		return fmt.Sprintf("label %q doesn't exist", lp.Label)
+	}
+	return fmt.Sprintf("label %q doesn't exist, did you mean %q?", lp.Label, lp.Suggestion)
+}
+
+// checkLabels returns the tracked labels that aren't among the existing ones.
+// Labels only match exactly, so labels that differ in case are suggested too.
+func checkLabels(tracked, existing []string) (problems []LabelProblem) {
+	exists := make(map[string]bool, len(existing))
+	for _, label := range existing {
+		exists[label] = true
+	}
+
+	for _, label := range tracked {
+		if !exists[label] {
+			problems = append(problems, LabelProblem{Label: label, Suggestion: closestLabel(label, existing)})
+		}
+	}
+
+	return problems
+}
+
+// closestLabel returns the existing label with the smallest edit distance to
+// label, ignoring case, if it is close enough to be a typo.
+func closestLabel(label string, existing []string) (closest string) {
+	max := len(label) / 3
+	if max < 2 {
+		max = 2
+	}
+
+	best := max + 1
+	for _, candidate := range existing {
+		d := editDistance(strings.ToLower(label), strings.ToLower(candidate))
+		if d < best || d == best && candidate < closest {
+			best, closest = d, candidate
+		}
+	}
+
+	return closest
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+
+	prev := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		cur := make([]int, len(br)+1)
+		cur[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+
+	return prev[len(br)]
+}
+
+func minInt(v int, vs ...int) int {
+	for _, w := range vs {
+		if w < v {
+			v = w
+		}
+	}
+	return v
+}
+
+// runLabelCheck implements -check-labels. It warns about the tracked labels of
+// each definition that don't exist in its orgs and repositories, and fails if
+// there are any.
+func runLabelCheck(cfg *Config, opts options) error {
+	ctx := context.Background()
+	p, err := newProvider(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	var count int
+	for i, ic := range cfg.Issues {
+		existing, err := p.Labels(ctx, ic)
+		if err != nil {
+			return fmt.Errorf("issues[%d]: listing labels: %v", i, err)
+		}
+
+		for _, problem := range checkLabels(ic.trackedLabels(), existing) {
+			log.Printf("issues[%d] (org %s): %s", i, ic.Org, problem)
+			count++
+		}
+	}
+
+	if count > 0 {
+		return fmt.Errorf("%d tracked labels don't exist", count)
+	}
+
+	log.Printf("All tracked labels exist.")
+	return nil
+}
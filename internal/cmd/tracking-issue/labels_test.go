@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestCheckLabels(t *testing.T) {
+	ic := &IssueConfig{
+		Org:             "sourcegraph",
+		Labels:          []string{"team/serach"},
+		LabelAllowlist:  []string{"Customer"},
+		LabelExpression: "NOT icebox AND (bug OR roadmap)",
+		Categories:      map[string]string{"debt": "planned"},
+	}
+
+	if err := ic.init(); err != nil {
+		t.Fatal(err)
+	}
+
+	tracked := ic.trackedLabels()
+	if want := []string{"Customer", "bug", "debt", "icebox", "roadmap", "team/serach", "tracking"}; !reflect.DeepEqual(tracked, want) {
+		t.Errorf("tracked labels: have %q, want %q", tracked, want)
+	}
+
+	existing := []string{"bug", "customer", "icebox", "roadmap", "team/search", "team/security", "tracking"}
+
+	have := checkLabels(tracked, existing)
+	want := []LabelProblem{
+		{Label: "Customer", Suggestion: "customer"},
+		{Label: "debt"},
+		{Label: "team/serach", Suggestion: "team/search"},
+	}
+
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("problems: have %+v, want %+v", have, want)
+	}
+
+	if have, want := have[2].String(), `label "team/serach" doesn't exist, did you mean "team/search"?`; have != want {
+		t.Errorf("problem: have %q, want %q", have, want)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"bug", "", 3},
+		{"team/serach", "team/search", 2},
+		{"kitten", "sitting", 3},
+		{"🐛", "🐞", 1},
+	} {
+		if have := editDistance(tc.a, tc.b); have != tc.want {
+			t.Errorf("editDistance(%q, %q): have %d, want %d", tc.a, tc.b, have, tc.want)
+		}
+	}
+}
+
+func TestGitHubLabels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ Variables map[string]interface{} }
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		repo := func(more bool, labels ...string) map[string]interface{} {
+			nodes := make([]map[string]string, 0, len(labels))
+			for _, label := range labels {
+				nodes = append(nodes, map[string]string{"name": label})
+			}
+			return map[string]interface{}{"name": "sourcegraph", "labels": map[string]interface{}{
+				"pageInfo": map[string]interface{}{"endCursor": "more", "hasNextPage": more},
+				"nodes":    nodes,
+			}}
+		}
+
+		var data map[string]interface{}
+		if owner, ok := req.Variables["owner"]; ok {
+			// The sourcegraph repository has a second page of labels.
+			switch {
+			case owner == "other":
+				data = map[string]interface{}{"repository": repo(false, "deploy")}
+			case req.Variables["labelsCursor"] == "more":
+				data = map[string]interface{}{"repository": repo(false, "team/web")}
+			}
+		} else {
+			// The second page of repositories has no new labels.
+			_, second := req.Variables["cursor"]
+			nodes := []interface{}{repo(true, "bug", "tracking"), repo(false, "team/search")}
+			if second {
+				nodes = []interface{}{repo(false, "bug")}
+			}
+
+			data = map[string]interface{}{"organization": map[string]interface{}{
+				"repositories": map[string]interface{}{
+					"pageInfo": map[string]interface{}{"endCursor": "next", "hasNextPage": !second},
+					"nodes":    nodes,
+				},
+			}}
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	defer srv.Close()
+
+	p := &gitHubProvider{newTestClient(srv.URL)}
+	labels, err := p.Labels(context.Background(), &IssueConfig{Org: "sourcegraph", Repos: []string{"other/infrastructure"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"bug", "deploy", "team/search", "team/web", "tracking"}; !reflect.DeepEqual(labels, want) {
+		t.Errorf("labels: have %q, want %q", labels, want)
+	}
+}
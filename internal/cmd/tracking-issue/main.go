@@ -1,4 +1,5 @@
-// Command tracking-issue uses the GitHub API to maintain open tracking issues.
+// Command tracking-issue uses the GitHub or GitLab API to maintain open tracking
+// issues.
 
 package main
 
@@ -10,91 +11,364 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/machinebox/graphql"
-	"golang.org/x/oauth2"
+	"golang.org/x/sync/errgroup"
 )
 
 func main() {
-	token := flag.String("token", os.Getenv("GITHUB_TOKEN"), "GitHub personal access token")
+	if len(os.Args) > 1 && os.Args[1] == "burndown" {
+		if err := burndown(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	serve := len(os.Args) > 1 && os.Args[1] == "serve"
+	args := os.Args[1:]
+	if serve {
+		args = args[1:]
+	}
+
+	var opts options
+
+	flag.StringVar(&opts.Token, "token", "", "GitHub personal access token, or GitLab access token with -provider=gitlab (env var GITHUB_TOKEN, or GITLAB_TOKEN with -provider=gitlab)")
+	flag.StringVar(&opts.Provider, "provider", "github", "Code host to maintain tracking issues on: github or gitlab")
+	flag.StringVar(&opts.GitHubURL, "github-url", envOr("GITHUB_URL", "https://github.com"), "URL of the GitHub instance, such as a GitHub Enterprise instance (env var GITHUB_URL)")
+	flag.StringVar(&opts.GitLabURL, "gitlab-url", envOr("GITLAB_URL", "https://gitlab.com"), "URL of the GitLab instance, with -provider=gitlab (env var GITLAB_URL)")
+	config := flag.String("config", "", "Path to a YAML configuration file (such as tracking-issue.yaml) defining the tracking issues to update. Can't be combined with the flags that define a tracking issue, such as -org.")
 	org := flag.String("org", "sourcegraph", "GitHub organization to list issues from")
-	dry := flag.Bool("dry", false, "If true, do not update GitHub tracking issues in-place, but print them to stdout")
-	verbose := flag.Bool("verbose", false, "If true, print the resulting tracking issue bodies to stdout")
+	milestone := flag.String("milestone", "", "If set, only update the tracking issues of this milestone")
+	labelExpression := flag.String("label-expression", "", "If set, only track the issues and pull requests whose labels satisfy this boolean expression, such as 'team/search AND NOT icebox OR customer'")
+	format := flag.String("format", "markdown", "Output format: markdown updates the tracking issues, json and csv write their workloads to stdout")
+	sortAssignees := flag.String("sort-assignees", "name", "Order of the assignee sections: name, estimate or remaining")
+	sortIssues := flag.String("sort-issues", "search", "Order of the issues of each assignee: search, estimate, age, state or number")
+	flag.IntVar(&opts.Concurrency, "concurrency", 4, "Maximum number of concurrent GitHub API requests")
+	flag.IntVar(&opts.MaxCost, "max-cost", 0, "If positive, stop with an error once the GraphQL queries of the run cost this many rate limit points")
+	flag.StringVar(&opts.SnapshotDir, "snapshot-dir", "", "If set, append a snapshot of the estimates of each tracking issue to a file in this directory, for use with the burndown subcommand")
+	flag.StringVar(&opts.CacheDir, "cache-dir", "", "If set, cache the responses to GitHub API queries in this directory, for iterating on the output locally without spending rate limit points. Requires -dry, -diff or a format other than markdown, and can't be combined with serve or -nudge")
+	flag.DurationVar(&opts.CacheTTL, "cache-ttl", time.Hour, "How long responses cached with -cache-dir are used, or forever if 0")
+	flag.BoolVar(&opts.Dry, "dry", false, "If true, do not update GitHub tracking issues in-place, but print them to stdout")
+	flag.BoolVar(&opts.Diff, "diff", false, "If true, do not update anything, but print a diff of the work sections of the out of date tracking issues and exit with a non-zero status if there are any")
+	labelCheck := flag.Bool("check-labels", false, "If true, do not update anything, but check that the labels the definitions track exist, suggest the closest existing labels for those that don't, and exit with a non-zero status if there are any")
+	flag.BoolVar(&opts.Nudge, "nudge", false, "If true, post a reminder comment to the stale issues of the definitions with staleDays set")
+	flag.BoolVar(&opts.Verbose, "verbose", false, "If true, print the resulting tracking issue bodies to stdout")
+	flag.StringVar(&opts.SlackWebhook, "slack-webhook", os.Getenv("SLACK_WEBHOOK"), "If set, post a summary of the updated tracking issues to this Slack incoming webhook URL (env var SLACK_WEBHOOK)")
+
+	addr := flag.String("addr", ":8080", "serve: Address to listen on for GitHub webhook events")
+	webhookSecret := flag.String("webhook-secret", os.Getenv("WEBHOOK_SECRET"), "serve: Secret of the GitHub webhook, used to verify the event signatures. Required with serve (env var WEBHOOK_SECRET)")
+	debounce := flag.Duration("debounce", 5*time.Second, "serve: How long to wait for more events before updating the affected tracking issues")
+
+	_ = flag.CommandLine.Parse(args)
+
+	if opts.Token == "" {
+		opts.Token = os.Getenv(tokenEnv(opts.Provider))
+	}
+
+	cfg, err := loadConfig(*config, &IssueConfig{
+		Org:             *org,
+		Milestone:       *milestone,
+		LabelExpression: *labelExpression,
+		Format:          *format,
+		Sort:            Sort{Assignees: *sortAssignees, Issues: *sortIssues},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	flag.Parse()
+	if err := checkCacheDir(cfg, opts, serve); err != nil {
+		log.Fatal(err)
+	}
 
-	if err := run(*token, *org, *dry, *verbose); err != nil {
+	switch {
+	case serve:
+		err = runServer(cfg, opts, *addr, *webhookSecret, *debounce)
+	case *labelCheck:
+		err = runLabelCheck(cfg, opts)
+	default:
+		err = run(cfg, opts)
+	}
+
+	if err != nil {
 		log.Fatal(err)
 	}
 }
 
-func run(token, org string, dry, verbose bool) (err error) {
-	if token == "" {
-		return fmt.Errorf("no -token given")
+// options are the command-line options of a run that aren't part of the
+// configuration file.
+type options struct {
+	Token        string
+	Provider     string
+	GitHubURL    string
+	GitLabURL    string
+	Concurrency  int
+	MaxCost      int
+	SnapshotDir  string
+	SlackWebhook string
+	CacheDir     string
+	CacheTTL     time.Duration
+	Dry          bool
+	Diff         bool
+	Nudge        bool
+	Verbose      bool
+}
+
+// loadConfig reads the configuration file at path, or returns a configuration
+// with the single definition built from the command-line flags if path is
+// empty.
+func loadConfig(path string, fromFlags *IssueConfig) (*Config, error) {
+	if path == "" {
+		cfg := &Config{Issues: []*IssueConfig{fromFlags}}
+		return cfg, cfg.init()
 	}
 
-	if org == "" {
-		return fmt.Errorf("no -org given")
+	var conflicting []string
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "org", "milestone", "label-expression", "format", "sort-assignees", "sort-issues":
+			conflicting = append(conflicting, "-"+f.Name)
+		}
+	})
+
+	if len(conflicting) > 0 {
+		return nil, fmt.Errorf("%s can't be combined with -config", strings.Join(conflicting, ", "))
 	}
 
+	return ReadConfig(path)
+}
+
+func envOr(name, fallback string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func run(cfg *Config, opts options) (err error) {
 	ctx := context.Background()
-	cli := graphql.NewClient("https://api.github.com/graphql", graphql.WithHTTPClient(
-		oauth2.NewClient(ctx, oauth2.StaticTokenSource(
-			&oauth2.Token{AccessToken: token},
-		))),
-	)
+	p, err := newProvider(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	if gh, ok := p.(*gitHubProvider); ok {
+		defer func() {
+			log.Printf("GraphQL queries cost %d rate limit points.", gh.Cost())
+		}()
+	}
 
-	issues, err := listTrackingIssues(ctx, cli, org)
+	tracking, err := p.Load(ctx, cfg, nil)
 	if err != nil {
 		return err
 	}
 
-	if len(issues) == 0 {
+	if len(tracking) == 0 {
 		log.Printf("No tracking issues found. Exiting.")
 		return nil
 	}
 
-	tracking := make([]*TrackingIssue, 0, len(issues))
-	for _, issue := range issues {
-		tracking = append(tracking, &TrackingIssue{Issue: issue})
+	return updateTracking(ctx, p, tracking, opts)
+}
+
+func newRunClient(ctx context.Context, opts options) (*Client, error) {
+	if opts.Token == "" {
+		return nil, fmt.Errorf("no -token given")
 	}
 
-	err = loadTrackingIssues(ctx, cli, org, tracking)
+	cli, err := newClient(ctx, opts.Token, opts.GitHubURL, opts.CacheDir, opts.CacheTTL)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	var toUpdate []*Issue
+	cli.Concurrency = opts.Concurrency
+	cli.MaxCost = opts.MaxCost
+	return cli, nil
+}
+
+// updateTracking renders the work of the tracking issues and writes it back to
+// the code host or to stdout, depending on the format.
+func updateTracking(ctx context.Context, p Provider, tracking []*TrackingIssue, opts options) error {
+	var (
+		toUpdate  []*Issue
+		exports   = map[string][]*TrackingIssue{}
+		summaries []string
+		outOfDate int
+	)
+
+	now := time.Now()
+
 	for _, issue := range tracking {
-		if updated, err := issue.UpdateWork(issue.Workloads().Markdown()); err != nil {
+		issue.Filter(issue.Config.Matches)
+		issue.MarkStale(now)
+
+		if format := issue.Config.Format; format != "markdown" {
+			exports[format] = append(exports[format], issue)
+			continue
+		}
+
+		markers := issue.Config.Markers
+		previous, hadWork := between(issue.Body, markers.Begin, markers.End)
+
+		work, err := issue.RenderWork()
+		if err != nil {
+			return fmt.Errorf("%q %s: %v", issue.Title, issue.URL, err)
+		}
+
+		if opts.Diff {
+			if !hadWork {
+				log.Printf("failed to diff work section in %q %s: markers not found", issue.Title, issue.URL)
+				outOfDate++
+			} else if d := UnifiedDiff(issue.URL+" (current)", issue.URL+" (regenerated)", previous, work); d != "" {
+				fmt.Print(d)
+				outOfDate++
+			}
+			continue
+		}
+
+		if updated, err := issue.UpdateWork(work); err != nil {
 			log.Printf("failed to patch work section in %q %s: %v", issue.Title, issue.URL, err)
 		} else if !updated {
 			log.Printf("%q %s not modified.", issue.Title, issue.URL)
-		} else if !dry {
+		} else if !opts.Dry {
 			log.Printf("%q %s modified", issue.Title, issue.URL)
 			toUpdate = append(toUpdate, issue.Issue)
+
+			if opts.SlackWebhook != "" {
+				// Without a previous work section, all issues would be new.
+				var added []*Issue
+				if hadWork {
+					added = addedIssues(issue, previous)
+				}
+				summaries = append(summaries, SlackSummary(issue, added))
+			}
 		} else {
 			log.Printf("%q %s modified, but not updated due to -dry=true.", issue.Title, issue.URL)
 		}
 
-		if verbose {
+		if opts.Verbose {
 			log.Printf("%q %s body\n%s\n\n", issue.Title, issue.URL, issue.Body)
 		}
 	}
 
+	// Diffs don't write anything, so that they can check in CI whether the
+	// tracking issues are up to date.
+	if opts.Diff {
+		if outOfDate > 0 {
+			return fmt.Errorf("%d tracking issues are out of date", outOfDate)
+		}
+		return nil
+	}
+
+	if opts.SnapshotDir != "" {
+		if err := writeSnapshots(opts.SnapshotDir, now, tracking); err != nil {
+			return err
+		}
+	}
+
+	if len(exports["json"]) > 0 {
+		if err := writeJSON(os.Stdout, exports["json"]); err != nil {
+			return err
+		}
+	}
+
+	if len(exports["csv"]) > 0 {
+		if err := writeCSV(os.Stdout, exports["csv"]); err != nil {
+			return err
+		}
+	}
+
 	if len(toUpdate) > 0 {
-		return updateIssues(ctx, cli, toUpdate)
+		if err := p.UpdateIssues(ctx, toUpdate); err != nil {
+			return err
+		}
+	}
+
+	if opts.Nudge && !opts.Dry {
+		if err := nudge(ctx, p, tracking, now); err != nil {
+			return err
+		}
+	}
+
+	if len(summaries) > 0 {
+		return postSlack(ctx, opts.SlackWebhook, strings.Join(summaries, "\n\n"))
 	}
 
 	return nil
 }
 
-func updateIssues(ctx context.Context, cli *graphql.Client, issues []*Issue) (err error) {
+// loadAll lists the tracking issues of each definition in cfg and loads their
+// work. If include is not nil, only the tracking issues it returns true for are
+// loaded. Definitions are listed and orgs are loaded concurrently, bounded by
+// the concurrency of the client.
+func loadAll(ctx context.Context, cli *Client, cfg *Config, include func(*TrackingIssue) bool) ([]*TrackingIssue, error) {
+	listed := make([][]*Issue, len(cfg.Issues))
+
+	g, gctx := errgroup.WithContext(ctx)
+	for i, ic := range cfg.Issues {
+		i, ic := i, ic
+		g.Go(func() (err error) {
+			listed[i], err = listTrackingIssues(gctx, cli, ic)
+			return err
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	tracking := trackingIssues(cfg, listed, include)
+
+	// Load the work of all tracking issues of an org at once, so that
+	// searches are shared between them.
+	byOrg := map[string][]*TrackingIssue{}
+	for _, issue := range tracking {
+		byOrg[issue.Config.Org] = append(byOrg[issue.Config.Org], issue)
+	}
+
+	g, gctx = errgroup.WithContext(ctx)
+	for org, issues := range byOrg {
+		org, issues := org, issues
+		g.Go(func() error {
+			return loadTrackingIssues(gctx, cli, org, issues)
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return tracking, loadCarryOver(ctx, tracking, func(ctx context.Context, t *TrackingIssue, previous string) (string, error) {
+		return previousTrackingIssueBody(ctx, cli, t, previous)
+	})
+}
+
+// trackingIssues returns the tracking issues listed for each definition in cfg,
+// skipping those include returns false for, if it is not nil.
+func trackingIssues(cfg *Config, listed [][]*Issue, include func(*TrackingIssue) bool) (tracking []*TrackingIssue) {
+	seen := map[string]bool{}
+	for i, issues := range listed {
+		for _, issue := range issues {
+			// A tracking issue matched by several definitions is updated
+			// according to the first one.
+			if seen[issue.ID] {
+				continue
+			}
+			seen[issue.ID] = true
+
+			t := &TrackingIssue{Issue: issue, Config: cfg.Issues[i]}
+			if include == nil || include(t) {
+				tracking = append(tracking, t)
+			}
+		}
+	}
+	return tracking
+}
+
+func updateIssues(ctx context.Context, cli *Client, issues []*Issue) (err error) {
 	var q bytes.Buffer
 	q.WriteString("mutation(")
 
@@ -134,91 +408,94 @@ func patch(s, replacement, opening, closing string) (string, error) {
 		return s, errors.New("could not find opening marker in issue body")
 	}
 
-	end := strings.Index(s, closing)
+	// Only look for the closing marker after the opening one, so that manual
+	// text that precedes the work section is never overwritten.
+	end := strings.Index(s[start+len(opening):], closing)
 	if end == -1 {
 		return s, errors.New("could not find closing marker in issue body")
 	}
+	end += start + len(opening)
 
 	return s[:start+len(opening)] + replacement + s[end:], nil
 }
 
-type Workloads map[string]*Workload
-
-func (ws Workloads) Markdown() string {
-	assignees := make([]string, 0, len(ws))
-	for assignee := range ws {
-		assignees = append(assignees, assignee)
-	}
-
-	sort.Strings(assignees)
-
-	var b strings.Builder
-	for _, assignee := range assignees {
-		b.WriteString(ws[assignee].Markdown())
-	}
+// Workloads are the workloads of the assignees of a tracking issue, along with
+// the definition of the tracking issue, whose settings apply to all of them.
+type Workloads struct {
+	ByAssignee map[string]*Workload
 
-	return b.String()
+	config *IssueConfig // nil if the tracking issue has no definition
 }
 
 type Workload struct {
 	Assignee     string
 	Days         float64
+	CarriedOver  float64            // Estimate of the carried over issues, included in Days
+	Categories   map[string]float64 // Days by category, if categories are configured
+	ReviewDays   float64            // Review cost of the open pull requests in Reviews
+	Capacity     float64            // Days available in the milestone, if HasCapacity
+	HasCapacity  bool               // Whether the capacity of the assignee is configured
+	Rotations    []*Rotation        // Of the assignee in the milestone
+	RotationDays float64            // Taken from the capacity by the rotations
+	Unit         string             // Of the estimates and capacity, such as d
 	Issues       []*Issue
 	PullRequests []*PullRequest
+	Reviews      []*PullRequest // Open pull requests the assignee is requested to review
 }
 
-func (wl *Workload) Markdown() string {
-	var b strings.Builder
-
-	var days string
-	if wl.Days > 0 {
-		days = fmt.Sprintf(": __%.2fd__", wl.Days)
-	}
-
-	fmt.Fprintf(&b, "\n@%s%s\n\n", wl.Assignee, days)
+// Load returns the estimated days of work including reviews.
+func (wl *Workload) Load() float64 {
+	return wl.Days + wl.ReviewDays
+}
 
+// Remaining returns the summed estimates of the open issues planned for the
+// milestone.
+func (wl *Workload) Remaining() (days float64) {
 	for _, issue := range wl.Issues {
-		b.WriteString(issue.Markdown())
-
-		for _, pr := range issue.LinkedPRs {
-			b.WriteString("  ") // Nested list
-			b.WriteString(pr.Markdown())
+		if !issue.Deprioritised && !issue.Closed() {
+			days += issue.estimateValue()
 		}
 	}
+	return days
+}
 
-	// Put all PRs that aren't linked to issues top-level
-	for _, pr := range wl.PullRequests {
-		if len(pr.LinkedIssues) == 0 {
-			b.WriteString(pr.Markdown())
-		}
+// Available returns the capacity of the assignee that isn't taken by
+// rotations, or 0 if the capacity is unknown.
+func (wl *Workload) Available() float64 {
+	if !wl.HasCapacity {
+		return 0
+	}
+	if available := wl.Capacity - wl.RotationDays; available > 0 {
+		return available
 	}
+	return 0
+}
 
-	return b.String()
+// Overcommitted reports whether the estimated work exceeds the available
+// capacity of the assignee.
+func (wl *Workload) Overcommitted() bool {
+	return wl.HasCapacity && wl.Load() > wl.Available()
 }
 
+// Days returns the days of an estimate in the form of an estimate/ label.
 func Days(estimate string) float64 {
-	d, _ := strconv.ParseFloat(strings.TrimSuffix(estimate, "d"), 64)
-	return d
+	return defaultEstimates.Value(estimate)
 }
 
+// Estimate returns the value of the first estimate/ label, such as 2d.
 func Estimate(labels []string) string {
-	const prefix = "estimate/"
-	for _, label := range labels {
-		if strings.HasPrefix(label, prefix) {
-			return label[len(prefix):]
-		}
-	}
-	return ""
+	return defaultEstimates.Estimate(labels)
 }
 
-var matcher = regexp.MustCompile(`https://app\.hubspot\.com/contacts/2762526/company/\d+`)
-
-func Customer(body string) string {
-	customer := matcher.FindString(body)
-	if customer == "" {
-		return "👩"
+// Category returns the category of the first label with one in categories, or
+// "other" if there is none.
+func Category(labels []string, categories map[string]string) string {
+	for _, label := range labels {
+		if category, ok := categories[label]; ok {
+			return category
+		}
 	}
-	return "[👩](" + customer + ")"
+	return "other"
 }
 
 func Assignee(assignees []string) string {
@@ -232,17 +509,32 @@ type TrackingIssue struct {
 	*Issue
 	Issues []*Issue
 	PRs    []*PullRequest
+
+	// Config is the definition the tracking issue was found by. If nil, the
+	// defaults apply.
+	Config *IssueConfig `json:"-"`
+
+	workloads *Workloads
+}
+
+// RenderWork renders the work section with the renderer of the definition.
+func (t *TrackingIssue) RenderWork() (string, error) {
+	var r Renderer = markdownRenderer{}
+	if t.Config != nil && t.Config.renderer != nil {
+		r = t.Config.renderer
+	}
+	return Render(r, t.Workloads())
 }
 
 func (t *TrackingIssue) UpdateWork(work string) (updated bool, err error) {
-	const (
-		openingMarker = "<!-- BEGIN WORK -->"
-		closingMarker = "<!-- END WORK -->"
-	)
+	markers := defaultMarkers
+	if t.Config != nil {
+		markers = t.Config.Markers
+	}
 
 	before := t.Body
 
-	after, err := patch(t.Body, work, openingMarker, closingMarker)
+	after, err := patch(t.Body, work, markers.Begin, markers.End)
 	if err != nil {
 		return false, err
 	}
@@ -251,24 +543,94 @@ func (t *TrackingIssue) UpdateWork(work string) (updated bool, err error) {
 	return before != after, nil
 }
 
+// Filter removes the issues and pull requests whose labels don't match.
+func (t *TrackingIssue) Filter(match func(labels []string) bool) {
+	issues := t.Issues[:0]
+	for _, issue := range t.Issues {
+		if match(issue.Labels) {
+			issues = append(issues, issue)
+		}
+	}
+	t.Issues = issues
+
+	prs := t.PRs[:0]
+	for _, pr := range t.PRs {
+		if match(pr.Labels) {
+			prs = append(prs, pr)
+		}
+	}
+	t.PRs = prs
+}
+
+// Workloads groups the work of the tracking issue by assignee. Since it links
+// issues and pull requests, the workloads are only computed once.
 func (t *TrackingIssue) Workloads() Workloads {
+	if t.workloads == nil {
+		ws := t.computeWorkloads()
+		t.workloads = &ws
+	}
+	return *t.workloads
+}
+
+func (t *TrackingIssue) computeWorkloads() Workloads {
 	workloads := map[string]*Workload{}
 
 	workload := func(assignee string) *Workload {
 		w := workloads[assignee]
 		if w == nil {
-			w = &Workload{Assignee: assignee}
+			w = &Workload{Assignee: assignee, Unit: defaultEstimates.Unit}
+			if t.Config != nil {
+				w.Unit = t.Config.Estimates.unit()
+				w.Capacity, w.HasCapacity = t.Config.Capacity[assignee]
+				for _, r := range t.Config.Rotations {
+					if r.applies(assignee, t.Milestone) {
+						w.Rotations = append(w.Rotations, r)
+						w.RotationDays += r.offDays()
+					}
+				}
+			}
 			workloads[assignee] = w
 		}
 		return w
 	}
 
+	var (
+		reviewCost float64
+		estimates  *Estimates
+		customers  []*CustomerMapping
+		categories map[string]string
+		aggregated bool
+	)
+
+	if t.Config != nil {
+		reviewCost = t.Config.ReviewCost
+		estimates = &t.Config.Estimates
+		customers = t.Config.Customers
+		categories = t.Config.Categories
+		aggregated = t.Config.Aggregated()
+	}
+
 	for _, pr := range t.PRs {
+		pr.customers = customers
+		pr.qualified = aggregated
+
 		w := workload(pr.Author)
 		w.PullRequests = append(w.PullRequests, pr)
+
+		if reviewCost > 0 && strings.EqualFold(pr.State, "open") {
+			for _, reviewer := range pr.Reviewers {
+				r := workload(reviewer)
+				r.Reviews = append(r.Reviews, pr)
+				r.ReviewDays += reviewCost
+			}
+		}
 	}
 
 	for _, issue := range t.Issues {
+		issue.estimates = estimates
+		issue.customers = customers
+		issue.qualified = aggregated
+
 		w := workload(Assignee(issue.Assignees))
 
 		w.Issues = append(w.Issues, issue)
@@ -280,14 +642,29 @@ func (t *TrackingIssue) Workloads() Workloads {
 		}
 
 		if t.Milestone == "" || issue.Milestone == t.Milestone {
-			estimate := Estimate(issue.Labels)
-			w.Days += Days(estimate)
+			estimate := issue.estimateValue()
+			w.Days += estimate
+			if issue.CarriedOver {
+				w.CarriedOver += estimate
+			}
+			if len(categories) > 0 && issue.Estimate() != "" {
+				if w.Categories == nil {
+					w.Categories = map[string]float64{}
+				}
+				w.Categories[Category(issue.Labels, categories)] += estimate
+			}
 		} else {
 			issue.Deprioritised = true
 		}
 	}
 
-	return workloads
+	if t.Config != nil {
+		for _, w := range workloads {
+			sortIssues(w.Issues, t.Config.Sort.Issues)
+		}
+	}
+
+	return Workloads{ByAssignee: workloads, config: t.Config}
 }
 
 type Issue struct {
@@ -308,34 +685,98 @@ type Issue struct {
 	ClosedAt   time.Time
 
 	Deprioritised bool           `json:"-"`
+	CarriedOver   bool           `json:"-"` // Listed in the previous milestone's tracking issue
+	Stale         bool           `json:"-"` // Without activity for the configured number of days
 	LinkedPRs     []*PullRequest `json:"-"`
+
+	estimates *Estimates
+	customers []*CustomerMapping
+	qualified bool // Whether references include the repository
+
+	// Set from the project fields, if configured.
+	projectItems []projectItem
+	estimate     string
+	status       string
+	done         bool
+
+	// Set from the timeline, if timeline metrics are enabled.
+	events       []timelineEvent
+	timed        bool
+	milestonedAt time.Time
+	labeledAt    time.Time // When the in progress label was added
+}
+
+// Estimate returns the estimate of the issue, such as 2d, from its project
+// fields or its estimate label.
+func (issue *Issue) Estimate() string {
+	if issue.estimate != "" {
+		return issue.estimate
+	}
+	return issue.estimateFormat().Estimate(issue.Labels)
+}
+
+// estimateValue returns the estimate of the issue in the configured unit.
+func (issue *Issue) estimateValue() float64 {
+	return issue.estimateFormat().Value(issue.Estimate())
+}
+
+func (issue *Issue) estimateFormat() *Estimates {
+	if issue.estimates == nil {
+		return &defaultEstimates
+	}
+	return issue.estimates
+}
+
+// Closed reports whether the issue is closed, or done according to its
+// project status.
+func (issue *Issue) Closed() bool {
+	return issue.done || strings.EqualFold(issue.State, "closed")
 }
 
 func (issue *Issue) Markdown() string {
 	state := " "
-	if strings.EqualFold(issue.State, "closed") {
+	if issue.Closed() {
 		state = "x"
 	}
 
-	estimate := Estimate(issue.Labels)
+	estimate := issue.Estimate()
 
 	if estimate != "" {
 		estimate = "__" + estimate + "__ "
 	}
 
-	return fmt.Sprintf("- [%s] %s [#%d](%s) %s%s\n",
+	return fmt.Sprintf("- [%s] %s [%s](%s) %s%s\n",
 		state,
 		issue.title(),
-		issue.Number,
+		reference(issue.Repository, issue.Number, issue.qualified),
 		issue.URL,
 		estimate,
 		issue.Emojis(),
 	)
 }
 
+// reference returns the reference of an issue or pull request, such as #123,
+// or sourcegraph/sourcegraph#123 if qualified.
+func reference(repository string, number int, qualified bool) string {
+	if qualified {
+		return repository + "#" + strconv.Itoa(number)
+	}
+	return "#" + strconv.Itoa(number)
+}
+
 func (issue *Issue) Emojis() string {
-	categories := Categories(issue.Labels, issue.Repository, issue.Body)
-	return Emojis(categories)
+	return Emojis(issue.categories())
+}
+
+func (issue *Issue) categories() map[string]string {
+	categories := Categories(issue.Labels, issue.Repository, issue.Body, issue.customers)
+	if issue.CarriedOver {
+		categories["carried-over"] = "↩️"
+	}
+	if issue.Stale {
+		categories["stale"] = "💤"
+	}
+	return categories
 }
 
 func Emojis(categories map[string]string) string {
@@ -385,8 +826,18 @@ func (issue *Issue) title() string {
 }
 
 func (issue *Issue) LinkedPullRequests(prs []*PullRequest) (linked []*PullRequest) {
+	ref := "#" + strconv.Itoa(issue.Number)
 	for _, pr := range prs {
-		if strings.Contains(pr.Body, "#"+strconv.Itoa(issue.Number)) {
+		// When work spans orgs, pull requests in other repositories have to
+		// reference the issue with its repository or URL.
+		if issue.qualified && pr.Repository != issue.Repository {
+			if strings.Contains(pr.Body, issue.Repository+ref) || strings.Contains(pr.Body, issue.URL) {
+				linked = append(linked, pr)
+			}
+			continue
+		}
+
+		if strings.Contains(pr.Body, ref) {
 			linked = append(linked, pr)
 		}
 	}
@@ -427,8 +878,12 @@ type PullRequest struct {
 	UpdatedAt  time.Time
 	ClosedAt   time.Time
 	BeganAt    time.Time // Time of the first authored commit
+	Reviewers  []string  // Users whose review is requested
 
 	LinkedIssues []*Issue `json:"-"`
+
+	customers []*CustomerMapping
+	qualified bool // Whether references include the repository
 }
 
 func (pr *PullRequest) Markdown() string {
@@ -437,19 +892,23 @@ func (pr *PullRequest) Markdown() string {
 		state = "x"
 	}
 
-	return fmt.Sprintf("- [%s] %s [#%d](%s) %s\n",
+	return fmt.Sprintf("- [%s] %s [%s](%s) %s\n",
 		state,
 		pr.title(),
-		pr.Number,
+		reference(pr.Repository, pr.Number, pr.qualified),
 		pr.URL,
 		pr.Emojis(),
 	)
 }
 
 func (pr *PullRequest) Emojis() string {
-	categories := Categories(pr.Labels, pr.Repository, pr.Body)
+	return Emojis(pr.categories())
+}
+
+func (pr *PullRequest) categories() map[string]string {
+	categories := Categories(pr.Labels, pr.Repository, pr.Body, pr.customers)
 	categories["pull-request"] = ":shipit:"
-	return Emojis(categories)
+	return categories
 }
 
 func (pr *PullRequest) title() string {
@@ -475,24 +934,32 @@ func (pr *PullRequest) Redact() {
 	}
 }
 
-func Categories(labels []string, repository, body string) map[string]string {
+// Categories returns the emojis of an issue or pull request, keyed by category.
+// If customers is nil, the default customer mappings apply.
+func Categories(labels []string, repository, body string, customers []*CustomerMapping) map[string]string {
 	categories := make(map[string]string, len(labels))
 
-	switch repository {
-	case "sourcegraph/customer":
-		categories["customer"] = Customer(body)
-	case "sourcegraph/security-prs":
+	if repository == "sourcegraph/security-prs" {
 		categories["security"] = Emoji("security")
 	}
 
 	for _, label := range labels {
-		if label == "customer" {
-			categories[label] = Customer(body)
-		} else if emoji := Emoji(label); emoji != "" {
+		if emoji := Emoji(label); emoji != "" {
 			categories[label] = emoji
 		}
 	}
 
+	if customers == nil {
+		customers = defaultCustomerMappings
+	}
+
+	// Mappings with the same emoji annotate an issue only once.
+	for _, m := range customers {
+		if annotation, ok := m.Annotation(labels, repository, body); ok {
+			categories["customer:"+m.Emoji] = annotation
+		}
+	}
+
 	return categories
 }
 
@@ -526,18 +993,25 @@ type searchNode struct {
 		NameWithOwner string
 		IsPrivate     bool
 	}
-	Author    struct{ Login string }
-	Assignees struct{ Nodes []struct{ Login string } }
-	Labels    struct{ Nodes []struct{ Name string } }
-	Milestone struct{ Title string }
-	Commits   struct {
+	Author         struct{ Login string }
+	Assignees      struct{ Nodes []struct{ Login string } }
+	Labels         struct{ Nodes []struct{ Name string } }
+	Milestone      struct{ Title string }
+	ReviewRequests struct {
+		Nodes []struct {
+			RequestedReviewer struct{ Login string }
+		}
+	}
+	Commits struct {
 		Nodes []struct {
 			Commit struct{ AuthoredDate time.Time }
 		}
 	}
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	ClosedAt  time.Time
+	ProjectItems  projectItemsNode
+	TimelineItems timelineNode
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	ClosedAt      time.Time
 }
 
 type search struct {
@@ -548,65 +1022,190 @@ type search struct {
 	Nodes []searchNode
 }
 
-func loadTrackingIssues(ctx context.Context, cli *graphql.Client, org string, issues []*TrackingIssue) error {
-	var q bytes.Buffer
-	q.WriteString("query(\n")
+// searchesPerRequest is the maximum number of searches in a GraphQL request.
+// Larger requests are more likely to time out, and smaller ones can be sent
+// concurrently.
+const searchesPerRequest = 4
+
+// searchQuery is a search for the work of tracking issues.
+type searchQuery struct {
+	issues []*TrackingIssue
+	query  string
+	fields searchFields
+	cursor string
+	done   bool
+	nodes  []searchNode
+}
 
-	type query struct {
-		issue  *TrackingIssue
-		count  int
-		cursor string
-		query  string
+// searchFields are the optional fields of the issues in search results, which
+// are only fetched if a definition needs them.
+type searchFields struct {
+	projectItems bool // Need the read:project scope
+	timeline     bool // Add to the cost of searches
+}
+
+func (f searchFields) or(g searchFields) searchFields {
+	return searchFields{
+		projectItems: f.projectItems || g.projectItems,
+		timeline:     f.timeline || g.timeline,
+	}
+}
+
+func loadTrackingIssues(ctx context.Context, cli *Client, org string, issues []*TrackingIssue) error {
+	// Tracking issues with the same milestone and labels share a search, so
+	// that it is fetched only once per run.
+	queries := map[string]*searchQuery{}
+	bySearch := map[string]*searchQuery{}
+	var names []string
+
+	add := func(name string, issue *TrackingIssue, search string) {
+		var fields searchFields
+		if issue.Config != nil {
+			fields = issue.Config.searchFields()
+		}
+
+		if existing, ok := bySearch[search]; ok {
+			existing.issues = append(existing.issues, issue)
+			existing.fields = existing.fields.or(fields)
+			return
+		}
+
+		queries[name] = &searchQuery{
+			issues: []*TrackingIssue{issue},
+			query:  search,
+			fields: fields,
+		}
+		bySearch[search] = queries[name]
+		names = append(names, name)
 	}
 
-	queries := map[string]*query{}
 	for _, issue := range issues {
+		var qualifiers string
+		var narrowed bool
+		if issue.Config != nil {
+			qualifiers, narrowed = issue.Config.SearchQualifiers(), issue.Config.narrowsWork()
+		}
+
+		// Without a milestone, labels or a project, a tracking issue would
+		// track all work in the org, or in all of its orgs and repos.
+		if issue.Milestone == "" && !narrowed && !hasWorkLabels(issue.Labels) {
+			log.Printf("skipping %q %s: no milestone, labels or project to find its work by", issue.Title, issue.URL)
+			continue
+		}
+
 		if issue.Milestone == "" {
 			name := "tracking" + strconv.Itoa(issue.Number)
-			fmt.Fprintf(&q, "$%[1]sCount: Int!, $%[1]sCursor: String, $%[1]sQuery: String!,\n", name)
-			queries[name] = &query{
-				issue: issue,
-				count: 100,
-				query: listIssuesSearchQuery(org, "", issue.Labels, false),
-			}
+			add(name, issue, listIssuesSearchQuery(org, "", issue.Labels, false)+qualifiers)
 		} else {
 			milestoned := "tracking" + strconv.Itoa(issue.Number) + "Milestoned"
-			fmt.Fprintf(&q, "$%[1]sCount: Int!, $%[1]sCursor: String, $%[1]sQuery: String!,\n", milestoned)
-
-			queries[milestoned] = &query{
-				issue: issue,
-				count: 100,
-				query: listIssuesSearchQuery(org, issue.Milestone, issue.Labels, false),
-			}
+			add(milestoned, issue, listIssuesSearchQuery(org, issue.Milestone, issue.Labels, false)+qualifiers)
 
 			demilestoned := "tracking" + strconv.Itoa(issue.Number) + "Demilestoned"
-			fmt.Fprintf(&q, "$%[1]sCount: Int!, $%[1]sCursor: String, $%[1]sQuery: String!,\n", demilestoned)
+			add(demilestoned, issue, listIssuesSearchQuery(org, issue.Milestone, issue.Labels, true)+qualifiers)
+		}
+	}
 
-			queries[demilestoned] = &query{
-				issue: issue,
-				count: 100,
-				query: listIssuesSearchQuery(org, issue.Milestone, issue.Labels, true),
-			}
+	// Each batch of searches is paginated on its own, concurrently with the
+	// other batches.
+	g, gctx := errgroup.WithContext(ctx)
+	for i := 0; i < len(names); i += searchesPerRequest {
+		j := i + searchesPerRequest
+		if j > len(names) {
+			j = len(names)
 		}
+
+		batch := names[i:j]
+		g.Go(func() error {
+			return runSearches(gctx, cli, batch, queries)
+		})
 	}
 
-	q.Truncate(q.Len() - 1) // Remove the trailing comma from the loop above.
-	q.WriteString(") {")
+	if err := g.Wait(); err != nil {
+		return err
+	}
 
-	for query := range queries {
-		q.WriteString(searchGraphQLQuery(query))
+	// Merge the results in the order the searches were added, so that they
+	// don't depend on which batch finished first.
+	for _, name := range names {
+		q := queries[name]
+
+		// Each tracking issue gets its own copies of the results, since
+		// computing its workloads links them to each other.
+		for _, t := range q.issues {
+			issues, prs := unmarshalSearchNodes(q.nodes)
+
+			if t.Config != nil && t.Config.ProjectFields != nil {
+				inColumn := issues[:0]
+				for _, issue := range issues {
+					t.Config.ProjectFields.apply(issue, t.Config.Estimates.unit())
+					if t.Config.InColumn(issue) {
+						inColumn = append(inColumn, issue)
+					}
+				}
+				issues = inColumn
+			}
+
+			if t.Config != nil && t.Config.Timeline != nil {
+				for _, issue := range issues {
+					t.Config.Timeline.apply(issue)
+				}
+			}
+
+			t.Issues = append(t.Issues, issues...)
+			t.PRs = append(t.PRs, prs...)
+		}
 	}
 
-	q.WriteString("}")
+	return nil
+}
 
+// runSearches fetches all pages of the named searches, batched in one request
+// per page.
+func runSearches(ctx context.Context, cli *Client, names []string, queries map[string]*searchQuery) error {
 	for {
+		// Only the searches with more pages are part of the next request, so
+		// that finished searches don't add to its cost.
+		var pending []string
+		for _, name := range names {
+			if !queries[name].done {
+				pending = append(pending, name)
+			}
+		}
+
+		if len(pending) == 0 {
+			return nil
+		}
+
+		var q bytes.Buffer
+		q.WriteString("query(\n")
+
+		for _, name := range pending {
+			fmt.Fprintf(&q, "$%[1]sCount: Int!, $%[1]sCursor: String, $%[1]sQuery: String!,\n", name)
+		}
+
+		q.Truncate(q.Len() - 2) // Remove the trailing comma from the loop above.
+		q.WriteString(") {\n")
+		q.WriteString(rateLimitGraphQLQuery)
+
+		var fields searchFields
+		for _, name := range pending {
+			fields = fields.or(queries[name].fields)
+		}
+
+		for _, name := range pending {
+			q.WriteString(searchGraphQLQuery(name, fields))
+		}
+
+		q.WriteString("}")
+
 		r := graphql.NewRequest(q.String())
 
-		for query, args := range queries {
-			r.Var(query+"Count", args.count)
-			r.Var(query+"Query", args.query)
+		for _, name := range pending {
+			args := queries[name]
+			r.Var(name+"Count", 100)
+			r.Var(name+"Query", args.query)
 			if args.cursor != "" {
-				r.Var(query+"Cursor", args.cursor)
+				r.Var(name+"Cursor", args.cursor)
 			}
 		}
 
@@ -617,40 +1216,31 @@ func loadTrackingIssues(ctx context.Context, cli *graphql.Client, org string, is
 			return err
 		}
 
-		var hasNextPage bool
-		for query, s := range data {
-			q := queries[query]
+		for _, name := range pending {
+			q, s := queries[name], data[name]
 
 			if s.PageInfo.HasNextPage {
-				hasNextPage = true
 				q.cursor = s.PageInfo.EndCursor
 			} else {
-				q.count = 0
+				q.done = true
 			}
 
-			issues, prs := unmarshalSearchNodes(s.Nodes)
-			q.issue.Issues = append(q.issue.Issues, issues...)
-			q.issue.PRs = append(q.issue.PRs, prs...)
-		}
-
-		if !hasNextPage {
-			break
+			q.nodes = append(q.nodes, s.Nodes...)
 		}
 	}
-
-	return nil
 }
 
-func listTrackingIssues(ctx context.Context, cli *graphql.Client, org string) (all []*Issue, _ error) {
+func listTrackingIssues(ctx context.Context, cli *Client, ic *IssueConfig) (all []*Issue, _ error) {
 	var q strings.Builder
 	q.WriteString("query($trackingCount: Int!, $trackingCursor: String, $trackingQuery: String!) {\n")
-	q.WriteString(searchGraphQLQuery("tracking"))
+	q.WriteString(rateLimitGraphQLQuery)
+	q.WriteString(searchGraphQLQuery("tracking", searchFields{}))
 	q.WriteString("}")
 
 	r := graphql.NewRequest(q.String())
 
 	r.Var("trackingCount", 100)
-	r.Var("trackingQuery", fmt.Sprintf("org:%q label:tracking is:open", org))
+	r.Var("trackingQuery", listTrackingIssuesSearchQuery(ic))
 
 	for {
 		var data struct{ Tracking search }
@@ -693,7 +1283,10 @@ func unmarshalSearchNodes(nodes []searchNode) (issues []*Issue, prs []*PullReque
 				CreatedAt:  n.CreatedAt,
 				UpdatedAt:  n.UpdatedAt,
 				ClosedAt:   n.ClosedAt,
-				BeganAt:    n.Commits.Nodes[0].Commit.AuthoredDate,
+			}
+
+			if len(n.Commits.Nodes) > 0 {
+				pr.BeganAt = n.Commits.Nodes[0].Commit.AuthoredDate
 			}
 
 			for _, assignee := range n.Assignees.Nodes {
@@ -704,6 +1297,13 @@ func unmarshalSearchNodes(nodes []searchNode) (issues []*Issue, prs []*PullReque
 				pr.Labels = append(pr.Labels, label.Name)
 			}
 
+			for _, request := range n.ReviewRequests.Nodes {
+				// Team review requests have no login.
+				if login := request.RequestedReviewer.Login; login != "" {
+					pr.Reviewers = append(pr.Reviewers, login)
+				}
+			}
+
 			prs = append(prs, pr)
 
 		case "Issue":
@@ -733,6 +1333,12 @@ func unmarshalSearchNodes(nodes []searchNode) (issues []*Issue, prs []*PullReque
 				issue.Labels = append(issue.Labels, label.Name)
 			}
 
+			if len(n.ProjectItems.Nodes) > 0 {
+				issue.projectItems = n.ProjectItems.items()
+			}
+
+			issue.events = n.TimelineItems.Nodes
+
 			issues = append(issues, issue)
 		}
 	}
@@ -740,7 +1346,7 @@ func unmarshalSearchNodes(nodes []searchNode) (issues []*Issue, prs []*PullReque
 	return issues, prs
 }
 
-func searchGraphQLQuery(alias string) string {
+func searchGraphQLQuery(alias string, fields searchFields) string {
 	const searchQuery = `%[1]s: search(first: $%[1]sCount, type: ISSUE, after: $%[1]sCursor query: $%[1]sQuery) {
 		pageInfo {
 			endCursor
@@ -758,16 +1364,16 @@ func searchGraphQLQuery(alias string) string {
 
 	return fmt.Sprintf(searchQuery,
 		alias,
-		searchNodeFields(false),
-		searchNodeFields(true),
+		searchNodeFields(false, fields),
+		searchNodeFields(true, searchFields{}),
 	)
 }
 
-func searchNodeFields(isPR bool) string {
+func searchNodeFields(isPR bool, optional searchFields) string {
 	fields := `
 		__typename
 		id, title, body, state, number, url
-		createdAt, closedAt
+		createdAt, updatedAt, closedAt
 		repository { nameWithOwner, isPrivate }
 		author { login }
 		assignees(first: 25) { nodes { login } }
@@ -778,12 +1384,32 @@ func searchNodeFields(isPR bool) string {
 	if isPR {
 		fields += `
 			commits(first: 1) { nodes { commit { authoredDate } } }
+			reviewRequests(first: 25) { nodes { requestedReviewer { ... on User { login } } } }
 		`
 	}
 
+	if optional.projectItems {
+		fields += projectItemsGraphQLFields
+	}
+
+	if optional.timeline {
+		fields += timelineGraphQLFields
+	}
+
 	return fields
 }
 
+// hasWorkLabels reports whether the labels of a tracking issue narrow down its
+// work.
+func hasWorkLabels(labels []string) bool {
+	for _, label := range labels {
+		if label != "" && label != "tracking" {
+			return true
+		}
+	}
+	return false
+}
+
 func listIssuesSearchQuery(org, milestone string, labels []string, demilestoned bool) string {
 	var q strings.Builder
 
@@ -805,3 +1431,21 @@ func listIssuesSearchQuery(org, milestone string, labels []string, demilestoned
 
 	return q.String()
 }
+
+func listTrackingIssuesSearchQuery(ic *IssueConfig) string {
+	var q strings.Builder
+
+	fmt.Fprintf(&q, "org:%q label:tracking is:open", ic.Org)
+
+	if ic.Milestone != "" {
+		fmt.Fprintf(&q, " milestone:%q", ic.Milestone)
+	}
+
+	for _, label := range ic.Labels {
+		if label != "" && label != "tracking" {
+			fmt.Fprintf(&q, " label:%q", label)
+		}
+	}
+
+	return q.String()
+}
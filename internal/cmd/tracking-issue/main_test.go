@@ -4,13 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 
-	"github.com/machinebox/graphql"
-	"golang.org/x/oauth2"
-
 	"github.com/sourcegraph/sourcegraph/internal/testutil"
 )
 
@@ -40,13 +42,12 @@ func loadTrackingIssueFixtures(t testing.TB, org string, issue *TrackingIssue) {
 
 	if *updateFixture {
 		ctx := context.Background()
-		cli := graphql.NewClient(
-			"https://api.github.com/graphql",
-			graphql.WithHTTPClient(oauth2.NewClient(ctx, oauth2.StaticTokenSource(
-				&oauth2.Token{AccessToken: os.Getenv("GITHUB_TOKEN")},
-			))),
-		)
-		err := loadTrackingIssues(ctx, cli, org, []*TrackingIssue{issue})
+		cli, err := newClient(ctx, os.Getenv("GITHUB_TOKEN"), "https://github.com", "", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = loadTrackingIssues(ctx, cli, org, []*TrackingIssue{issue})
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -72,3 +73,339 @@ func loadTrackingIssueFixtures(t testing.TB, org string, issue *TrackingIssue) {
 		t.Fatal(err)
 	}
 }
+
+func TestPatch(t *testing.T) {
+	const (
+		opening = "<!-- BEGIN WORK -->"
+		closing = "<!-- END WORK -->"
+	)
+
+	for _, tc := range []struct {
+		name string
+		body string
+		want string
+		err  string
+	}{
+		{
+			name: "preserves text outside markers",
+			body: "intro\n" + opening + "old" + closing + "\noutro",
+			want: "intro\n" + opening + "new" + closing + "\noutro",
+		},
+		{
+			name: "closing marker before opening marker",
+			body: "see " + closing + " below\n" + opening + "old" + closing,
+			want: "see " + closing + " below\n" + opening + "new" + closing,
+		},
+		{
+			name: "no opening marker",
+			body: "old" + closing,
+			err:  "could not find opening marker in issue body",
+		},
+		{
+			name: "no closing marker",
+			body: opening + "old",
+			err:  "could not find closing marker in issue body",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := patch(tc.body, "new", opening, closing)
+			if tc.err != "" {
+				if have := fmt.Sprint(err); have != tc.err {
+					t.Fatalf("error: have %q, want %q", have, tc.err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tc.want {
+				t.Errorf("have %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWorkloadsMarkdownOvercommitted(t *testing.T) {
+	ti := &TrackingIssue{
+		Issue: &Issue{Milestone: "3.14"},
+		Issues: []*Issue{
+			{Number: 1, Title: "a", Milestone: "3.14", Assignees: []string{"alice"}, Labels: []string{"estimate/3d"}},
+			{Number: 2, Title: "b", Milestone: "3.14", Assignees: []string{"alice"}, Labels: []string{"estimate/2d"}},
+			{Number: 3, Title: "c", Milestone: "3.14", Assignees: []string{"bob"}, Labels: []string{"estimate/1d"}},
+			{Number: 4, Title: "d", Milestone: "3.14", Assignees: []string{"carol"}, Labels: []string{"estimate/1d"}},
+			{Number: 5, Title: "e", Milestone: "3.14", Assignees: []string{"dave"}, Labels: []string{"estimate/1d"}},
+		},
+		// Carol is out for the milestone, and dave's capacity is unknown.
+		Config: &IssueConfig{Capacity: map[string]float64{"alice": 4, "bob": 5, "carol": 0}},
+	}
+
+	want := `
+⚠️ __Overcommitted__
+
+- @alice: __5.00d__ estimated, 4.00d available
+- @carol: __1.00d__ estimated, 0.00d available
+
+@alice: __5.00d__ of 4.00d ⚠️
+
+- [ ] a [#1]() __3d__ 
+- [ ] b [#2]() __2d__ 
+
+@bob: __1.00d__ of 5.00d
+
+- [ ] c [#3]() __1d__ 
+
+@carol: __1.00d__ of 0.00d ⚠️
+
+- [ ] d [#4]() __1d__ 
+
+@dave: __1.00d__
+
+- [ ] e [#5]() __1d__ 
+`
+
+	if have := ti.Workloads().Markdown(); have != want {
+		t.Errorf("have:\n%s\nwant:\n%s", have, want)
+	}
+}
+
+func TestLoadTrackingIssuesPaginated(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct{ Variables map[string]interface{} }
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+			return
+		}
+
+		data := map[string]interface{}{"rateLimit": map[string]int{"cost": 1}}
+		for name, v := range req.Variables {
+			alias := strings.TrimSuffix(name, "Query")
+			if alias == name {
+				continue
+			}
+
+			page, hasNextPage := 1, true
+			if req.Variables[alias+"Cursor"] != nil {
+				page, hasNextPage = 2, false
+			}
+
+			data[alias] = map[string]interface{}{
+				"pageInfo": map[string]interface{}{"endCursor": "next", "hasNextPage": hasNextPage},
+				"nodes": []map[string]interface{}{{
+					"__typename": "Issue",
+					"title":      fmt.Sprintf("%s page %d", v, page),
+				}},
+			}
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	defer srv.Close()
+
+	cli := newTestClient(srv.URL)
+	cli.Concurrency = 2
+
+	var tracking []*TrackingIssue
+	for i := 1; i <= 5; i++ {
+		tracking = append(tracking, &TrackingIssue{Issue: &Issue{
+			Number:    i,
+			Milestone: fmt.Sprintf("3.%d", i),
+		}})
+	}
+
+	// Share the searches of the first tracking issue.
+	tracking = append(tracking, &TrackingIssue{Issue: &Issue{Number: 6, Milestone: "3.1"}})
+
+	if err := loadTrackingIssues(context.Background(), cli, "sourcegraph", tracking); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ti := range tracking {
+		milestoned := listIssuesSearchQuery("sourcegraph", ti.Milestone, nil, false)
+		demilestoned := listIssuesSearchQuery("sourcegraph", ti.Milestone, nil, true)
+
+		var have []string
+		for _, issue := range ti.Issues {
+			have = append(have, issue.Title)
+		}
+
+		want := []string{
+			milestoned + " page 1",
+			milestoned + " page 2",
+			demilestoned + " page 1",
+			demilestoned + " page 2",
+		}
+
+		if !reflect.DeepEqual(have, want) {
+			t.Errorf("tracking issue #%d: have %q, want %q", ti.Number, have, want)
+		}
+	}
+
+	if ti1, ti6 := tracking[0], tracking[5]; ti1.Issues[0] == ti6.Issues[0] {
+		t.Error("tracking issues with the same searches share results")
+	}
+
+	// 10 searches in 3 batches, each of them fetched in 2 pages.
+	if have, want := cli.Cost(), 6; have != want {
+		t.Errorf("cost: have %d, want %d", have, want)
+	}
+}
+
+func TestLoadTrackingIssuesUnscoped(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("unexpected search for a tracking issue without milestone or labels")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{}})
+	}))
+	defer srv.Close()
+
+	for name, ic := range map[string]*IssueConfig{
+		// The org and repo qualifiers of an aggregated definition don't
+		// narrow down its work.
+		"aggregated": {Org: "sourcegraph", Orgs: []string{"sourcegraph-enterprise"}, Repos: []string{"acme/fork"}},
+		// Neither does a label expression that only excludes labels.
+		"excluded labels": {Org: "sourcegraph", LabelExpression: "NOT icebox"},
+	} {
+		if err := ic.init(); err != nil {
+			t.Fatal(err)
+		}
+
+		tracking := []*TrackingIssue{{Issue: &Issue{Number: 1, Labels: []string{"tracking"}}, Config: ic}}
+		if err := loadTrackingIssues(context.Background(), newTestClient(srv.URL), "sourcegraph", tracking); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(tracking[0].Issues) != 0 {
+			t.Errorf("%s: issues: have %d, want none", name, len(tracking[0].Issues))
+		}
+	}
+}
+
+func TestWorkloadsMarkdownHygiene(t *testing.T) {
+	ti := &TrackingIssue{
+		Issue: &Issue{Milestone: "3.14"},
+		Issues: []*Issue{
+			{Number: 1, Title: "estimated", State: "OPEN", Milestone: "3.14", Assignees: []string{"alice"}, Labels: []string{"estimate/1d"}},
+			{Number: 2, Title: "unestimated", State: "OPEN", Milestone: "3.14", Assignees: []string{"alice"}},
+			{Number: 3, Title: "unassigned", State: "OPEN", Milestone: "3.14", Labels: []string{"estimate/2d"}},
+			{Number: 4, Title: "closed", State: "CLOSED", Milestone: "3.14"},
+			{Number: 5, Title: "deprioritised", State: "OPEN", Milestone: "3.13"},
+		},
+	}
+
+	want := `
+@Unassigned: __2.00d__
+
+- [ ] unassigned [#3]() __2d__ 
+- [x] closed [#4]() 
+- [ ] ~deprioritised~ [#5]() 
+
+@alice: __1.00d__
+
+- [ ] estimated [#1]() __1d__ 
+- [ ] unestimated [#2]() 
+
+⚠️ __Unestimated__
+
+- [ ] unestimated [#2]() 
+
+⚠️ __Unassigned__
+
+- [ ] unassigned [#3]() __2d__ 
+`
+
+	if have := ti.Workloads().Markdown(); have != want {
+		t.Errorf("have:\n%s\nwant:\n%s", have, want)
+	}
+}
+
+func TestWorkloadsReviewLoad(t *testing.T) {
+	ti := &TrackingIssue{
+		Issue: &Issue{Milestone: "3.14"},
+		Issues: []*Issue{
+			{Number: 1, Title: "a", State: "OPEN", Milestone: "3.14", Assignees: []string{"alice"}, Labels: []string{"estimate/1d"}},
+		},
+		PRs: []*PullRequest{
+			{Number: 2, Title: "open", State: "OPEN", Author: "bob", Reviewers: []string{"alice"}},
+			{Number: 3, Title: "merged", State: "MERGED", Author: "bob", Reviewers: []string{"alice"}},
+		},
+		Config: &IssueConfig{
+			ReviewCost: 0.5,
+			Capacity:   map[string]float64{"alice": 1.25},
+		},
+	}
+
+	want := `
+⚠️ __Overcommitted__
+
+- @alice: __1.50d__ estimated, 1.25d available
+
+@alice: __1.00d__ + 0.50d reviews of 1.25d ⚠️
+
+- [ ] a [#1]() __1d__ 
+- 👀 open [#2]()
+
+@bob
+
+- [ ] open [#2]() :shipit:
+- [x] merged [#3]() :shipit:
+`
+
+	if have := ti.Workloads().Markdown(); have != want {
+		t.Errorf("have:\n%s\nwant:\n%s", have, want)
+	}
+}
+
+func TestWorkloadsCategories(t *testing.T) {
+	ti := &TrackingIssue{
+		Issue: &Issue{Milestone: "3.14"},
+		Issues: []*Issue{
+			{Number: 1, Title: "feature", State: "OPEN", Milestone: "3.14", Assignees: []string{"alice"}, Labels: []string{"estimate/3d", "roadmap"}},
+			{Number: 2, Title: "fix", State: "CLOSED", Milestone: "3.14", Assignees: []string{"bob"}, Labels: []string{"bug", "estimate/1d"}},
+			{Number: 3, Title: "cleanup", State: "OPEN", Milestone: "3.14", Assignees: []string{"bob"}, Labels: []string{"estimate/1d"}},
+			{Number: 4, Title: "deprioritised", State: "OPEN", Milestone: "3.13", Assignees: []string{"bob"}, Labels: []string{"estimate/5d", "bug"}},
+		},
+		Config: &IssueConfig{
+			Categories: map[string]string{"roadmap": "planned", "bug": "reactive"},
+		},
+	}
+
+	want := `
+📊 __Categories__
+
+- planned: __3.00d__ (60%)
+- other: __1.00d__ (20%)
+- reactive: __1.00d__ (20%)
+`
+
+	have := ti.Workloads().Markdown()
+	if i := strings.Index(have, "\n📊"); i == -1 || have[i:] != want {
+		t.Errorf("have:\n%s\nwant categories:\n%s", have, want)
+	}
+}
+
+func TestWorkloadsAggregated(t *testing.T) {
+	ti := &TrackingIssue{
+		Issue: &Issue{},
+		Issues: []*Issue{
+			{Number: 1, Title: "a", URL: "https://github.com/sourcegraph/sourcegraph/issues/1", Repository: "sourcegraph/sourcegraph", State: "OPEN", Assignees: []string{"alice"}, Labels: []string{"estimate/1d"}},
+			{Number: 1, Title: "b", URL: "https://github.com/acme/enterprise/issues/1", Repository: "acme/enterprise", State: "OPEN", Assignees: []string{"alice"}, Labels: []string{"estimate/2d"}},
+		},
+		PRs: []*PullRequest{
+			{Number: 2, Title: "fix b", Body: "Fixes #1", URL: "https://github.com/acme/enterprise/pull/2", Repository: "acme/enterprise", State: "OPEN", Author: "alice"},
+		},
+		Config: &IssueConfig{Org: "sourcegraph", Orgs: []string{"acme"}},
+	}
+
+	want := `
+@alice: __3.00d__
+
+- [ ] a [sourcegraph/sourcegraph#1](https://github.com/sourcegraph/sourcegraph/issues/1) __1d__ 
+- [ ] b [acme/enterprise#1](https://github.com/acme/enterprise/issues/1) __2d__ 
+  - [ ] fix b [acme/enterprise#2](https://github.com/acme/enterprise/pull/2) :shipit:
+`
+
+	if have := ti.Workloads().Markdown(); have != want {
+		t.Errorf("have:\n%s\nwant:\n%s", have, want)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ProjectFields configures reading the estimates and statuses of issues from
+// the custom fields of GitHub Projects instead of labels, for teams that plan
+// in a project. Reading project fields needs a token with the read:project
+// scope.
+type ProjectFields struct {
+	// Project is the number of the project to read the fields from. If 0, the
+	// first project of an issue with the fields is used.
+	Project int `yaml:"project"`
+
+	// Estimate is the name of the number, text or single select field with the
+	// estimate in the unit of the estimates, such as 2 or 0.5d. Issues
+	// without a value fall back to their estimate label.
+	Estimate string `yaml:"estimate"`
+
+	// Status is the name of the single select field with the status of the
+	// issue.
+	Status string `yaml:"status"`
+
+	// Done are the statuses that count an open issue as done. It defaults to
+	// "Done".
+	Done []string `yaml:"done"`
+}
+
+// projectItem is an issue's item in a project, with the values of its fields
+// keyed by field name.
+type projectItem struct {
+	Project int
+	Fields  map[string]string
+}
+
+// apply sets the estimate and status of the issue from its project fields.
+// Numeric estimates are in the unit.
+func (pf *ProjectFields) apply(issue *Issue, unit string) {
+	for _, item := range issue.projectItems {
+		if pf.Project != 0 && item.Project != pf.Project {
+			continue
+		}
+
+		var ok bool
+
+		if pf.Estimate != "" {
+			if estimate := item.Fields[pf.Estimate]; estimate != "" {
+				issue.estimate, ok = projectEstimate(estimate, unit), true
+			}
+		}
+
+		if pf.Status != "" {
+			if status := item.Fields[pf.Status]; status != "" {
+				issue.status, ok = status, true
+				for _, done := range pf.Done {
+					if strings.EqualFold(status, done) {
+						issue.done = true
+					}
+				}
+			}
+		}
+
+		if ok {
+			return
+		}
+	}
+}
+
+// projectEstimate normalizes an estimate field value to the form of an
+// estimate label, such as 2d.
+func projectEstimate(value, unit string) string {
+	value = strings.TrimSpace(value)
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value + unit
+	}
+	return value
+}
+
+// projectItemsGraphQLFields are the GraphQL fields of the project items of an
+// issue, with the values of their number, text and single select fields.
+const projectItemsGraphQLFields = `
+	projectItems(first: 10) {
+		nodes {
+			project { number }
+			fieldValues(first: 25) {
+				nodes {
+					... on ProjectV2ItemFieldNumberValue { number, field { ... on ProjectV2FieldCommon { name } } }
+					... on ProjectV2ItemFieldTextValue { text, field { ... on ProjectV2FieldCommon { name } } }
+					... on ProjectV2ItemFieldSingleSelectValue { name, field { ... on ProjectV2FieldCommon { name } } }
+				}
+			}
+		}
+	}
+`
+
+type projectItemsNode struct {
+	Nodes []struct {
+		Project     struct{ Number int }
+		FieldValues struct {
+			Nodes []struct {
+				Number *float64
+				Text   string
+				Name   string
+				Field  struct{ Name string }
+			}
+		}
+	}
+}
+
+func (n projectItemsNode) items() []projectItem {
+	items := make([]projectItem, 0, len(n.Nodes))
+	for _, node := range n.Nodes {
+		item := projectItem{Project: node.Project.Number, Fields: map[string]string{}}
+		for _, v := range node.FieldValues.Nodes {
+			switch {
+			case v.Field.Name == "":
+				// Values of other field types.
+			case v.Number != nil:
+				item.Fields[v.Field.Name] = strconv.FormatFloat(*v.Number, 'f', -1, 64)
+			case v.Text != "":
+				item.Fields[v.Field.Name] = v.Text
+			default:
+				item.Fields[v.Field.Name] = v.Name
+			}
+		}
+		items = append(items, item)
+	}
+	return items
+}
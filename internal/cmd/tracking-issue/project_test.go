@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestProjectFields(t *testing.T) {
+	const data = `{"projectItems": {"nodes": [
+		{"project": {"number": 3}, "fieldValues": {"nodes": [
+			{"number": 8, "field": {"name": "Estimate"}},
+			{"name": "Done", "field": {"name": "Status"}}
+		]}},
+		{"project": {"number": 12}, "fieldValues": {"nodes": [
+			{},
+			{"number": 1.5, "field": {"name": "Estimate"}},
+			{"name": "Shipped", "field": {"name": "Status"}},
+			{"text": "some notes", "field": {"name": "Notes"}}
+		]}}
+	]}}`
+
+	var n searchNode
+	if err := json.Unmarshal([]byte(data), &n); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		fields   ProjectFields
+		labels   []string
+		estimate string
+		closed   bool
+	}{
+		{
+			name:     "first project",
+			fields:   ProjectFields{Estimate: "Estimate", Status: "Status", Done: []string{"Done"}},
+			estimate: "8d",
+			closed:   true,
+		},
+		{
+			name:     "numbered project",
+			fields:   ProjectFields{Project: 12, Estimate: "Estimate", Status: "Status", Done: []string{"Done"}},
+			estimate: "1.5d",
+		},
+		{
+			name:     "custom done status",
+			fields:   ProjectFields{Project: 12, Status: "Status", Done: []string{"shipped"}},
+			labels:   []string{"estimate/2d"},
+			estimate: "2d",
+			closed:   true,
+		},
+		{
+			name:     "missing field",
+			fields:   ProjectFields{Estimate: "Size"},
+			labels:   []string{"estimate/3d"},
+			estimate: "3d",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			issue := &Issue{State: "OPEN", Labels: tc.labels, projectItems: n.ProjectItems.items()}
+			tc.fields.apply(issue, "d")
+
+			if have := issue.Estimate(); have != tc.estimate {
+				t.Errorf("estimate: have %q, want %q", have, tc.estimate)
+			}
+
+			if have := issue.Closed(); have != tc.closed {
+				t.Errorf("closed: have %t, want %t", have, tc.closed)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/machinebox/graphql"
+)
+
+// Provider is a code host that tracking issues and the work they track are
+// loaded from, and that the updated tracking issues are written back to.
+type Provider interface {
+	// Load lists the tracking issues of each definition in cfg and loads
+	// their work. If include is not nil, only the tracking issues it returns
+	// true for are loaded.
+	Load(ctx context.Context, cfg *Config, include func(*TrackingIssue) bool) ([]*TrackingIssue, error)
+
+	// UpdateIssues writes the bodies of the issues back to the code host.
+	UpdateIssues(ctx context.Context, issues []*Issue) error
+
+	// Comment posts a comment to the issue.
+	Comment(ctx context.Context, issue *Issue, body string) error
+
+	// Labels returns the labels that exist in the orgs and repos of the
+	// definition, sorted.
+	Labels(ctx context.Context, ic *IssueConfig) ([]string, error)
+}
+
+// newProvider returns the provider selected by -provider.
+func newProvider(ctx context.Context, opts options) (Provider, error) {
+	switch opts.Provider {
+	case "", "github":
+		cli, err := newRunClient(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &gitHubProvider{cli}, nil
+
+	case "gitlab":
+		if opts.Token == "" {
+			return nil, fmt.Errorf("no -token given")
+		}
+		return newGitLabProvider(opts.GitLabURL, opts.Token, opts.Concurrency)
+
+	default:
+		return nil, fmt.Errorf("unsupported provider %q, want github or gitlab", opts.Provider)
+	}
+}
+
+// tokenEnv returns the env var that -token defaults to with the provider, so
+// that a GitHub token is never sent to a GitLab instance.
+func tokenEnv(provider string) string {
+	if provider == "gitlab" {
+		return "GITLAB_TOKEN"
+	}
+	return "GITHUB_TOKEN"
+}
+
+// gitHubProvider loads tracking issues with the GitHub GraphQL API.
+type gitHubProvider struct {
+	*Client
+}
+
+func (p *gitHubProvider) Load(ctx context.Context, cfg *Config, include func(*TrackingIssue) bool) ([]*TrackingIssue, error) {
+	return loadAll(ctx, p.Client, cfg, include)
+}
+
+func (p *gitHubProvider) UpdateIssues(ctx context.Context, issues []*Issue) error {
+	return updateIssues(ctx, p.Client, issues)
+}
+
+func (p *gitHubProvider) Comment(ctx context.Context, issue *Issue, body string) error {
+	r := graphql.NewRequest(`mutation($input: AddCommentInput!) {
+		addComment(input: $input) { clientMutationId }
+	}`)
+
+	r.Var("input", map[string]string{"subjectId": issue.ID, "body": body})
+	return p.Run(ctx, r, nil)
+}
+
+// Labels returns the labels of the repositories of the orgs and the repos of
+// the definition, sorted.
+func (p *gitHubProvider) Labels(ctx context.Context, ic *IssueConfig) ([]string, error) {
+	labels := map[string]bool{}
+
+	for _, org := range append([]string{ic.Org}, ic.Orgs...) {
+		// $labelsCursor is never set here: only the first page of labels
+		// of each repository is listed with the repositories.
+		r := graphql.NewRequest(`query($org: String!, $cursor: String, $labelsCursor: String) {
+			` + rateLimitGraphQLQuery + `
+			organization(login: $org) {
+				repositories(first: 50, after: $cursor) {
+					pageInfo { endCursor, hasNextPage }
+					nodes { name, ` + labelsGraphQLFields + ` }
+				}
+			}
+		}`)
+		r.Var("org", org)
+
+		for {
+			var data struct {
+				Organization struct {
+					Repositories struct {
+						PageInfo pageInfo
+						Nodes    []struct {
+							Name   string
+							Labels labelsNode
+						}
+					}
+				}
+			}
+
+			if err := p.Run(ctx, r, &data); err != nil {
+				return nil, err
+			}
+
+			repos := data.Organization.Repositories
+			for _, repo := range repos.Nodes {
+				for _, label := range repo.Labels.Nodes {
+					labels[label.Name] = true
+				}
+
+				// Repositories with more labels than fit on the first page
+				// are paginated separately.
+				if repo.Labels.PageInfo.HasNextPage {
+					if err := p.repoLabels(ctx, org, repo.Name, repo.Labels.PageInfo.EndCursor, labels); err != nil {
+						return nil, err
+					}
+				}
+			}
+
+			if !repos.PageInfo.HasNextPage {
+				break
+			}
+			r.Var("cursor", repos.PageInfo.EndCursor)
+		}
+	}
+
+	for _, repo := range ic.Repos {
+		parts := strings.SplitN(repo, "/", 2)
+		if err := p.repoLabels(ctx, parts[0], parts[1], "", labels); err != nil {
+			return nil, err
+		}
+	}
+
+	return sortedKeys(labels), nil
+}
+
+// labelsGraphQLFields selects a page of the labels of a repository.
+const labelsGraphQLFields = `labels(first: 100, after: $labelsCursor) {
+	pageInfo { endCursor, hasNextPage }
+	nodes { name }
+}`
+
+type labelsNode struct {
+	PageInfo pageInfo
+	Nodes    []struct{ Name string }
+}
+
+type pageInfo struct {
+	EndCursor   string
+	HasNextPage bool
+}
+
+// repoLabels adds the labels of the repository after the cursor to labels.
+func (p *gitHubProvider) repoLabels(ctx context.Context, owner, name, cursor string, labels map[string]bool) error {
+	r := graphql.NewRequest(`query($owner: String!, $name: String!, $labelsCursor: String) {
+		` + rateLimitGraphQLQuery + `
+		repository(owner: $owner, name: $name) { ` + labelsGraphQLFields + ` }
+	}`)
+	r.Var("owner", owner)
+	r.Var("name", name)
+
+	for {
+		if cursor != "" {
+			r.Var("labelsCursor", cursor)
+		}
+
+		var data struct{ Repository struct{ Labels labelsNode } }
+		if err := p.Run(ctx, r, &data); err != nil {
+			return err
+		}
+
+		for _, label := range data.Repository.Labels.Nodes {
+			labels[label.Name] = true
+		}
+
+		if !data.Repository.Labels.PageInfo.HasNextPage {
+			return nil
+		}
+		cursor = data.Repository.Labels.PageInfo.EndCursor
+	}
+}
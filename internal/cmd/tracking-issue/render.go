@@ -0,0 +1,418 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Renderer renders the parts of the work section of a tracking issue. Render
+// puts them together.
+type Renderer interface {
+	// Header renders the sections before the workloads.
+	Header(w io.Writer, s *Summary) error
+	// Workload renders the heading of the section of an assignee.
+	Workload(w io.Writer, wl *Workload) error
+	// Issue renders the line of an issue.
+	Issue(w io.Writer, issue *Issue) error
+	// PullRequest renders the line of a pull request.
+	PullRequest(w io.Writer, pr *PullRequest) error
+	// Review renders the line of a pull request the assignee is requested to
+	// review.
+	Review(w io.Writer, pr *PullRequest) error
+	// Summary renders the sections after the workloads.
+	Summary(w io.Writer, s *Summary) error
+}
+
+// Summary is what the header and summary sections of the work section are
+// rendered from.
+type Summary struct {
+	Workloads     []*Workload // In the configured order
+	Overcommitted []*Workload
+	CarriedOver   float64         // Days of work carried over from the previous milestone
+	Categories    []CategoryTotal // Largest first, if categories are configured
+	Unestimated   []*Issue        // Open issues planned for the milestone without an estimate
+	Unassigned    []*Issue        // Open issues planned for the milestone without an assignee
+	Stale         []*Issue        // Open issues planned for the milestone without recent activity
+	Cycles        []Cycle         // Of the issues planned for the milestone, if timeline metrics are enabled
+	Completed     []CompletedWeek // Most recent week first, if completed work is summarized by week
+	Unit          string          // Of the estimates, such as d
+}
+
+// CategoryTotal is the estimated work of a category.
+type CategoryTotal struct {
+	Category string
+	Days     float64
+	Percent  float64 // Share of the estimated work of all categories
+}
+
+// Summarize returns the summary of the workloads.
+func (ws Workloads) Summarize() *Summary {
+	s := &Summary{Unit: defaultEstimates.Unit}
+	if ws.config != nil {
+		s.Unit = ws.config.Estimates.unit()
+	}
+
+	for _, assignee := range ws.sortedAssignees() {
+		wl := ws.ByAssignee[assignee]
+		s.Workloads = append(s.Workloads, wl)
+		s.CarriedOver += wl.CarriedOver
+
+		if wl.Overcommitted() {
+			s.Overcommitted = append(s.Overcommitted, wl)
+		}
+
+		// List the open issues planned for the milestone that can't be
+		// accounted for, so that updates double as planning hygiene reports.
+		for _, issue := range wl.Issues {
+			if c, ok := issue.Cycle(); ok && !issue.Deprioritised {
+				s.Cycles = append(s.Cycles, c)
+			}
+
+			if issue.Deprioritised || issue.Closed() {
+				continue
+			}
+			if issue.Estimate() == "" {
+				s.Unestimated = append(s.Unestimated, issue)
+			}
+			if len(issue.Assignees) == 0 {
+				s.Unassigned = append(s.Unassigned, issue)
+			}
+			if issue.Stale {
+				s.Stale = append(s.Stale, issue)
+			}
+		}
+	}
+
+	s.Categories = ws.categoryTotals()
+
+	if ws.config != nil && ws.config.CompletedByWeek {
+		s.Completed = ws.completedWeeks()
+	}
+
+	return s
+}
+
+// categoryTotals sums the estimates of all workloads by category.
+func (ws Workloads) categoryTotals() []CategoryTotal {
+	var total float64
+	days := map[string]float64{}
+	for _, wl := range ws.ByAssignee {
+		for category, d := range wl.Categories {
+			days[category] += d
+			total += d
+		}
+	}
+
+	if total == 0 {
+		return nil
+	}
+
+	totals := make([]CategoryTotal, 0, len(days))
+	for category, d := range days {
+		totals = append(totals, CategoryTotal{Category: category, Days: d, Percent: d / total * 100})
+	}
+
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Days != totals[j].Days {
+			return totals[i].Days > totals[j].Days
+		}
+		return totals[i].Category < totals[j].Category
+	})
+
+	return totals
+}
+
+// Render renders the work section of the workloads.
+func Render(r Renderer, ws Workloads) (string, error) {
+	s := ws.Summarize()
+
+	var b strings.Builder
+
+	if err := r.Header(&b, s); err != nil {
+		return "", err
+	}
+
+	for _, wl := range s.Workloads {
+		if err := renderWorkload(&b, r, wl); err != nil {
+			return "", err
+		}
+	}
+
+	if err := r.Summary(&b, s); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+func renderWorkload(w io.Writer, r Renderer, wl *Workload) error {
+	if err := r.Workload(w, wl); err != nil {
+		return err
+	}
+
+	for _, issue := range wl.Issues {
+		if err := r.Issue(w, issue); err != nil {
+			return err
+		}
+
+		for _, pr := range issue.LinkedPRs {
+			io.WriteString(w, "  ") // Nested list
+			if err := r.PullRequest(w, pr); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Put all PRs that aren't linked to issues top-level
+	for _, pr := range wl.PullRequests {
+		if len(pr.LinkedIssues) == 0 {
+			if err := r.PullRequest(w, pr); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, pr := range wl.Reviews {
+		if err := r.Review(w, pr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Markdown renders the workloads with the default renderer.
+func (ws Workloads) Markdown() string {
+	md, _ := Render(markdownRenderer{}, ws) // Never fails
+	return md
+}
+
+// Markdown renders the section of the workload with the default renderer.
+func (wl *Workload) Markdown() string {
+	var b strings.Builder
+	_ = renderWorkload(&b, markdownRenderer{}, wl) // Never fails
+	return b.String()
+}
+
+// markdownRenderer is the default renderer.
+type markdownRenderer struct{}
+
+func (markdownRenderer) Header(w io.Writer, s *Summary) error {
+	if len(s.Overcommitted) > 0 {
+		io.WriteString(w, "\n⚠️ __Overcommitted__\n\n")
+		for _, wl := range s.Overcommitted {
+			fmt.Fprintf(w, "- @%s: __%s__ estimated, %s available\n", wl.Assignee, formatEstimate(wl.Load(), s.Unit), formatEstimate(wl.Available(), s.Unit))
+		}
+	}
+	return nil
+}
+
+func (markdownRenderer) Workload(w io.Writer, wl *Workload) error {
+	unit := wl.Unit
+	if unit == "" {
+		unit = defaultEstimates.Unit
+	}
+
+	var days string
+	if wl.Days > 0 || wl.HasCapacity || wl.ReviewDays > 0 {
+		days = fmt.Sprintf(": __%s__", formatEstimate(wl.Days, unit))
+	}
+
+	if wl.CarriedOver > 0 {
+		days += fmt.Sprintf(" (%s carried over)", formatEstimate(wl.CarriedOver, unit))
+	}
+
+	if wl.ReviewDays > 0 {
+		days += fmt.Sprintf(" + %s reviews", formatEstimate(wl.ReviewDays, unit))
+	}
+
+	if wl.HasCapacity {
+		days += fmt.Sprintf(" of %s", formatEstimate(wl.Available(), unit))
+	}
+
+	days += rotationsMarkdown(wl.Rotations, unit)
+
+	if wl.Overcommitted() {
+		days += " ⚠️"
+	}
+
+	_, err := fmt.Fprintf(w, "\n@%s%s\n\n", wl.Assignee, days)
+	return err
+}
+
+func (markdownRenderer) Issue(w io.Writer, issue *Issue) error {
+	_, err := io.WriteString(w, issue.Markdown())
+	return err
+}
+
+func (markdownRenderer) PullRequest(w io.Writer, pr *PullRequest) error {
+	_, err := io.WriteString(w, pr.Markdown())
+	return err
+}
+
+func (markdownRenderer) Review(w io.Writer, pr *PullRequest) error {
+	_, err := fmt.Fprintf(w, "- 👀 %s [%s](%s)\n", pr.title(), reference(pr.Repository, pr.Number, pr.qualified), pr.URL)
+	return err
+}
+
+func (markdownRenderer) Summary(w io.Writer, s *Summary) error {
+	if s.CarriedOver > 0 {
+		fmt.Fprintf(w, "\n↩️ __%s__ carried over from the previous milestone\n", formatEstimate(s.CarriedOver, s.Unit))
+	}
+
+	if len(s.Categories) > 0 {
+		io.WriteString(w, "\n📊 __Categories__\n\n")
+		for _, c := range s.Categories {
+			fmt.Fprintf(w, "- %s: __%s__ (%.0f%%)\n", c.Category, formatEstimate(c.Days, s.Unit), c.Percent)
+		}
+	}
+
+	writeIssues := func(title string, issues []*Issue) {
+		if len(issues) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "\n⚠️ __%s__\n\n", title)
+		for _, issue := range issues {
+			io.WriteString(w, issue.Markdown())
+		}
+	}
+
+	writeIssues("Unestimated", s.Unestimated)
+	writeIssues("Unassigned", s.Unassigned)
+	writeIssues("Stale", s.Stale)
+
+	cyclesMarkdown(w, s.Cycles)
+	completedMarkdown(w, s.Completed)
+
+	return nil
+}
+
+// templateRenderer renders the parts that are defined in its templates, and
+// falls back to the default renderer for the others. The templates are named
+// after the methods of Renderer, such as
+//
+//	{{define "issue"}}- [{{if .Closed}}x{{else}} {{end}}] {{.Title}} ([{{.Ref}}]({{.URL}})){{with .Estimate}} {{.}}{{end}}
+//	{{end}}
+//
+// "header" and "summary" are executed with a *Summary, "workload" with a
+// *Workload, and "issue", "pullRequest" and "review" with an IssueView or
+// PullRequestView.
+type templateRenderer struct {
+	tmpl     *template.Template
+	fallback Renderer
+}
+
+// IssueView is the data of the issue template.
+type IssueView struct {
+	*Issue
+	Title      string            // Redacted for private issues, and crossed off when deprioritised
+	Ref        string            // Such as #123
+	Estimate   string            // Such as 2d
+	Closed     bool              // Closed or done
+	Emojis     string            // Of the categories
+	Categories map[string]string // Emojis of the issue, keyed by category
+}
+
+// PullRequestView is the data of the pull request and review templates.
+type PullRequestView struct {
+	*PullRequest
+	Title      string // Redacted for private pull requests, and crossed off when closed
+	Ref        string
+	Merged     bool
+	Emojis     string
+	Categories map[string]string
+}
+
+// templateNames are the names of the templates a templateRenderer uses.
+var templateNames = []string{"header", "workload", "issue", "pullRequest", "review", "summary"}
+
+// parseTemplates parses the templates in the file at path.
+func parseTemplates(path string) (*templateRenderer, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New(path).Parse(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var defined bool
+	for _, name := range templateNames {
+		if tmpl.Lookup(name) != nil {
+			defined = true
+		}
+	}
+
+	if !defined {
+		return nil, fmt.Errorf("%s defines none of the templates %s", path, strings.Join(templateNames, ", "))
+	}
+
+	return &templateRenderer{tmpl: tmpl, fallback: markdownRenderer{}}, nil
+}
+
+func (r *templateRenderer) execute(w io.Writer, name string, data interface{}, fallback func() error) error {
+	t := r.tmpl.Lookup(name)
+	if t == nil {
+		return fallback()
+	}
+
+	if err := t.Execute(w, data); err != nil {
+		return fmt.Errorf("rendering %s template: %v", name, err)
+	}
+	return nil
+}
+
+func (r *templateRenderer) Header(w io.Writer, s *Summary) error {
+	return r.execute(w, "header", s, func() error { return r.fallback.Header(w, s) })
+}
+
+func (r *templateRenderer) Workload(w io.Writer, wl *Workload) error {
+	return r.execute(w, "workload", wl, func() error { return r.fallback.Workload(w, wl) })
+}
+
+func (r *templateRenderer) Issue(w io.Writer, issue *Issue) error {
+	return r.execute(w, "issue", issueView(issue), func() error { return r.fallback.Issue(w, issue) })
+}
+
+func (r *templateRenderer) PullRequest(w io.Writer, pr *PullRequest) error {
+	return r.execute(w, "pullRequest", pullRequestView(pr), func() error { return r.fallback.PullRequest(w, pr) })
+}
+
+func (r *templateRenderer) Review(w io.Writer, pr *PullRequest) error {
+	return r.execute(w, "review", pullRequestView(pr), func() error { return r.fallback.Review(w, pr) })
+}
+
+func (r *templateRenderer) Summary(w io.Writer, s *Summary) error {
+	return r.execute(w, "summary", s, func() error { return r.fallback.Summary(w, s) })
+}
+
+func issueView(issue *Issue) IssueView {
+	categories := issue.categories()
+	return IssueView{
+		Issue:      issue,
+		Title:      issue.title(),
+		Ref:        reference(issue.Repository, issue.Number, issue.qualified),
+		Estimate:   issue.Estimate(),
+		Closed:     issue.Closed(),
+		Emojis:     Emojis(categories),
+		Categories: categories,
+	}
+}
+
+func pullRequestView(pr *PullRequest) PullRequestView {
+	categories := pr.categories()
+	return PullRequestView{
+		PullRequest: pr,
+		Title:       pr.title(),
+		Ref:         reference(pr.Repository, pr.Number, pr.qualified),
+		Merged:      strings.EqualFold(pr.State, "merged"),
+		Emojis:      Emojis(categories),
+		Categories:  categories,
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTemplateRenderer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tracking-issue-templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(name, templates string) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(templates), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	path := write("ok.tmpl", `
+{{- define "workload"}}
+### {{.Assignee}} ({{printf "%.1f" .Days}} days)
+
+{{end -}}
+{{- define "issue"}}* {{if .Closed}}~{{.Title}}~{{else}}{{.Title}}{{end}} {{.Ref}}{{with .Estimate}} ({{.}}){{end}}{{if index .Categories "bug"}} :beetle:{{end}}
+{{end -}}
+{{- define "summary"}}
+{{len .Unestimated}} unestimated
+{{- end}}`)
+
+	r, err := parseTemplates(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ti := &TrackingIssue{
+		Issue: &Issue{Milestone: "3.14"},
+		Issues: []*Issue{
+			{Number: 1, Title: "a", State: "OPEN", Milestone: "3.14", Assignees: []string{"alice"}, Labels: []string{"estimate/1.5d", "bug"}},
+			{Number: 2, Title: "b", State: "CLOSED", Milestone: "3.14", Assignees: []string{"alice"}},
+		},
+		PRs: []*PullRequest{
+			{Number: 3, Title: "fix a", Body: "Fixes #1", State: "MERGED", Author: "alice"},
+		},
+	}
+
+	want := `
+### alice (1.5 days)
+
+* a #1 (1.5d) :beetle:
+  - [x] fix a [#3]() :shipit:
+* ~b~ #2
+
+0 unestimated`
+
+	have, err := Render(r, ti.Workloads())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have != want {
+		t.Errorf("have:\n%s\nwant:\n%s", have, want)
+	}
+
+	if _, err := parseTemplates(write("none.tmpl", `{{define "other"}}{{end}}`)); err == nil || !strings.Contains(err.Error(), "defines none of the templates") {
+		t.Errorf("templates without known names: have error %v", err)
+	}
+
+	r, err = parseTemplates(write("broken.tmpl", `{{define "header"}}{{.Missing}}{{end}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Render(r, ti.Workloads()); err == nil || !strings.Contains(err.Error(), "rendering header template") {
+		t.Errorf("broken template: have error %v", err)
+	}
+}
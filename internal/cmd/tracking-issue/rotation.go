@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Rotation is a period in which assignees are on a team rotation, such as
+// on-call or support, and less available for planned work.
+type Rotation struct {
+	// Name of the rotation, such as on-call. Workload headers are annotated
+	// with it.
+	Name string `yaml:"name"`
+
+	// Assignees on the rotation, by login.
+	Assignees []string `yaml:"assignees"`
+
+	// Start and End are the first and last days of the rotation, such as
+	// 2020-06-08.
+	Start string `yaml:"start"`
+	End   string `yaml:"end"`
+
+	// Milestone is the milestone the rotation falls into. Only the capacity of
+	// the tracking issues of that milestone is reduced.
+	Milestone string `yaml:"milestone"`
+
+	// Days, if positive, is subtracted from the capacity of the assignees
+	// instead of the number of weekdays from Start to End. It is in the unit
+	// of the estimates, and required if that isn't days.
+	Days float64 `yaml:"days"`
+
+	start, end time.Time
+}
+
+// init validates the rotation of a definition whose estimates are in unit.
+func (r *Rotation) init(unit string) (err error) {
+	if r.Name == "" {
+		return fmt.Errorf("no name given")
+	}
+
+	if len(r.Assignees) == 0 {
+		return fmt.Errorf("%s: no assignees given", r.Name)
+	}
+
+	if r.start, err = time.Parse("2006-01-02", r.Start); err != nil {
+		return fmt.Errorf("%s: invalid start %q, want a date such as 2020-06-08", r.Name, r.Start)
+	}
+
+	if r.end, err = time.Parse("2006-01-02", r.End); err != nil {
+		return fmt.Errorf("%s: invalid end %q, want a date such as 2020-06-12", r.Name, r.End)
+	}
+
+	if r.end.Before(r.start) {
+		return fmt.Errorf("%s: ends before it starts", r.Name)
+	}
+
+	if r.Milestone == "" {
+		return fmt.Errorf("%s: no milestone given", r.Name)
+	}
+
+	if r.Days < 0 {
+		return fmt.Errorf("%s: negative days", r.Name)
+	}
+
+	// Weekdays can't be subtracted from a capacity in points.
+	if r.Days == 0 && unit != defaultEstimates.Unit {
+		return fmt.Errorf("%s: no days given, which is required because estimates are in %q", r.Name, unit)
+	}
+
+	return nil
+}
+
+// applies reports whether the rotation reduces the capacity of the assignee in
+// the milestone.
+func (r *Rotation) applies(assignee, milestone string) bool {
+	return r.Milestone == milestone && has(assignee, r.Assignees)
+}
+
+// offDays returns the capacity the rotation takes from each of its assignees.
+func (r *Rotation) offDays() float64 {
+	if r.Days > 0 {
+		return r.Days
+	}
+
+	var weekdays float64
+	for d := r.start; !d.After(r.end); d = d.AddDate(0, 0, 1) {
+		if wd := d.Weekday(); wd != time.Saturday && wd != time.Sunday {
+			weekdays++
+		}
+	}
+	return weekdays
+}
+
+// rotationsMarkdown annotates a workload header with the rotations of the
+// assignee, such as " (5.00d on-call)".
+func rotationsMarkdown(rotations []*Rotation, unit string) string {
+	if len(rotations) == 0 {
+		return ""
+	}
+
+	parts := make([]string, 0, len(rotations))
+	for _, r := range rotations {
+		parts = append(parts, formatEstimate(r.offDays(), unit)+" "+r.Name)
+	}
+
+	return " (" + strings.Join(parts, ", ") + ")"
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRotationInit(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		r    Rotation
+		unit string
+		err  string
+	}{
+		{
+			name: "valid",
+			r:    Rotation{Name: "on-call", Assignees: []string{"kzh"}, Start: "2020-06-08", End: "2020-06-12", Milestone: "3.17"},
+		},
+		{
+			name: "no name",
+			r:    Rotation{Assignees: []string{"kzh"}, Start: "2020-06-08", End: "2020-06-12", Milestone: "3.17"},
+			err:  "no name given",
+		},
+		{
+			name: "no assignees",
+			r:    Rotation{Name: "on-call", Start: "2020-06-08", End: "2020-06-12", Milestone: "3.17"},
+			err:  "on-call: no assignees given",
+		},
+		{
+			name: "invalid start",
+			r:    Rotation{Name: "on-call", Assignees: []string{"kzh"}, Start: "June 8", End: "2020-06-12", Milestone: "3.17"},
+			err:  `on-call: invalid start "June 8", want a date such as 2020-06-08`,
+		},
+		{
+			name: "ends before start",
+			r:    Rotation{Name: "on-call", Assignees: []string{"kzh"}, Start: "2020-06-12", End: "2020-06-08", Milestone: "3.17"},
+			err:  "on-call: ends before it starts",
+		},
+		{
+			name: "no milestone",
+			r:    Rotation{Name: "on-call", Assignees: []string{"kzh"}, Start: "2020-06-08", End: "2020-06-12"},
+			err:  "on-call: no milestone given",
+		},
+		{
+			name: "points",
+			r:    Rotation{Name: "on-call", Assignees: []string{"kzh"}, Start: "2020-06-08", End: "2020-06-12", Milestone: "3.17"},
+			unit: "pt",
+			err:  `on-call: no days given, which is required because estimates are in "pt"`,
+		},
+		{
+			name: "points with days",
+			r:    Rotation{Name: "on-call", Assignees: []string{"kzh"}, Start: "2020-06-08", End: "2020-06-12", Milestone: "3.17", Days: 3},
+			unit: "pt",
+		},
+		{
+			name: "negative days",
+			r:    Rotation{Name: "on-call", Assignees: []string{"kzh"}, Start: "2020-06-08", End: "2020-06-12", Milestone: "3.17", Days: -1},
+			err:  "on-call: negative days",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.unit == "" {
+				tc.unit = "d"
+			}
+
+			var have string
+			if err := tc.r.init(tc.unit); err != nil {
+				have = err.Error()
+			}
+			if have != tc.err {
+				t.Errorf("error: have %q, want %q", have, tc.err)
+			}
+		})
+	}
+}
+
+func TestRotationOffDays(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		r    Rotation
+		want float64
+	}{
+		{name: "week", r: Rotation{Start: "2020-06-08", End: "2020-06-12"}, want: 5},
+		{name: "over a weekend", r: Rotation{Start: "2020-06-12", End: "2020-06-15"}, want: 2},
+		{name: "weekend", r: Rotation{Start: "2020-06-13", End: "2020-06-14"}, want: 0},
+		{name: "days", r: Rotation{Start: "2020-06-08", End: "2020-06-12", Days: 2.5}, want: 2.5},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.r.Name, tc.r.Assignees, tc.r.Milestone = "on-call", []string{"kzh"}, "3.17"
+			if err := tc.r.init("d"); err != nil {
+				t.Fatal(err)
+			}
+			if have := tc.r.offDays(); have != tc.want {
+				t.Errorf("offDays: have %v, want %v", have, tc.want)
+			}
+		})
+	}
+}
+
+func TestWorkloadRotations(t *testing.T) {
+	rotations := []*Rotation{
+		{Name: "on-call", Assignees: []string{"alice"}, Start: "2020-06-08", End: "2020-06-12", Milestone: "3.17"},
+		{Name: "support", Assignees: []string{"alice", "bob"}, Start: "2020-06-15", End: "2020-06-15", Milestone: "3.17"},
+		{Name: "release", Assignees: []string{"alice"}, Start: "2020-06-16", End: "2020-06-16", Milestone: "3.18"},
+	}
+	for _, r := range rotations {
+		if err := r.init("d"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ti := &TrackingIssue{
+		Issue: &Issue{Milestone: "3.17"},
+		Issues: []*Issue{
+			{Number: 1, State: "OPEN", Milestone: "3.17", Assignees: []string{"alice"}, Labels: []string{"estimate/4d"}},
+			{Number: 2, State: "OPEN", Milestone: "3.17", Assignees: []string{"bob"}, Labels: []string{"estimate/4d"}},
+		},
+		Config: &IssueConfig{
+			Org:       "sourcegraph",
+			Capacity:  map[string]float64{"alice": 8, "bob": 8},
+			Rotations: rotations,
+		},
+	}
+
+	workloads := ti.Workloads()
+
+	for _, tc := range []struct {
+		assignee      string
+		available     float64
+		overcommitted bool
+		header        string
+	}{
+		{"alice", 2, true, "\n@alice: __4.00d__ of 2.00d (5.00d on-call, 1.00d support) ⚠️\n"},
+		{"bob", 7, false, "\n@bob: __4.00d__ of 7.00d (1.00d support)\n"},
+	} {
+		wl := workloads.ByAssignee[tc.assignee]
+		if have := wl.Available(); have != tc.available {
+			t.Errorf("%s: available: have %v, want %v", tc.assignee, have, tc.available)
+		}
+		if have := wl.Overcommitted(); have != tc.overcommitted {
+			t.Errorf("%s: overcommitted: have %v, want %v", tc.assignee, have, tc.overcommitted)
+		}
+		if md := workloads.Markdown(); !strings.Contains(md, tc.header) {
+			t.Errorf("Markdown doesn't contain %q:\n%s", tc.header, md)
+		}
+	}
+}
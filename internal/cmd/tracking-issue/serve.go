@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// runServer implements the serve subcommand. It listens for GitHub webhook
+// events about issues and pull requests and updates the affected tracking
+// issues shortly after, instead of waiting for the next scheduled run.
+func runServer(cfg *Config, opts options, addr, secret string, debounce time.Duration) error {
+	ctx := context.Background()
+	if opts.Provider != "" && opts.Provider != "github" {
+		return fmt.Errorf("serve only supports GitHub webhook events, not -provider=%s", opts.Provider)
+	}
+
+	// Anyone who can reach the server could otherwise trigger updates.
+	if secret == "" {
+		return fmt.Errorf("serve requires -webhook-secret, to verify the signatures of webhook events")
+	}
+
+	p, err := newProvider(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	s := &webhookServer{
+		secret:   []byte(secret),
+		debounce: debounce,
+		refresh: func(ctx context.Context, events []webhookEvent) error {
+			return refresh(ctx, p, cfg, opts, events)
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", s)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	srv := &http.Server{
+		Addr:         addr,
+		Handler:      mux,
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+
+	log.Printf("Listening for GitHub webhook events on %s.", addr)
+	return srv.ListenAndServe()
+}
+
+// refresh updates the tracking issues affected by the events.
+func refresh(ctx context.Context, p Provider, cfg *Config, opts options, events []webhookEvent) error {
+	// Only list the tracking issues of the definitions that track work in
+	// the orgs of the events.
+	affectedCfg := &Config{}
+	for _, ic := range cfg.Issues {
+		for _, e := range events {
+			if ic.TracksOrg(e.Org) {
+				affectedCfg.Issues = append(affectedCfg.Issues, ic)
+				break
+			}
+		}
+	}
+
+	if len(affectedCfg.Issues) == 0 {
+		return nil
+	}
+
+	tracking, err := p.Load(ctx, affectedCfg, func(t *TrackingIssue) bool {
+		return affected(t, events)
+	})
+	if err != nil || len(tracking) == 0 {
+		return err
+	}
+
+	return updateTracking(ctx, p, tracking, opts)
+}
+
+// webhookEvent is the part of a GitHub issues or pull_request webhook event
+// that determines the affected tracking issues.
+type webhookEvent struct {
+	Org      string
+	Labels   []string
+	Tracking bool // The event is about a tracking issue.
+}
+
+// affected reports whether the work of the tracking issue may have changed
+// because of one of the events. The work of a tracking issue is searched by
+// its labels, so an event about an item with all of them affects it. The
+// labels of an event include the label it removed, if any, so that the
+// tracking issue the item left is updated too. Since the item's previous
+// milestone is unknown, this errs on the side of updating.
+func affected(t *TrackingIssue, events []webhookEvent) bool {
+	for _, e := range events {
+		if e.Tracking {
+			if strings.EqualFold(e.Org, t.Config.Org) {
+				return true
+			}
+			continue
+		}
+
+		if !t.Config.TracksOrg(e.Org) {
+			continue
+		}
+
+		matches := true
+		for _, label := range t.Labels {
+			if label != "tracking" && !has(label, e.Labels) {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// webhookServer handles GitHub webhook events. Events that arrive within the
+// debounce duration of each other are handled together.
+type webhookServer struct {
+	secret   []byte
+	debounce time.Duration
+	refresh  func(context.Context, []webhookEvent) error
+
+	mu      sync.Mutex
+	pending []webhookEvent
+	timer   *time.Timer
+
+	// refreshing serializes refreshes, so that a slow refresh doesn't race
+	// with the next one.
+	refreshing sync.Mutex
+}
+
+// webhookActions are the actions of issues and pull_request events that can
+// change the work of a tracking issue.
+var webhookActions = map[string]bool{
+	"opened":                 true,
+	"edited":                 true,
+	"deleted":                true,
+	"closed":                 true,
+	"reopened":               true,
+	"assigned":               true,
+	"unassigned":             true,
+	"labeled":                true,
+	"unlabeled":              true,
+	"milestoned":             true,
+	"demilestoned":           true,
+	"transferred":            true,
+	"review_requested":       true,
+	"review_request_removed": true,
+}
+
+func (s *webhookServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, 25<<20))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !s.verify(r.Header, body) {
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return
+	}
+
+	kind := r.Header.Get("X-GitHub-Event")
+	if kind != "issues" && kind != "pull_request" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	var payload struct {
+		Action string
+		Label  *struct{ Name string }
+		Issue  *struct {
+			Labels []struct{ Name string }
+		}
+		PullRequest *struct {
+			Labels []struct{ Name string }
+		} `json:"pull_request"`
+		Repository struct {
+			Owner struct{ Login string }
+		}
+	}
+
+	if err := json.Unmarshal(body, &payload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !webhookActions[payload.Action] {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	e := webhookEvent{Org: payload.Repository.Owner.Login}
+
+	labels := payload.PullRequest
+	if payload.Issue != nil {
+		labels = payload.Issue
+	}
+
+	if labels != nil {
+		for _, label := range labels.Labels {
+			e.Labels = append(e.Labels, label.Name)
+		}
+	}
+
+	// The label of a labeled or unlabeled event. The item no longer has a
+	// removed label, but the tracking issue it left needs an update.
+	if payload.Label != nil && !has(payload.Label.Name, e.Labels) {
+		e.Labels = append(e.Labels, payload.Label.Name)
+	}
+
+	e.Tracking = kind == "issues" && has("tracking", e.Labels)
+
+	// Our own updates of tracking issue bodies would otherwise trigger
+	// another update.
+	if e.Tracking && payload.Action == "edited" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	s.enqueue(e)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// verify checks the signature of the event body. Without a secret, no
+// signature is valid.
+func (s *webhookServer) verify(h http.Header, body []byte) bool {
+	if len(s.secret) == 0 {
+		return false
+	}
+
+	var (
+		newHash   func() hash.Hash
+		signature string
+	)
+
+	if sig := h.Get("X-Hub-Signature-256"); sig != "" {
+		newHash, signature = sha256.New, strings.TrimPrefix(sig, "sha256=")
+	} else if sig := h.Get("X-Hub-Signature"); sig != "" {
+		newHash, signature = sha1.New, strings.TrimPrefix(sig, "sha1=")
+	} else {
+		return false
+	}
+
+	want, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(newHash, s.secret)
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), want)
+}
+
+func (s *webhookServer) enqueue(e webhookEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pending = append(s.pending, e)
+	if s.timer == nil {
+		s.timer = time.AfterFunc(s.debounce, s.flush)
+	}
+}
+
+func (s *webhookServer) flush() {
+	s.refreshing.Lock()
+	defer s.refreshing.Unlock()
+
+	s.mu.Lock()
+	events := s.pending
+	s.pending, s.timer = nil, nil
+	s.mu.Unlock()
+
+	if len(events) == 0 {
+		return
+	}
+
+	if err := s.refresh(context.Background(), events); err != nil {
+		log.Printf("failed to update tracking issues after %d webhook events: %v", len(events), err)
+	}
+}
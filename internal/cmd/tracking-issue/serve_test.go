@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookServer(t *testing.T) {
+	refreshed := make(chan []webhookEvent, 1)
+	s := &webhookServer{
+		secret:   []byte("secret"),
+		debounce: 10 * time.Millisecond,
+		refresh: func(_ context.Context, events []webhookEvent) error {
+			refreshed <- events
+			return nil
+		},
+	}
+
+	send := func(kind, body string, sign bool) int {
+		req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		req.Header.Set("X-GitHub-Event", kind)
+		if sign {
+			mac := hmac.New(sha256.New, s.secret)
+			mac.Write([]byte(body))
+			req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		}
+
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	const (
+		labeled   = `{"action": "labeled", "label": {"name": "team/search"}, "issue": {"labels": [{"name": "team/search"}]}, "repository": {"owner": {"login": "sourcegraph"}}}`
+		unlabeled = `{"action": "unlabeled", "label": {"name": "team/code-intel"}, "issue": {"labels": [{"name": "bug"}]}, "repository": {"owner": {"login": "sourcegraph"}}}`
+		merged    = `{"action": "closed", "pull_request": {"labels": [{"name": "bug"}]}, "repository": {"owner": {"login": "sourcegraph"}}}`
+		edited    = `{"action": "edited", "issue": {"labels": [{"name": "tracking"}]}, "repository": {"owner": {"login": "sourcegraph"}}}`
+	)
+
+	for _, tc := range []struct {
+		kind, body string
+		sign       bool
+		want       int
+	}{
+		{"issues", labeled, false, http.StatusUnauthorized},
+		{"issues", labeled, true, http.StatusAccepted},
+		{"issues", unlabeled, true, http.StatusAccepted},
+		{"pull_request", merged, true, http.StatusAccepted},
+		{"issues", edited, true, http.StatusNoContent},
+		{"push", `{}`, true, http.StatusNoContent},
+	} {
+		if have := send(tc.kind, tc.body, tc.sign); have != tc.want {
+			t.Errorf("%s %s: have status %d, want %d", tc.kind, tc.body, have, tc.want)
+		}
+	}
+
+	want := []webhookEvent{
+		{Org: "sourcegraph", Labels: []string{"team/search"}},
+		{Org: "sourcegraph", Labels: []string{"bug", "team/code-intel"}},
+		{Org: "sourcegraph", Labels: []string{"bug"}},
+	}
+
+	select {
+	case events := <-refreshed:
+		if !reflect.DeepEqual(events, want) {
+			t.Errorf("events: have %+v, want %+v", events, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for refresh")
+	}
+}
+
+func TestWebhookServerVerify(t *testing.T) {
+	body := []byte(`{"action": "opened"}`)
+	sign := func(secret string) http.Header {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		return http.Header{"X-Hub-Signature-256": []string{"sha256=" + hex.EncodeToString(mac.Sum(nil))}}
+	}
+
+	for _, tc := range []struct {
+		name   string
+		secret string
+		header http.Header
+		want   bool
+	}{
+		{"valid", "secret", sign("secret"), true},
+		{"other secret", "secret", sign("other"), false},
+		{"unsigned", "secret", http.Header{}, false},
+		{"no secret", "", sign(""), false},
+	} {
+		s := &webhookServer{secret: []byte(tc.secret)}
+		if have := s.verify(tc.header, body); have != tc.want {
+			t.Errorf("%s: have %t, want %t", tc.name, have, tc.want)
+		}
+	}
+}
+
+func TestAffected(t *testing.T) {
+	ti := &TrackingIssue{
+		Issue:  &Issue{Labels: []string{"tracking", "team/search"}},
+		Config: &IssueConfig{Org: "sourcegraph", Repos: []string{"acme/enterprise"}},
+	}
+
+	for _, tc := range []struct {
+		name  string
+		event webhookEvent
+		want  bool
+	}{
+		{"matching labels", webhookEvent{Org: "sourcegraph", Labels: []string{"team/search", "bug"}}, true},
+		{"other org", webhookEvent{Org: "other", Labels: []string{"team/search"}}, false},
+		{"missing label", webhookEvent{Org: "sourcegraph", Labels: []string{"bug"}}, false},
+		{"tracking issue", webhookEvent{Org: "Sourcegraph", Tracking: true}, true},
+		{"aggregated org", webhookEvent{Org: "acme", Labels: []string{"team/search"}}, true},
+		{"tracking issue in aggregated org", webhookEvent{Org: "acme", Tracking: true}, false},
+	} {
+		if have := affected(ti, []webhookEvent{tc.event}); have != tc.want {
+			t.Errorf("%s: have %t, want %t", tc.name, have, tc.want)
+		}
+	}
+}
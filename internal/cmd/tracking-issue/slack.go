@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// SlackSummary returns a short summary of the tracking issue in Slack's
+// markup, with the issues that weren't listed in its previous work section.
+func SlackSummary(t *TrackingIssue, added []*Issue) string {
+	workloads := t.Workloads().ByAssignee
+
+	assignees := make([]string, 0, len(workloads))
+	for assignee := range workloads {
+		assignees = append(assignees, assignee)
+	}
+
+	sort.Strings(assignees)
+
+	var (
+		total, remaining float64
+		overcommitted    []string
+		unit             = defaultEstimates.Unit
+	)
+
+	if t.Config != nil {
+		unit = t.Config.Estimates.unit()
+	}
+
+	for _, assignee := range assignees {
+		wl := workloads[assignee]
+		total += wl.Days
+		remaining += wl.Remaining()
+		if wl.Overcommitted() {
+			overcommitted = append(overcommitted, fmt.Sprintf("%s (%s of %s)", wl.Assignee, formatEstimate(wl.Load(), unit), formatEstimate(wl.Available(), unit)))
+		}
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "*<%s|%s>*: %s estimated, %s remaining", t.URL, slackEscape(t.Title), formatEstimate(total, unit), formatEstimate(remaining, unit))
+
+	if len(overcommitted) > 0 {
+		fmt.Fprintf(&b, "\n:warning: Overcommitted: %s", slackEscape(strings.Join(overcommitted, ", ")))
+	}
+
+	if len(added) > 0 {
+		links := make([]string, 0, len(added))
+		for _, issue := range added {
+			title := exportedTitle(issue.Title, issue.Repository, issue.Private)
+			links = append(links, fmt.Sprintf("<%s|#%d %s>", issue.URL, issue.Number, slackEscape(title)))
+		}
+		fmt.Fprintf(&b, "\nAdded: %s", strings.Join(links, ", "))
+	}
+
+	return b.String()
+}
+
+// addedIssues returns the issues of the tracking issue that aren't linked in
+// its previous work section.
+func addedIssues(t *TrackingIssue, previousWork string) (added []*Issue) {
+	listed := listedIssueURLs(previousWork)
+	for _, issue := range t.Issues {
+		if !listed[issue.URL] {
+			added = append(added, issue)
+		}
+	}
+	return added
+}
+
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func slackEscape(s string) string {
+	return slackEscaper.Replace(s)
+}
+
+// postSlack posts a message to a Slack incoming webhook.
+func postSlack(ctx context.Context, webhookURL, text string) error {
+	payload, err := json.Marshal(struct {
+		Text        string `json:"text"`
+		UnfurlLinks bool   `json:"unfurl_links"`
+	}{Text: text})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("posting Slack summary: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("posting Slack summary: %s: %s", resp.Status, body)
+	}
+
+	return nil
+}
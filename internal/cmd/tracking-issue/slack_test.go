@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSlackSummary(t *testing.T) {
+	ti := &TrackingIssue{
+		Issue: &Issue{Title: "Search: 3.14 <tracking>", URL: "https://github.com/sourcegraph/sourcegraph/issues/10", Milestone: "3.14"},
+		Issues: []*Issue{
+			{Number: 1, Title: "a", URL: "https://github.com/sourcegraph/sourcegraph/issues/1", State: "CLOSED", Milestone: "3.14", Assignees: []string{"alice"}, Labels: []string{"estimate/2d"}},
+			{Number: 2, Title: "b & c", URL: "https://github.com/sourcegraph/sourcegraph/issues/2", State: "OPEN", Milestone: "3.14", Assignees: []string{"alice"}, Labels: []string{"estimate/3d"}},
+		},
+		Config: &IssueConfig{Capacity: map[string]float64{"alice": 4}},
+	}
+
+	previous := "- [x] a [#1](https://github.com/sourcegraph/sourcegraph/issues/1) __2d__ \n"
+
+	want := "*<https://github.com/sourcegraph/sourcegraph/issues/10|Search: 3.14 &lt;tracking&gt;>*: 5.00d estimated, 3.00d remaining\n" +
+		":warning: Overcommitted: alice (5.00d of 4.00d)\n" +
+		"Added: <https://github.com/sourcegraph/sourcegraph/issues/2|#2 b &amp; c>"
+
+	if have := SlackSummary(ti, addedIssues(ti, previous)); have != want {
+		t.Errorf("have:\n%s\nwant:\n%s", have, want)
+	}
+}
+
+func TestPostSlack(t *testing.T) {
+	var text string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct{ Text string }
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Error(err)
+		}
+		text = payload.Text
+	}))
+	defer srv.Close()
+
+	if err := postSlack(context.Background(), srv.URL, "hello"); err != nil {
+		t.Fatal(err)
+	}
+
+	if text != "hello" {
+		t.Errorf("have text %q, want %q", text, "hello")
+	}
+}
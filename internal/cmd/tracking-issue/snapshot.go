@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Snapshot records the estimates of a tracking issue at a point in time.
+// Snapshots are appended to a file per tracking issue in the -snapshot-dir, one
+// JSON object per line.
+type Snapshot struct {
+	Time          time.Time          `json:"time"`
+	TrackingIssue string             `json:"trackingIssue"` // URL of the tracking issue
+	Milestone     string             `json:"milestone,omitempty"`
+	Unit          string             `json:"unit,omitempty"` // Of the estimates, such as d
+	Total         float64            `json:"total"`
+	Remaining     float64            `json:"remaining"`
+	Assignees     []AssigneeSnapshot `json:"assignees"`
+}
+
+type AssigneeSnapshot struct {
+	Assignee  string  `json:"assignee"`
+	Total     float64 `json:"total"`
+	Remaining float64 `json:"remaining"`
+}
+
+// NewSnapshot returns a snapshot of the current estimates of the tracking
+// issue.
+func NewSnapshot(t *TrackingIssue, now time.Time) Snapshot {
+	s := Snapshot{
+		Time:          now.UTC(),
+		TrackingIssue: t.URL,
+		Milestone:     t.Milestone,
+		Unit:          defaultEstimates.Unit,
+		Assignees:     []AssigneeSnapshot{},
+	}
+
+	if t.Config != nil {
+		s.Unit = t.Config.Estimates.unit()
+	}
+
+	workloads := t.Workloads().ByAssignee
+
+	assignees := make([]string, 0, len(workloads))
+	for assignee := range workloads {
+		assignees = append(assignees, assignee)
+	}
+
+	sort.Strings(assignees)
+
+	for _, assignee := range assignees {
+		wl := workloads[assignee]
+		a := AssigneeSnapshot{
+			Assignee:  assignee,
+			Total:     wl.Days,
+			Remaining: wl.Remaining(),
+		}
+
+		s.Total += a.Total
+		s.Remaining += a.Remaining
+		s.Assignees = append(s.Assignees, a)
+	}
+
+	return s
+}
+
+var unsafeFileNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// snapshotFile returns the name of the file in the snapshot directory that the
+// snapshots of the tracking issue are appended to.
+func snapshotFile(t *TrackingIssue) string {
+	repo := t.Repository
+	if repo == "" && t.Config != nil {
+		repo = t.Config.Org
+	}
+	name := fmt.Sprintf("%s-%d", repo, t.Number)
+	return unsafeFileNameChars.ReplaceAllString(name, "_") + ".jsonl"
+}
+
+// writeSnapshots appends a snapshot of each tracking issue to its file in dir.
+func writeSnapshots(dir string, now time.Time, issues []*TrackingIssue) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	for _, issue := range issues {
+		b, err := json.Marshal(NewSnapshot(issue, now))
+		if err != nil {
+			return err
+		}
+
+		path := filepath.Join(dir, snapshotFile(issue))
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+
+		_, err = f.Write(append(b, '\n'))
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+
+		if err != nil {
+			return fmt.Errorf("writing snapshot to %s: %v", path, err)
+		}
+	}
+
+	return nil
+}
+
+// readSnapshots reads the snapshots of all tracking issues in dir.
+func readSnapshots(dir string) ([]Snapshot, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []Snapshot
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+
+		sc := bufio.NewScanner(f)
+		sc.Buffer(nil, 1<<20)
+		for line := 1; sc.Scan(); line++ {
+			if len(strings.TrimSpace(sc.Text())) == 0 {
+				continue
+			}
+
+			var s Snapshot
+			if err := json.Unmarshal(sc.Bytes(), &s); err != nil {
+				f.Close()
+				return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+			}
+			snapshots = append(snapshots, s)
+		}
+
+		err = sc.Err()
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return snapshots, nil
+}
+
+// BurndownDay is the total and remaining estimate of a milestone on a day.
+type BurndownDay struct {
+	Date      string
+	Unit      string // Of the estimates, such as d
+	Total     float64
+	Remaining float64
+}
+
+// unit returns the unit of the estimates of the snapshot. Snapshots written
+// before the unit was recorded are in days.
+func (s Snapshot) unit() string {
+	if s.Unit == "" {
+		return defaultEstimates.Unit
+	}
+	return s.Unit
+}
+
+// Burndown returns the estimates of the milestone for each day with snapshots,
+// in order. The estimates of a day are the sums of the last snapshot of that
+// day of each tracking issue of the milestone. An error is returned if the
+// snapshots of the milestone are in different units, since they can't be
+// summed.
+func Burndown(snapshots []Snapshot, milestone string) ([]BurndownDay, error) {
+	var unit string
+	// last[date][tracking issue] is the last snapshot of the tracking issue on
+	// that day.
+	last := map[string]map[string]Snapshot{}
+	for _, s := range snapshots {
+		if s.Milestone != milestone {
+			continue
+		}
+
+		if unit == "" {
+			unit = s.unit()
+		} else if s.unit() != unit {
+			return nil, fmt.Errorf("snapshots of milestone %q have estimates in both %q and %q", milestone, unit, s.unit())
+		}
+
+		date := s.Time.UTC().Format("2006-01-02")
+		if last[date] == nil {
+			last[date] = map[string]Snapshot{}
+		}
+
+		if prev, ok := last[date][s.TrackingIssue]; !ok || !s.Time.Before(prev.Time) {
+			last[date][s.TrackingIssue] = s
+		}
+	}
+
+	days := make([]BurndownDay, 0, len(last))
+	for date, issues := range last {
+		day := BurndownDay{Date: date, Unit: unit}
+		for _, s := range issues {
+			day.Total += s.Total
+			day.Remaining += s.Remaining
+		}
+		days = append(days, day)
+	}
+
+	sort.Slice(days, func(i, j int) bool { return days[i].Date < days[j].Date })
+	return days, nil
+}
+
+// BurndownMarkdown renders the burndown as a Markdown table with a bar chart of
+// the remaining estimate.
+func BurndownMarkdown(milestone string, days []BurndownDay) string {
+	const width = 30
+
+	var max float64
+	for _, d := range days {
+		if d.Total > max {
+			max = d.Total
+		}
+		if d.Remaining > max {
+			max = d.Remaining
+		}
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "### Burndown of %s\n\n", milestone)
+	b.WriteString("| Date | Total | Remaining | |\n")
+	b.WriteString("|------|------:|----------:|-|\n")
+
+	for _, d := range days {
+		var bar string
+		if max > 0 {
+			bar = strings.Repeat("█", int(d.Remaining/max*width+0.5))
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | `%s` |\n", d.Date, formatEstimate(d.Total, d.Unit), formatEstimate(d.Remaining, d.Unit), bar)
+	}
+
+	return b.String()
+}
+
+// burndown implements the burndown subcommand, which prints the burndown of a
+// milestone from the snapshots written by -snapshot-dir.
+func burndown(args []string) error {
+	fs := flag.NewFlagSet("burndown", flag.ExitOnError)
+	dir := fs.String("snapshot-dir", "", "Directory with the snapshots written by the -snapshot-dir option")
+	milestone := fs.String("milestone", "", "Milestone to render the burndown of")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *dir == "" {
+		return fmt.Errorf("no -snapshot-dir given")
+	}
+
+	if *milestone == "" {
+		return fmt.Errorf("no -milestone given")
+	}
+
+	snapshots, err := readSnapshots(*dir)
+	if err != nil {
+		return err
+	}
+
+	days, err := Burndown(snapshots, *milestone)
+	if err != nil {
+		return err
+	}
+
+	if len(days) == 0 {
+		return fmt.Errorf("no snapshots of milestone %q in %s", *milestone, *dir)
+	}
+
+	_, err = io.WriteString(os.Stdout, BurndownMarkdown(*milestone, days))
+	return err
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnapshotsBurndown(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tracking-issue-snapshots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	newTrackingIssue := func(number int, closed ...bool) *TrackingIssue {
+		ti := &TrackingIssue{Issue: &Issue{
+			Number:     number,
+			URL:        fmt.Sprintf("https://github.com/sourcegraph/sourcegraph/issues/%d", number),
+			Repository: "sourcegraph/sourcegraph",
+			Milestone:  "3.14",
+		}}
+		for i, c := range closed {
+			state := "OPEN"
+			if c {
+				state = "CLOSED"
+			}
+			ti.Issues = append(ti.Issues, &Issue{
+				Number:    100*number + i,
+				State:     state,
+				Milestone: "3.14",
+				Assignees: []string{"alice"},
+				Labels:    []string{"estimate/2d"},
+			})
+		}
+		return ti
+	}
+
+	day1 := time.Date(2020, 3, 2, 9, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+
+	runs := []struct {
+		now    time.Time
+		issues []*TrackingIssue
+	}{
+		{day1, []*TrackingIssue{newTrackingIssue(1, false, false), newTrackingIssue(2, false)}},
+		{day1.Add(time.Hour), []*TrackingIssue{newTrackingIssue(1, true, false), newTrackingIssue(2, false)}},
+		{day2, []*TrackingIssue{newTrackingIssue(1, true, true), newTrackingIssue(2, false)}},
+	}
+
+	for _, r := range runs {
+		if err := writeSnapshots(dir, r.now, r.issues); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snapshots, err := readSnapshots(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have, want := len(snapshots), 6; have != want {
+		t.Fatalf("snapshots: have %d, want %d", have, want)
+	}
+
+	days, err := Burndown(snapshots, "3.14")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []BurndownDay{
+		{Date: "2020-03-02", Unit: "d", Total: 6, Remaining: 4},
+		{Date: "2020-03-03", Unit: "d", Total: 6, Remaining: 2},
+	}
+
+	if !reflect.DeepEqual(days, want) {
+		t.Fatalf("have %+v, want %+v", days, want)
+	}
+
+	if days, err := Burndown(snapshots, "3.15"); err != nil || len(days) != 0 {
+		t.Errorf("other milestone: have %+v, %v, want none", days, err)
+	}
+
+	wantMarkdown := "### Burndown of 3.14\n\n" +
+		"| Date | Total | Remaining | |\n" +
+		"|------|------:|----------:|-|\n" +
+		"| 2020-03-02 | 6.00d | 4.00d | `████████████████████` |\n" +
+		"| 2020-03-03 | 6.00d | 2.00d | `██████████` |\n"
+
+	if have := BurndownMarkdown("3.14", days); have != wantMarkdown {
+		t.Errorf("have:\n%s\nwant:\n%s", have, wantMarkdown)
+	}
+}
+
+func TestBurndownUnits(t *testing.T) {
+	day := time.Date(2020, 3, 2, 9, 0, 0, 0, time.UTC)
+	snapshots := []Snapshot{
+		{Time: day, TrackingIssue: "1", Milestone: "3.14", Unit: "pt", Total: 8, Remaining: 5},
+		{Time: day, TrackingIssue: "2", Milestone: "3.14", Unit: "pt", Total: 3, Remaining: 3},
+		{Time: day, TrackingIssue: "3", Milestone: "3.15", Total: 2, Remaining: 1},
+		{Time: day, TrackingIssue: "4", Milestone: "3.15", Unit: "pt", Total: 2, Remaining: 1},
+	}
+
+	days, err := Burndown(snapshots, "3.14")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "| 2020-03-02 | 11.00pt | 8.00pt | `" + strings.Repeat("█", 22) + "` |\n"
+	if have := BurndownMarkdown("3.14", days); !strings.Contains(have, want) {
+		t.Errorf("Markdown doesn't contain %q:\n%s", want, have)
+	}
+
+	// Snapshots without a unit are in days.
+	_, err = Burndown(snapshots, "3.15")
+	if want := `snapshots of milestone "3.15" have estimates in both "d" and "pt"`; err == nil || err.Error() != want {
+		t.Errorf("error: have %v, want %q", err, want)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Sort orders the assignee sections and the issues within them in the work
+// section.
+type Sort struct {
+	// Assignees is the order of the assignee sections: "name" (the default),
+	// or "estimate" or "remaining" for the largest workloads first.
+	Assignees string `yaml:"assignees"`
+
+	// Issues is the order of the issues of each assignee: "search" (the
+	// default) keeps the order of the GitHub search results, "estimate" puts
+	// the largest estimates first, "age" the oldest issues, "state" the open
+	// issues and "number" the lowest issue numbers.
+	Issues string `yaml:"issues"`
+}
+
+func (s *Sort) init() error {
+	switch s.Assignees {
+	case "":
+		s.Assignees = "name"
+	case "name", "estimate", "remaining":
+	default:
+		return fmt.Errorf("unsupported assignee sort order %q", s.Assignees)
+	}
+
+	switch s.Issues {
+	case "":
+		s.Issues = "search"
+	case "search", "estimate", "age", "state", "number":
+	default:
+		return fmt.Errorf("unsupported issue sort order %q", s.Issues)
+	}
+
+	return nil
+}
+
+// sortedAssignees returns the assignees of the workloads in the order of the
+// definition.
+func (ws Workloads) sortedAssignees() []string {
+	assignees := make([]string, 0, len(ws.ByAssignee))
+	for assignee := range ws.ByAssignee {
+		assignees = append(assignees, assignee)
+	}
+
+	sort.Strings(assignees)
+
+	var order string
+	if ws.config != nil {
+		order = ws.config.Sort.Assignees
+	}
+
+	var key func(*Workload) float64
+	switch order {
+	case "estimate":
+		key = (*Workload).Load
+	case "remaining":
+		key = (*Workload).Remaining
+	default:
+		return assignees
+	}
+
+	sort.SliceStable(assignees, func(i, j int) bool {
+		return key(ws.ByAssignee[assignees[i]]) > key(ws.ByAssignee[assignees[j]])
+	})
+
+	return assignees
+}
+
+// sortIssues sorts the issues of a workload in the given order.
+func sortIssues(issues []*Issue, order string) {
+	var less func(a, b *Issue) bool
+
+	switch order {
+	case "estimate":
+		less = func(a, b *Issue) bool { return a.estimateValue() > b.estimateValue() }
+	case "age":
+		less = func(a, b *Issue) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	case "state":
+		less = func(a, b *Issue) bool { return stateRank(a) < stateRank(b) }
+	case "number":
+		less = func(a, b *Issue) bool {
+			if a.Number != b.Number {
+				return a.Number < b.Number
+			}
+			return a.Repository < b.Repository
+		}
+	default:
+		return
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return less(issues[i], issues[j]) })
+}
+
+// stateRank orders open issues before closed ones, and deprioritised issues
+// last.
+func stateRank(issue *Issue) int {
+	switch {
+	case issue.Deprioritised:
+		return 2
+	case issue.Closed():
+		return 1
+	default:
+		return 0
+	}
+}
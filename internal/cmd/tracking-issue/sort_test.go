@@ -0,0 +1,54 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWorkloadsSort(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2020, 4, d, 0, 0, 0, 0, time.UTC) }
+
+	issues := func() []*Issue {
+		return []*Issue{
+			{Number: 3, State: "CLOSED", Milestone: "3.14", Assignees: []string{"alice"}, Labels: []string{"estimate/1d"}, CreatedAt: day(2)},
+			{Number: 1, State: "OPEN", Milestone: "3.13", Assignees: []string{"alice"}, Labels: []string{"estimate/5d"}, CreatedAt: day(3)},
+			{Number: 2, State: "OPEN", Milestone: "3.14", Assignees: []string{"alice"}, Labels: []string{"estimate/2d"}, CreatedAt: day(1)},
+			{Number: 4, State: "OPEN", Milestone: "3.14", Assignees: []string{"bob"}, Labels: []string{"estimate/1d"}},
+			{Number: 5, State: "OPEN", Milestone: "3.14", Assignees: []string{"carol"}, Labels: []string{"estimate/4d"}},
+		}
+	}
+
+	for _, tc := range []struct {
+		sort      Sort
+		assignees []string
+		issues    []int // of alice
+	}{
+		{Sort{Assignees: "name", Issues: "search"}, []string{"alice", "bob", "carol"}, []int{3, 1, 2}},
+		{Sort{Assignees: "estimate", Issues: "estimate"}, []string{"carol", "alice", "bob"}, []int{1, 2, 3}},
+		{Sort{Assignees: "remaining", Issues: "age"}, []string{"carol", "alice", "bob"}, []int{2, 3, 1}},
+		{Sort{Assignees: "name", Issues: "state"}, []string{"alice", "bob", "carol"}, []int{2, 3, 1}},
+		{Sort{Assignees: "name", Issues: "number"}, []string{"alice", "bob", "carol"}, []int{1, 2, 3}},
+	} {
+		ti := &TrackingIssue{
+			Issue:  &Issue{Milestone: "3.14"},
+			Issues: issues(),
+			Config: &IssueConfig{Sort: tc.sort},
+		}
+
+		ws := ti.Workloads()
+
+		if have := ws.sortedAssignees(); !reflect.DeepEqual(have, tc.assignees) {
+			t.Errorf("%+v: assignees: have %q, want %q", tc.sort, have, tc.assignees)
+		}
+
+		var have []int
+		for _, issue := range ws.ByAssignee["alice"].Issues {
+			have = append(have, issue.Number)
+		}
+
+		if !reflect.DeepEqual(have, tc.issues) {
+			t.Errorf("%+v: issues: have %v, want %v", tc.sort, have, tc.issues)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// LastActivity returns when the issue or one of its linked pull requests was
+// last updated, such as by a comment, a label or a pushed commit.
+func (issue *Issue) LastActivity() time.Time {
+	last := issue.UpdatedAt
+	for _, pr := range issue.LinkedPRs {
+		if pr.UpdatedAt.After(last) {
+			last = pr.UpdatedAt
+		}
+	}
+	return last
+}
+
+// MarkStale flags the open and assigned issues planned for the milestone that
+// had no activity for the configured number of days, and returns them.
+func (t *TrackingIssue) MarkStale(now time.Time) (stale []*Issue) {
+	if t.Config == nil || t.Config.StaleDays <= 0 {
+		return nil
+	}
+
+	// Computing the workloads links the pull requests and marks the
+	// deprioritised issues.
+	t.Workloads()
+
+	cutoff := now.AddDate(0, 0, -t.Config.StaleDays)
+	for _, issue := range t.Issues {
+		if issue.Deprioritised || issue.Closed() || len(issue.Assignees) == 0 {
+			continue
+		}
+
+		if last := issue.LastActivity(); !last.IsZero() && last.Before(cutoff) {
+			issue.Stale = true
+			stale = append(stale, issue)
+		}
+	}
+
+	return stale
+}
+
+// nudgeComment returns the reminder comment posted to a stale issue.
+func nudgeComment(t *TrackingIssue, issue *Issue, now time.Time) string {
+	mentions := make([]string, 0, len(issue.Assignees))
+	for _, assignee := range issue.Assignees {
+		mentions = append(mentions, "@"+assignee)
+	}
+
+	days := int(now.Sub(issue.LastActivity()).Hours() / 24)
+
+	var milestone string
+	if t.Milestone != "" {
+		milestone = fmt.Sprintf(" planned for %s", t.Milestone)
+	}
+
+	return fmt.Sprintf("%s: this issue%s has had no activity for %d days. Is it still on track? Please leave an update, or unassign yourself if it isn't being worked on.\n\n_Posted by the tracking issue %s._",
+		strings.Join(mentions, " "), milestone, days, t.URL)
+}
+
+// nudge posts a reminder comment to each issue marked stale once, even if
+// several tracking issues list it. The comment counts as activity, so an issue
+// is only nudged again once it is stale again.
+func nudge(ctx context.Context, p Provider, tracking []*TrackingIssue, now time.Time) error {
+	nudged := map[string]bool{}
+	for _, t := range tracking {
+		for _, issue := range t.Issues {
+			if !issue.Stale || nudged[issue.URL] {
+				continue
+			}
+			nudged[issue.URL] = true
+
+			if err := p.Comment(ctx, issue, nudgeComment(t, issue, now)); err != nil {
+				return fmt.Errorf("nudging %s: %v", issue.URL, err)
+			}
+			log.Printf("Nudged the assignees of stale issue %s.", issue.URL)
+		}
+	}
+	return nil
+}
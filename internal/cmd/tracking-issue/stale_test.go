@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarkStale(t *testing.T) {
+	now := time.Date(2020, 6, 20, 0, 0, 0, 0, time.UTC)
+	daysAgo := func(d int) time.Time { return now.AddDate(0, 0, -d) }
+
+	issue := func(number int, state string, updated time.Time, assignees ...string) *Issue {
+		return &Issue{
+			Number:    number,
+			URL:       fmt.Sprintf("https://github.com/sourcegraph/sourcegraph/issues/%d", number),
+			State:     state,
+			Milestone: "3.17",
+			Assignees: assignees,
+			UpdatedAt: updated,
+			Labels:    []string{"estimate/1d"},
+		}
+	}
+
+	ti := &TrackingIssue{
+		Issue: &Issue{Milestone: "3.17", URL: "https://github.com/sourcegraph/sourcegraph/issues/1000"},
+		Issues: []*Issue{
+			issue(1, "OPEN", daysAgo(10), "alice"),
+			issue(2, "OPEN", daysAgo(2), "alice"),
+			issue(3, "CLOSED", daysAgo(10), "alice"),
+			issue(4, "OPEN", daysAgo(10)), // Unassigned
+			issue(5, "OPEN", daysAgo(10), "bob"),
+			issue(6, "OPEN", daysAgo(10), "alice", "bob"),
+		},
+		PRs: []*PullRequest{
+			// Recent activity on a linked pull request counts.
+			{Number: 7, Body: "Closes #5", Author: "bob", State: "OPEN", UpdatedAt: daysAgo(1)},
+		},
+		Config: &IssueConfig{Org: "sourcegraph", StaleDays: 7},
+	}
+
+	var have []int
+	for _, issue := range ti.MarkStale(now) {
+		have = append(have, issue.Number)
+	}
+
+	if want := []int{1, 6}; !reflect.DeepEqual(have, want) {
+		t.Errorf("stale issues: have %v, want %v", have, want)
+	}
+
+	md := ti.Workloads().Markdown()
+	for _, want := range []string{
+		"[#1](https://github.com/sourcegraph/sourcegraph/issues/1) __1d__ 💤\n",
+		"\n⚠️ __Stale__\n\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown doesn't contain %q:\n%s", want, md)
+		}
+	}
+
+	comment := nudgeComment(ti, ti.Issues[5], now)
+	if want := "@alice @bob: this issue planned for 3.17 has had no activity for 10 days."; !strings.HasPrefix(comment, want) {
+		t.Errorf("comment: have %q, want prefix %q", comment, want)
+	}
+
+	// Tracking issues that list the same issue nudge it once.
+	other := &TrackingIssue{Issue: &Issue{}, Issues: []*Issue{ti.Issues[0]}}
+	p := &fakeProvider{}
+	if err := nudge(context.Background(), p, []*TrackingIssue{ti, other}, now); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{ti.Issues[0].URL, ti.Issues[5].URL}; !reflect.DeepEqual(p.commented, want) {
+		t.Errorf("nudged: have %q, want %q", p.commented, want)
+	}
+
+	ti.Config.StaleDays = 0
+	if stale := ti.MarkStale(now); len(stale) != 0 {
+		t.Errorf("stale issues without staleDays: %v", stale)
+	}
+}
+
+type fakeProvider struct {
+	Provider
+	commented []string
+}
+
+func (p *fakeProvider) Comment(_ context.Context, issue *Issue, _ string) error {
+	p.commented = append(p.commented, issue.URL)
+	return nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TimelineMetrics configures measuring the cycle times of issues from their
+// timelines: how long they waited after being added to the milestone, and how
+// long they were in progress until they were closed.
+type TimelineMetrics struct {
+	// InProgressLabel, if set, marks an issue in progress when it is added.
+	// Issues are also in progress once the first commit of a linked pull
+	// request was authored.
+	InProgressLabel string `yaml:"inProgressLabel"`
+}
+
+// timelineEvent is a milestoned or labeled event of an issue timeline.
+type timelineEvent struct {
+	Typename       string `json:"__typename"`
+	CreatedAt      time.Time
+	MilestoneTitle string
+	Label          struct{ Name string }
+}
+
+type timelineNode struct {
+	Nodes []timelineEvent
+}
+
+// timelineGraphQLFields are the GraphQL fields of the timeline events of an
+// issue that cycle times are measured from.
+const timelineGraphQLFields = `
+	timelineItems(last: 100, itemTypes: [MILESTONED_EVENT, LABELED_EVENT]) {
+		nodes {
+			__typename
+			... on MilestonedEvent { createdAt, milestoneTitle }
+			... on LabeledEvent { createdAt, label { name } }
+		}
+	}
+`
+
+// apply sets when the issue was last added to its milestone and first labeled
+// in progress from its timeline.
+func (tm *TimelineMetrics) apply(issue *Issue) {
+	issue.timed = true
+	issue.milestonedAt = issue.CreatedAt
+
+	for _, e := range issue.events {
+		switch e.Typename {
+		case "MilestonedEvent":
+			if issue.Milestone != "" && e.MilestoneTitle == issue.Milestone && e.CreatedAt.After(issue.milestonedAt) {
+				issue.milestonedAt = e.CreatedAt
+			}
+		case "LabeledEvent":
+			if tm.InProgressLabel != "" && e.Label.Name == tm.InProgressLabel &&
+				(issue.labeledAt.IsZero() || e.CreatedAt.Before(issue.labeledAt)) {
+				issue.labeledAt = e.CreatedAt
+			}
+		}
+	}
+}
+
+// Cycle is when an issue was added to the milestone, started and closed. The
+// times of the phases it hasn't reached yet are zero.
+type Cycle struct {
+	Issue      *Issue
+	Milestoned time.Time
+	Started    time.Time
+	Closed     time.Time
+}
+
+// Cycle returns the cycle of the issue, and whether its timeline is known.
+func (issue *Issue) Cycle() (Cycle, bool) {
+	if !issue.timed {
+		return Cycle{}, false
+	}
+
+	c := Cycle{Issue: issue, Milestoned: issue.milestonedAt, Started: issue.labeledAt}
+
+	for _, pr := range issue.LinkedPRs {
+		if !pr.BeganAt.IsZero() && (c.Started.IsZero() || pr.BeganAt.Before(c.Started)) {
+			c.Started = pr.BeganAt
+		}
+	}
+
+	if issue.Closed() && !issue.ClosedAt.IsZero() {
+		c.Closed = issue.ClosedAt
+	}
+
+	// Work that began before the issue was added to the milestone didn't wait.
+	if !c.Started.IsZero() && c.Started.Before(c.Milestoned) {
+		c.Started = c.Milestoned
+	}
+
+	return c, true
+}
+
+// Waiting returns how long the issue waited to be started, and whether it was.
+func (c Cycle) Waiting() (time.Duration, bool) {
+	return span(c.Milestoned, c.Started)
+}
+
+// InProgress returns how long the issue was in progress until it was closed,
+// and whether it was.
+func (c Cycle) InProgress() (time.Duration, bool) {
+	return span(c.Started, c.Closed)
+}
+
+// Total returns how long it took to close the issue once it was added to the
+// milestone, and whether it was closed.
+func (c Cycle) Total() (time.Duration, bool) {
+	return span(c.Milestoned, c.Closed)
+}
+
+// span returns the duration between the times, and whether both are known.
+func span(from, to time.Time) (time.Duration, bool) {
+	if from.IsZero() || to.IsZero() {
+		return 0, false
+	}
+	if to.Before(from) {
+		return 0, true
+	}
+	return to.Sub(from), true
+}
+
+// cyclesMarkdown renders the cycle times of the issues as a collapsed table,
+// with the medians of each phase.
+func cyclesMarkdown(w io.Writer, cycles []Cycle) {
+	if len(cycles) == 0 {
+		return
+	}
+
+	io.WriteString(w, "\n<details><summary>⏱️ Cycle times</summary>\n\n")
+	io.WriteString(w, "| Issue | Waiting | In progress | Total |\n")
+	io.WriteString(w, "|-------|--------:|------------:|------:|\n")
+
+	// phases are the durations of each phase of the issues that completed
+	// it.
+	phases := make([][]time.Duration, 3)
+
+	for _, c := range cycles {
+		issue := c.Issue
+
+		cells := make([]string, 3)
+		for i, phase := range []func() (time.Duration, bool){c.Waiting, c.InProgress, c.Total} {
+			if d, ok := phase(); ok {
+				cells[i] = formatDays(d)
+				phases[i] = append(phases[i], d)
+			}
+		}
+
+		fmt.Fprintf(w, "| %s [%s](%s) | %s |\n",
+			strings.Replace(issue.title(), "|", `\|`, -1),
+			reference(issue.Repository, issue.Number, issue.qualified),
+			issue.URL,
+			strings.Join(cells, " | "),
+		)
+	}
+
+	median := func(ds []time.Duration) string {
+		if len(ds) == 0 {
+			return ""
+		}
+		return formatDays(medianDuration(ds))
+	}
+
+	fmt.Fprintf(w, "| __Median__ | %s | %s | %s |\n\n</details>\n", median(phases[0]), median(phases[1]), median(phases[2]))
+}
+
+func formatDays(d time.Duration) string {
+	return fmt.Sprintf("%.1fd", d.Hours()/24)
+}
+
+func medianDuration(ds []time.Duration) time.Duration {
+	sorted := append([]time.Duration(nil), ds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
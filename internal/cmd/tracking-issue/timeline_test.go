@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimelineMetrics(t *testing.T) {
+	const data = `{"timelineItems": {"nodes": [
+		{"__typename": "MilestonedEvent", "createdAt": "2020-06-01T00:00:00Z", "milestoneTitle": "3.17"},
+		{"__typename": "LabeledEvent", "createdAt": "2020-06-05T00:00:00Z", "label": {"name": "in progress"}},
+		{"__typename": "MilestonedEvent", "createdAt": "2020-06-03T00:00:00Z", "milestoneTitle": "3.18"},
+		{"__typename": "LabeledEvent", "createdAt": "2020-06-04T00:00:00Z", "label": {"name": "bug"}}
+	]}}`
+
+	var n searchNode
+	if err := json.Unmarshal([]byte(data), &n); err != nil {
+		t.Fatal(err)
+	}
+
+	day := func(d int) time.Time { return time.Date(2020, 6, d, 0, 0, 0, 0, time.UTC) }
+
+	for _, tc := range []struct {
+		name    string
+		metrics TimelineMetrics
+		issue   Issue
+		prs     []*PullRequest
+		cycle   Cycle
+	}{
+		{
+			name:    "labeled in progress",
+			metrics: TimelineMetrics{InProgressLabel: "in progress"},
+			issue:   Issue{Milestone: "3.18", State: "CLOSED", CreatedAt: day(1), ClosedAt: day(10)},
+			cycle:   Cycle{Milestoned: day(3), Started: day(5), Closed: day(10)},
+		},
+		{
+			name:    "linked pull request began first",
+			metrics: TimelineMetrics{InProgressLabel: "in progress"},
+			issue:   Issue{Milestone: "3.18", State: "OPEN", CreatedAt: day(1)},
+			prs:     []*PullRequest{{BeganAt: day(7)}, {BeganAt: day(4)}},
+			cycle:   Cycle{Milestoned: day(3), Started: day(4)},
+		},
+		{
+			name:  "began before milestoned",
+			issue: Issue{Milestone: "3.17", State: "OPEN", CreatedAt: day(1)},
+			prs:   []*PullRequest{{BeganAt: day(1).AddDate(0, 0, -3)}},
+			cycle: Cycle{Milestoned: day(1), Started: day(1)},
+		},
+		{
+			name:  "never milestoned",
+			issue: Issue{Milestone: "3.19", State: "OPEN", CreatedAt: day(2)},
+			cycle: Cycle{Milestoned: day(2)},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			issue := tc.issue
+			issue.events = n.TimelineItems.Nodes
+			issue.LinkedPRs = tc.prs
+			tc.metrics.apply(&issue)
+
+			c, ok := issue.Cycle()
+			if !ok {
+				t.Fatal("no cycle")
+			}
+
+			tc.cycle.Issue = &issue
+			if c != tc.cycle {
+				t.Errorf("cycle: want %+v, got %+v", tc.cycle, c)
+			}
+		})
+	}
+
+	if _, ok := (&Issue{}).Cycle(); ok {
+		t.Error("cycle of issue without timeline")
+	}
+}
+
+func TestCyclesMarkdown(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2020, 6, d, 0, 0, 0, 0, time.UTC) }
+
+	var b strings.Builder
+	cyclesMarkdown(&b, []Cycle{
+		{Issue: &Issue{Title: "a", Number: 1, URL: "https://github.com/sourcegraph/sourcegraph/issues/1"}, Milestoned: day(1), Started: day(2), Closed: day(5)},
+		{Issue: &Issue{Title: "b", Number: 2, URL: "https://github.com/sourcegraph/sourcegraph/issues/2"}, Milestoned: day(1), Started: day(4)},
+		{Issue: &Issue{Title: "c", Number: 3, URL: "https://github.com/sourcegraph/sourcegraph/issues/3"}, Milestoned: day(2)},
+	})
+
+	want := `
+<details><summary>⏱️ Cycle times</summary>
+
+| Issue | Waiting | In progress | Total |
+|-------|--------:|------------:|------:|
+| a [#1](https://github.com/sourcegraph/sourcegraph/issues/1) | 1.0d | 3.0d | 4.0d |
+| b [#2](https://github.com/sourcegraph/sourcegraph/issues/2) | 3.0d |  |  |
+| c [#3](https://github.com/sourcegraph/sourcegraph/issues/3) |  |  |  |
+| __Median__ | 2.0d | 3.0d | 4.0d |
+
+</details>
+`
+
+	if have := b.String(); have != want {
+		t.Errorf("cycles markdown:\n%s", UnifiedDiff("want", "have", want, have))
+	}
+
+	b.Reset()
+	if cyclesMarkdown(&b, nil); b.Len() != 0 {
+		t.Errorf("cycles markdown without cycles: %q", b.String())
+	}
+}
@@ -0,0 +1,251 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sourcegraph/jsonx"
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
+)
+
+// ElementMatcher reports whether an array element, decoded as by json.Unmarshal
+// into an interface{}, is the element to edit.
+type ElementMatcher func(element interface{}) bool
+
+// ComputeArrayElementInsertion returns the edits necessary to append value to
+// the array at path in the JSON input, creating the array if it doesn't exist.
+//
+// Unlike computing a property edit for the whole array, the existing elements
+// and the comments around them are left untouched. The new element follows the
+// layout of the last element.
+func ComputeArrayElementInsertion(input string, path jsonx.Path, value interface{}) ([]jsonx.Edit, error) {
+	array, text, err := findArray(input, path)
+	if err != nil {
+		return nil, err
+	}
+	if array == nil || len(array.Children) == 0 {
+		edits, _, err := jsonx.ComputePropertyEdit(input, append(path[:len(path):len(path)], jsonx.Segment{Index: -1}), value, nil, FormatOptions)
+		return edits, err
+	}
+
+	last := array.Children[len(array.Children)-1]
+	end := last.Offset + last.Length
+	indent, startsLine := lineIndent(text, last.Offset)
+	encoded, err := encodeElement(value, indent, strings.ContainsRune(nodeText(text, last), '\n'))
+	if err != nil {
+		return nil, err
+	}
+
+	comma := nextComma(input, end)
+	after := end
+	if comma >= 0 {
+		after = comma + 1
+	}
+	if lineEnd, _, blank := restOfLine(input, after); startsLine && blank {
+		// Add the element on its own line after the last element's line, so
+		// that a comment at the end of that line stays with it.
+		if comma < 0 {
+			return []jsonx.Edit{
+				{Offset: end, Content: ","},
+				{Offset: lineEnd, Content: "\n" + indent + encoded},
+			}, nil
+		}
+		return []jsonx.Edit{{Offset: lineEnd, Content: "\n" + indent + encoded + ","}}, nil
+	}
+
+	if comma < 0 {
+		return []jsonx.Edit{{Offset: end, Content: ", " + encoded}}, nil
+	}
+	return []jsonx.Edit{{Offset: after, Content: " " + encoded + ","}}, nil
+}
+
+// ComputeArrayElementEdit returns the edits necessary to replace the first
+// element of the array at path in the JSON input that matches with value. If no
+// element matches, no edits are returned.
+//
+// Only the matching element is rewritten, so the other elements and the
+// comments around the element are left untouched.
+func ComputeArrayElementEdit(input string, path jsonx.Path, match ElementMatcher, value interface{}) ([]jsonx.Edit, error) {
+	array, text, err := findArray(input, path)
+	if err != nil || array == nil {
+		return nil, err
+	}
+	i, err := findElement(text, array, match)
+	if err != nil || i < 0 {
+		return nil, err
+	}
+
+	element := array.Children[i]
+	indent, _ := lineIndent(text, element.Offset)
+	encoded, err := encodeElement(value, indent, strings.ContainsRune(nodeText(text, element), '\n'))
+	if err != nil {
+		return nil, err
+	}
+	return []jsonx.Edit{{Offset: element.Offset, Length: element.Length, Content: encoded}}, nil
+}
+
+// ComputeArrayElementRemoval returns the edits necessary to remove the first
+// element of the array at path in the JSON input that matches. If no element
+// matches, no edits are returned.
+//
+// If the element is on its own lines, those lines are removed, including a
+// comment at the end of its last line. Comments on other lines are left
+// untouched.
+func ComputeArrayElementRemoval(input string, path jsonx.Path, match ElementMatcher) ([]jsonx.Edit, error) {
+	array, text, err := findArray(input, path)
+	if err != nil || array == nil {
+		return nil, err
+	}
+	i, err := findElement(text, array, match)
+	if err != nil || i < 0 {
+		return nil, err
+	}
+
+	element := array.Children[i]
+	start, end := element.Offset, element.Offset+element.Length
+	comma := nextComma(input, end)
+	if comma >= 0 {
+		end = comma + 1
+	}
+
+	// The comma separating the previous element from this one must be
+	// removed if this is the last element and has no trailing comma.
+	prevComma := -1
+	if comma < 0 && i > 0 {
+		prev := array.Children[i-1]
+		if prevComma = nextComma(input, prev.Offset+prev.Length); prevComma < 0 {
+			return nil, fmt.Errorf("no comma after element %d of %s", i-1, pathString(path))
+		}
+	}
+
+	indent, startsLine := lineIndent(text, element.Offset)
+	if _, next, blank := restOfLine(input, end); startsLine && blank {
+		start, end = element.Offset-len([]rune(indent)), next
+		if prevComma >= 0 {
+			return []jsonx.Edit{
+				{Offset: prevComma, Length: 1},
+				{Offset: start, Length: end - start},
+			}, nil
+		}
+		return []jsonx.Edit{{Offset: start, Length: end - start}}, nil
+	}
+
+	if prevComma >= 0 {
+		start = prevComma
+	} else {
+		end = skipWhitespace(input, end)
+	}
+	return []jsonx.Edit{{Offset: start, Length: end - start}}, nil
+}
+
+// findArray returns the array node at path in the JSON input, or nil if there
+// is none, along with the input as runes (which node offsets refer to).
+func findArray(input string, path jsonx.Path) (*jsonx.Node, []rune, error) {
+	root, _ := jsonx.ParseTree(input, jsonx.ParseOptions{Comments: true, TrailingCommas: true})
+	node := jsonx.FindNodeAtLocation(root, path)
+	if node == nil {
+		return nil, nil, nil
+	}
+	if node.Type != jsonx.Array {
+		return nil, nil, fmt.Errorf("%s is not an array", pathString(path))
+	}
+	return node, []rune(input), nil
+}
+
+// findElement returns the index of the first element of array that matches, or
+// -1 if none does.
+func findElement(text []rune, array *jsonx.Node, match ElementMatcher) (int, error) {
+	for i, c := range array.Children {
+		var v interface{}
+		if err := jsonc.Unmarshal(nodeText(text, c), &v); err != nil {
+			return -1, err
+		}
+		if match(v) {
+			return i, nil
+		}
+	}
+	return -1, nil
+}
+
+func nodeText(text []rune, node *jsonx.Node) string {
+	return string(text[node.Offset : node.Offset+node.Length])
+}
+
+// encodeElement encodes an array element. If multiline is true, nested values
+// are put on their own lines, indented relative to indent.
+func encodeElement(value interface{}, indent string, multiline bool) (string, error) {
+	if raw, ok := value.(json.RawMessage); ok {
+		// Like jsonx, insert raw values (which may contain comments) as is.
+		return string(raw), nil
+	}
+	var (
+		b   []byte
+		err error
+	)
+	if multiline {
+		unit := "\t"
+		if FormatOptions.InsertSpaces {
+			unit = strings.Repeat(" ", FormatOptions.TabSize)
+		}
+		b, err = json.MarshalIndent(value, indent, unit)
+	} else {
+		b, err = json.Marshal(value)
+	}
+	return string(b), err
+}
+
+// lineIndent returns the whitespace between the start of the line and offset,
+// and whether there is nothing but whitespace there.
+func lineIndent(text []rune, offset int) (indent string, startsLine bool) {
+	start := offset
+	for start > 0 && text[start-1] != '\n' {
+		if c := text[start-1]; c != ' ' && c != '\t' {
+			return "", false
+		}
+		start--
+	}
+	return string(text[start:offset]), true
+}
+
+// nextComma returns the offset of the comma that follows offset (ignoring
+// whitespace and comments), or -1 if the next token is not a comma.
+func nextComma(input string, offset int) int {
+	s := jsonx.NewScanner(input, jsonx.ScanOptions{})
+	s.SetPosition(offset)
+	if s.Scan() == jsonx.CommaToken {
+		return s.TokenOffset()
+	}
+	return -1
+}
+
+// restOfLine scans the line from offset. It returns the offset of the line
+// break and the offset after it, and whether the rest of the line contains
+// only whitespace and comments.
+func restOfLine(input string, offset int) (lineEnd, next int, blank bool) {
+	s := jsonx.NewScanner(input, jsonx.ScanOptions{Trivia: true})
+	s.SetPosition(offset)
+	for {
+		switch s.Scan() {
+		case jsonx.Trivia, jsonx.LineCommentTrivia, jsonx.BlockCommentTrivia:
+			continue
+		case jsonx.LineBreakTrivia:
+			return s.TokenOffset(), s.Pos(), true
+		case jsonx.EOF:
+			return s.Pos(), s.Pos(), true
+		default:
+			return s.TokenOffset(), s.TokenOffset(), false
+		}
+	}
+}
+
+// skipWhitespace returns the offset of the first non-whitespace character at
+// or after offset on the same line.
+func skipWhitespace(input string, offset int) int {
+	s := jsonx.NewScanner(input, jsonx.ScanOptions{Trivia: true})
+	s.SetPosition(offset)
+	if s.Scan() == jsonx.Trivia {
+		return s.Pos()
+	}
+	return offset
+}
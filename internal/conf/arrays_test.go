@@ -0,0 +1,187 @@
+package conf
+
+import (
+	"testing"
+
+	"github.com/sourcegraph/jsonx"
+)
+
+func TestComputeArrayElementEdits(t *testing.T) {
+	const multiline = `{
+  "a": [
+    // first
+    {"x": 1},
+    {
+      "x": 2
+    }, // second
+    "c" // last
+  ]
+}`
+	isX := func(x float64) ElementMatcher {
+		return func(v interface{}) bool {
+			m, ok := v.(map[string]interface{})
+			return ok && m["x"] == x
+		}
+	}
+	isString := func(s string) ElementMatcher {
+		return func(v interface{}) bool { return v == s }
+	}
+	path := jsonx.PropertyPath("a")
+
+	tests := map[string]struct {
+		input   string
+		compute func(input string) ([]jsonx.Edit, error)
+		want    string
+	}{
+		"insert multiline": {
+			input: multiline,
+			compute: func(input string) ([]jsonx.Edit, error) {
+				return ComputeArrayElementInsertion(input, path, "d")
+			},
+			want: `{
+  "a": [
+    // first
+    {"x": 1},
+    {
+      "x": 2
+    }, // second
+    "c", // last
+    "d"
+  ]
+}`,
+		},
+		"insert trailing comma": {
+			input: "{\"a\": [\n  1,\n]}",
+			compute: func(input string) ([]jsonx.Edit, error) {
+				return ComputeArrayElementInsertion(input, path, 2)
+			},
+			want: "{\"a\": [\n  1,\n  2,\n]}",
+		},
+		"insert inline": {
+			input: `{"a": [1, 2]}`,
+			compute: func(input string) ([]jsonx.Edit, error) {
+				return ComputeArrayElementInsertion(input, path, map[string]int{"x": 3})
+			},
+			want: `{"a": [1, 2, {"x":3}]}`,
+		},
+		"insert into missing array": {
+			input: `{}`,
+			compute: func(input string) ([]jsonx.Edit, error) {
+				return ComputeArrayElementInsertion(input, path, 1)
+			},
+			want: `{
+  "a": [
+    1
+  ]
+}`,
+		},
+		"update keeps layout": {
+			input: multiline,
+			compute: func(input string) ([]jsonx.Edit, error) {
+				return ComputeArrayElementEdit(input, path, isX(2), map[string]int{"x": 3, "y": 4})
+			},
+			want: `{
+  "a": [
+    // first
+    {"x": 1},
+    {
+      "x": 3,
+      "y": 4
+    }, // second
+    "c" // last
+  ]
+}`,
+		},
+		"update single-line element": {
+			input: multiline,
+			compute: func(input string) ([]jsonx.Edit, error) {
+				return ComputeArrayElementEdit(input, path, isX(1), map[string]int{"x": 5})
+			},
+			want: `{
+  "a": [
+    // first
+    {"x":5},
+    {
+      "x": 2
+    }, // second
+    "c" // last
+  ]
+}`,
+		},
+		"update no match": {
+			input: multiline,
+			compute: func(input string) ([]jsonx.Edit, error) {
+				return ComputeArrayElementEdit(input, path, isX(9), 1)
+			},
+			want: multiline,
+		},
+		"remove middle": {
+			input: multiline,
+			compute: func(input string) ([]jsonx.Edit, error) {
+				return ComputeArrayElementRemoval(input, path, isX(2))
+			},
+			want: `{
+  "a": [
+    // first
+    {"x": 1},
+    "c" // last
+  ]
+}`,
+		},
+		"remove last": {
+			input: multiline,
+			compute: func(input string) ([]jsonx.Edit, error) {
+				return ComputeArrayElementRemoval(input, path, isString("c"))
+			},
+			want: `{
+  "a": [
+    // first
+    {"x": 1},
+    {
+      "x": 2
+    } // second
+  ]
+}`,
+		},
+		"remove inline first": {
+			input: `{"a": [1, 2, 3]}`,
+			compute: func(input string) ([]jsonx.Edit, error) {
+				return ComputeArrayElementRemoval(input, path, func(v interface{}) bool { return v == float64(1) })
+			},
+			want: `{"a": [2, 3]}`,
+		},
+		"remove inline last": {
+			input: `{"a": [1, 2, 3]}`,
+			compute: func(input string) ([]jsonx.Edit, error) {
+				return ComputeArrayElementRemoval(input, path, func(v interface{}) bool { return v == float64(3) })
+			},
+			want: `{"a": [1, 2]}`,
+		},
+		"remove only": {
+			input: `{"a": [1]}`,
+			compute: func(input string) ([]jsonx.Edit, error) {
+				return ComputeArrayElementRemoval(input, path, func(v interface{}) bool { return true })
+			},
+			want: `{"a": []}`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			edits, err := test.compute(test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := jsonx.ApplyEdits(test.input, edits...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got\n%s\nwant\n%s", got, test.want)
+			}
+		})
+	}
+
+	if _, err := ComputeArrayElementInsertion(`{"a": {}}`, path, 1); err == nil {
+		t.Error("got no error editing an object as an array")
+	}
+}
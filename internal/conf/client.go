@@ -6,6 +6,7 @@ import (
 	"math/rand"
 	"net"
 	"net/url"
+	"reflect"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -20,6 +21,11 @@ type client struct {
 	passthrough ConfigurationSource
 	watchersMu  sync.Mutex
 	watchers    []chan struct{}
+
+	// changes receives a value when the passthrough source reports a change
+	// (see ChangeNotifier), to fetch the configuration without waiting for the
+	// next poll. It is nil if the source does not report changes.
+	changes <-chan struct{}
 }
 
 var (
@@ -105,6 +111,17 @@ func Watch(f func()) {
 	defaultClient().Watch(f)
 }
 
+// WatchPath calls the given function whenever the value of the configuration
+// field at path has changed. See WatchPath on client for details.
+//
+// WatchPath is a wrapper around client.WatchPath.
+//
+// IMPORTANT: WatchPath will block on config initialization. It therefore should *never* be called
+// synchronously in `init` functions.
+func WatchPath(path string, f func(old, new interface{})) {
+	defaultClient().WatchPath(path, f)
+}
+
 // Cached will return a wrapper around f which caches the response. The value
 // will be recomputed every time the config is updated.
 //
@@ -139,6 +156,31 @@ func (c *client) Watch(f func()) {
 	}()
 }
 
+// WatchPath calls the given function in a separate goroutine whenever the
+// value of the configuration field at path has changed, with the previous and
+// new values of that field. Changes to other fields do not invoke f.
+//
+// The path is a JSON field name of the site configuration, such as
+// "externalURL" or "auth.providers". Nested experimental features and service
+// connections are addressed as "experimentalFeatures::<name>" and
+// "serviceConnections::<name>".
+//
+// Before WatchPath returns, it will invoke f with a nil old value and the
+// current value.
+func (c *client) WatchPath(path string, f func(old, new interface{})) {
+	var last interface{}
+	first := true
+	c.Watch(func() {
+		value := jsonFields(c.Get())[path]
+		if !first && reflect.DeepEqual(last, value) {
+			return
+		}
+		old := last
+		last, first = value, false
+		f(old, value)
+	})
+}
+
 // Cached will return a wrapper around f which caches the response. The value
 // will be recomputed every time the config is updated.
 //
@@ -202,7 +244,10 @@ func (c *client) continuouslyUpdate(optOnlySetByTests *continuousUpdateOptions)
 			log:                       log.Printf,
 			sleep: func() {
 				jitter := time.Duration(rand.Int63n(5 * int64(time.Second)))
-				time.Sleep(jitter)
+				select {
+				case <-time.After(jitter):
+				case <-c.changes:
+				}
 			},
 		}
 	}
@@ -56,3 +56,47 @@ func TestClient_continuouslyUpdate(t *testing.T) {
 		<-done
 	})
 }
+
+func TestClient_WatchPath(t *testing.T) {
+	client := &client{store: newStore()}
+	update := func(site string) {
+		t.Helper()
+		if _, err := client.store.MaybeUpdate(conftypes.RawUnified{Critical: "{}", Site: site}); err != nil {
+			t.Fatal(err)
+		}
+		client.notifyWatchers()
+	}
+
+	type change struct{ old, new interface{} }
+	changes := make(chan change, 10)
+
+	update(`{"maxReposToSearch": 1}`)
+	client.WatchPath("maxReposToSearch", func(old, new interface{}) {
+		changes <- change{old, new}
+	})
+
+	next := func() change {
+		t.Helper()
+		select {
+		case c := <-changes:
+			return c
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for WatchPath callback")
+			return change{}
+		}
+	}
+
+	if got, want := next(), (change{nil, 1}); got != want {
+		t.Errorf("got initial change %v, want %v", got, want)
+	}
+
+	// An unrelated edit must not invoke the callback, so the next change we
+	// observe is the edit to maxReposToSearch.
+	update(`{"maxReposToSearch": 1, "externalURL": "https://example.com"}`)
+	time.Sleep(50 * time.Millisecond)
+	update(`{"maxReposToSearch": 2, "externalURL": "https://example.com"}`)
+
+	if got, want := next(), (change{1, 2}); got != want {
+		t.Errorf("got change %v, want %v", got, want)
+	}
+}
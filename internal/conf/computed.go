@@ -198,15 +198,6 @@ func IsValidDeployType(deployType string) bool {
 		IsDev(deployType)
 }
 
-// UpdateChannel tells the update channel. Default is "release".
-func UpdateChannel() string {
-	channel := Get().UpdateChannel
-	if channel == "" {
-		return "release"
-	}
-	return channel
-}
-
 // SearchIndexEnabled returns true if sourcegraph should index all
 // repositories for text search. If the configuration is unset, it returns
 // false for the docker server image (due to resource usage) but true
@@ -113,3 +113,17 @@ func setenv(t *testing.T, keyval string) func() {
 func boolPtr(b bool) *bool {
 	return &b
 }
+
+func TestGitMaxConcurrentClones(t *testing.T) {
+	defer Mock(nil)
+
+	Mock(&Unified{})
+	if got, want := GitMaxConcurrentClones(), 5; got != want {
+		t.Errorf("unset: got %d, want default %d", got, want)
+	}
+
+	Mock(&Unified{SiteConfiguration: schema.SiteConfiguration{GitMaxConcurrentClones: 2}})
+	if got, want := GitMaxConcurrentClones(), 2; got != want {
+		t.Errorf("set: got %d, want %d", got, want)
+	}
+}
@@ -130,6 +130,20 @@ func (c *cachedConfigurationSource) Read(ctx context.Context) (conftypes.RawUnif
 	return *c.entry, nil
 }
 
+// invalidate makes the next read go to the underlying source.
+func (c *cachedConfigurationSource) invalidate() {
+	c.entryMu.Lock()
+	c.entry = nil
+	c.entryMu.Unlock()
+}
+
+// NotifyChanges implements ChangeNotifier if the underlying source does.
+func (c *cachedConfigurationSource) NotifyChanges(ch chan<- struct{}) {
+	if n, ok := c.source.(ChangeNotifier); ok {
+		n.NotifyChanges(ch)
+	}
+}
+
 func (c *cachedConfigurationSource) Write(ctx context.Context, input conftypes.RawUnified) error {
 	c.entryMu.Lock()
 	defer c.entryMu.Unlock()
@@ -141,6 +155,35 @@ func (c *cachedConfigurationSource) Write(ctx context.Context, input conftypes.R
 	return nil
 }
 
+// ReadVersion implements VersionedConfigurationSource if the underlying source
+// does. Versioned reads are never cached.
+func (c *cachedConfigurationSource) ReadVersion(ctx context.Context) (conftypes.RawUnified, ConfigurationVersion, error) {
+	v, ok := c.source.(VersionedConfigurationSource)
+	if !ok {
+		return conftypes.RawUnified{}, ConfigurationVersion{}, ErrUnversioned
+	}
+	return v.ReadVersion(ctx)
+}
+
+// WriteIfUpToDate implements VersionedConfigurationSource if the underlying
+// source does.
+func (c *cachedConfigurationSource) WriteIfUpToDate(ctx context.Context, input conftypes.RawUnified, version ConfigurationVersion) (ConfigurationVersion, error) {
+	v, ok := c.source.(VersionedConfigurationSource)
+	if !ok {
+		return ConfigurationVersion{}, ErrUnversioned
+	}
+
+	c.entryMu.Lock()
+	defer c.entryMu.Unlock()
+	written, err := v.WriteIfUpToDate(ctx, input, version)
+	if err != nil {
+		return ConfigurationVersion{}, err
+	}
+	c.entry = &input
+	c.entryTime = time.Now()
+	return written, nil
+}
+
 // InitConfigurationServerFrontendOnly creates and returns a configuration
 // server. This should only be invoked by the frontend, or else a panic will
 // occur. This function should only ever be called once.
@@ -160,12 +203,18 @@ func InitConfigurationServerFrontendOnly(source ConfigurationSource) *Server {
 		// conf.Watch poll rate is 5s, so we use half that.
 		ttl: 2500 * time.Millisecond,
 	})
+	server.HealthCheckTimeout = parseHealthCheckTimeout()
 	server.Start()
 
 	// Install the passthrough configuration source for defaultClient. This is
 	// so that the frontend does not request configuration from itself via HTTP
 	// and instead only relies on the DB.
 	defaultClient().passthrough = source
+	if n, ok := source.(ChangeNotifier); ok {
+		changes := make(chan struct{}, 1)
+		n.NotifyChanges(changes)
+		defaultClient().changes = changes
+	}
 
 	go defaultClient().continuouslyUpdate(nil)
 	close(configurationServerFrontendOnlyInitialized)
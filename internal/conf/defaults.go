@@ -0,0 +1,39 @@
+// Code generated by defaults_generate.go. DO NOT EDIT.
+
+package conf
+
+// AuthSessionExpiry returns the value of the "auth.sessionExpiry" site configuration
+// property, or the default value "2160h" if it is unset.
+func AuthSessionExpiry() string {
+	if v := Get().AuthSessionExpiry; v != "" {
+		return v
+	}
+	return "2160h"
+}
+
+// GitMaxConcurrentClones returns the value of the "gitMaxConcurrentClones" site configuration
+// property, or the default value 5 if it is unset.
+func GitMaxConcurrentClones() int {
+	if v := Get().GitMaxConcurrentClones; v != 0 {
+		return v
+	}
+	return 5
+}
+
+// RepoListUpdateInterval returns the value of the "repoListUpdateInterval" site configuration
+// property, or the default value 1 if it is unset.
+func RepoListUpdateInterval() int {
+	if v := Get().RepoListUpdateInterval; v != 0 {
+		return v
+	}
+	return 1
+}
+
+// UpdateChannel returns the value of the "update.channel" site configuration
+// property, or the default value "release" if it is unset.
+func UpdateChannel() string {
+	if v := Get().UpdateChannel; v != "" {
+		return v
+	}
+	return "release"
+}
@@ -3,8 +3,10 @@ package conf
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 
+	"github.com/sourcegraph/sourcegraph/internal/jsonc"
 	"github.com/sourcegraph/sourcegraph/schema"
 )
 
@@ -18,6 +20,16 @@ func diff(before, after *Unified) (fields map[string]struct{}) {
 	return diff
 }
 
+// jsonFields returns the values of the configuration's fields, keyed by the
+// same field names that diff returns.
+func jsonFields(c *Unified) (fields map[string]interface{}) {
+	fields = getJSONFields(c.SiteConfiguration, "")
+	for k, v := range getJSONFields(c.ServiceConnections, "serviceConnections::") {
+		fields[k] = v
+	}
+	return fields
+}
+
 func diffStruct(before, after interface{}, prefix string) (fields map[string]struct{}) {
 	fields = make(map[string]struct{})
 	beforeFields := getJSONFields(before, prefix)
@@ -60,3 +72,59 @@ func parseJSONTag(tag string) string {
 	}
 	return tag
 }
+
+// FieldChange is a change to a single property of the site configuration.
+type FieldChange struct {
+	// Path is the changed property, such as "email.smtp.port" or
+	// "auth.providers". Arrays are compared as a whole.
+	Path string
+
+	// Old and New are the values before and after the change, with secrets
+	// redacted (see RedactSecrets). Old is nil if the property was added, and
+	// New is nil if it was removed.
+	Old, New interface{}
+}
+
+// Diff returns the changes between the raw site configurations old and new,
+// sorted by path. A changed secret is reported with the redacted placeholder
+// as both its old and new value.
+func Diff(oldRaw, newRaw string) ([]FieldChange, error) {
+	var values [4]interface{}
+	for i, input := range []string{oldRaw, newRaw} {
+		if err := jsonc.Unmarshal(input, &values[i]); err != nil {
+			return nil, err
+		}
+
+		redacted, err := RedactSecrets(input)
+		if err != nil {
+			return nil, err
+		}
+		if err := jsonc.Unmarshal(redacted, &values[2+i]); err != nil {
+			return nil, err
+		}
+	}
+
+	var changes []FieldChange
+	for _, c := range diffProperties(nil, values[0], values[1]) {
+		change := FieldChange{Path: pathString(c.jsonxPath())}
+		change.Old, _ = lookupValue(values[2], c.path)
+		change.New, _ = lookupValue(values[3], c.path)
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// lookupValue returns the value at the property path in v.
+func lookupValue(v interface{}, path []string) (interface{}, bool) {
+	for _, p := range path {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if v, ok = obj[p]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
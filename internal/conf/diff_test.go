@@ -90,3 +90,32 @@ func toSlice(m map[string]struct{}) []string {
 	}
 	return s
 }
+
+func TestDiff_fieldChanges(t *testing.T) {
+	old := `{
+  // comment
+  "externalURL": "https://a.example.com",
+  "email.smtp": {"host": "smtp.example.com", "port": 25, "password": "old"},
+  "maxReposToSearch": 10,
+}`
+	new := `{
+  "externalURL": "https://b.example.com",
+  "email.smtp": {"host": "smtp.example.com", "port": 587, "password": "new"},
+  "disableAutoGitUpdates": true,
+}`
+
+	got, err := Diff(old, new)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FieldChange{
+		{Path: "disableAutoGitUpdates", New: true},
+		{Path: "email.smtp.password", Old: RedactedSecret, New: RedactedSecret},
+		{Path: "email.smtp.port", Old: float64(25), New: float64(587)},
+		{Path: "externalURL", Old: "https://a.example.com", New: "https://b.example.com"},
+		{Path: "maxReposToSearch", Old: float64(10)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,3 @@
+package conf
+
+//go:generate env GO111MODULE=on go run -tags generate gen/defaults_generate.go
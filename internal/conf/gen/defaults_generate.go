@@ -0,0 +1,107 @@
+// +build generate
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// skip lists the properties whose accessors are not generated because their
+// zero value is meaningful or they need custom handling.
+var skip = map[string]bool{
+	// 0 and negative values mean "no limit".
+	"maxReposToSearch": true,
+	// Negative values also fall back to the default, see computed.go.
+	"auth.minPasswordLength": true,
+	// The email address is required to send email, so it is checked by
+	// txemail and validation instead of falling back to the default.
+	"email.address": true,
+}
+
+// main generates defaults.go, which contains an accessor for each top-level
+// site configuration property that has a scalar type and a non-zero default
+// value in the site configuration JSON Schema. The accessors return the
+// default value when the property is unset.
+func main() {
+	var siteSchema struct {
+		Properties map[string]struct {
+			Type    interface{} `json:"type"`
+			Default interface{} `json:"default"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal([]byte(schema.SiteSchemaJSON), &siteSchema); err != nil {
+		log.Fatal(err)
+	}
+
+	fields := map[string]reflect.StructField{}
+	t := reflect.TypeOf(schema.SiteConfiguration{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		fields[name] = t.Field(i)
+	}
+
+	names := make([]string, 0, len(siteSchema.Properties))
+	for name := range siteSchema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by defaults_generate.go. DO NOT EDIT.\n\npackage conf\n")
+	for _, name := range names {
+		prop := siteSchema.Properties[name]
+		field, ok := fields[name]
+		if skip[name] || !ok || prop.Default == nil || reflect.ValueOf(prop.Default).IsZero() {
+			continue
+		}
+
+		var def, zero string
+		switch field.Type.Kind() {
+		case reflect.Int:
+			f, ok := prop.Default.(float64)
+			if !ok {
+				log.Fatalf("%s: default %v is not an integer", name, prop.Default)
+			}
+			def, zero = fmt.Sprint(int(f)), "0"
+		case reflect.String:
+			s, ok := prop.Default.(string)
+			if !ok {
+				log.Fatalf("%s: default %v is not a string", name, prop.Default)
+			}
+			def, zero = fmt.Sprintf("%q", s), `""`
+		default:
+			// Only scalar properties whose zero value means "unset" are
+			// supported.
+			continue
+		}
+
+		fmt.Fprintf(&buf, `
+// %[1]s returns the value of the %[2]q site configuration
+// property, or the default value %[3]s if it is unset.
+func %[1]s() %[4]s {
+	if v := Get().%[1]s; v != %[5]s {
+		return v
+	}
+	return %[3]s
+}
+`, field.Name, name, def, field.Type, zero)
+	}
+
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile("defaults.go", out, 0666); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,116 @@
+package conf
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/internal/conf/conftypes"
+	"github.com/sourcegraph/sourcegraph/internal/env"
+)
+
+// HealthCheck checks that a critical subsystem works with the given
+// configuration. It returns an error if the subsystem would fail to come back
+// after the configuration is applied.
+type HealthCheck func(ctx context.Context, c *Unified) error
+
+// ContributeHealthCheck adds the named health check to the checks that
+// (*Server).Edit and (*Server).EditBatch run after writing an edit when safe
+// edits are enabled (see Server.HealthCheckTimeout).
+//
+// It may only be called at init time.
+func ContributeHealthCheck(name string, check HealthCheck) {
+	contributedHealthChecks = append(contributedHealthChecks, healthCheck{name: name, check: check})
+}
+
+type healthCheck struct {
+	name  string
+	check HealthCheck
+}
+
+var contributedHealthChecks []healthCheck
+
+var healthCheckTimeout = env.Get("SITE_CONFIG_HEALTH_CHECK_TIMEOUT", "", "If set, site configuration edits are reverted automatically if the health checks do not pass within this duration (such as 30s) after the edit is applied.")
+
+// EditRevertedError is returned by (*Server).Edit and (*Server).EditBatch when
+// the edit was written but a health check failed afterwards, so the previous
+// configuration was restored.
+type EditRevertedError struct {
+	// Check is the name of the health check that failed.
+	Check string
+	Err   error
+}
+
+func (e *EditRevertedError) Error() string {
+	return fmt.Sprintf("configuration edit was reverted because health check %q failed: %s", e.Check, e.Err)
+}
+
+// checkEdit runs the contributed health checks against the configuration that
+// was just written, if safe edits are enabled. If a check fails, the previous
+// configuration is written back and an *EditRevertedError is returned.
+//
+// written is the configuration that the edit wrote, and version its version if
+// the source is versioned. The edit is only reverted while it is still the
+// latest configuration.
+func (s *Server) checkEdit(ctx context.Context, previous, written conftypes.RawUnified, version *ConfigurationVersion) error {
+	if s.HealthCheckTimeout <= 0 || len(contributedHealthChecks) == 0 {
+		return nil
+	}
+
+	// Write waits for the server to reload the configuration, so the last
+	// valid configuration is the one that was just written.
+	current := s.store.LastValid()
+	checkCtx, cancel := context.WithTimeout(ctx, s.HealthCheckTimeout)
+	defer cancel()
+	for _, c := range contributedHealthChecks {
+		err := c.check(checkCtx, current)
+		if err == nil {
+			continue
+		}
+
+		// Revert using the caller's context, which isn't subject to the health
+		// check timeout. If someone else wrote the configuration in the
+		// meantime, the revert fails with ErrNewerEdit and their change is
+		// kept.
+		if werr := s.revert(ctx, previous, written, version); werr != nil {
+			return errors.Wrapf(werr, "reverting configuration edit after health check %q failed (%s)", c.name, err)
+		}
+		return &EditRevertedError{Check: c.name, Err: err}
+	}
+	return nil
+}
+
+// revert writes previous if the latest configuration is still written. It
+// returns ErrNewerEdit otherwise.
+func (s *Server) revert(ctx context.Context, previous, written conftypes.RawUnified, version *ConfigurationVersion) error {
+	if version != nil {
+		_, err := s.write(ctx, previous, version)
+		return err
+	}
+
+	// Without versions, a write can still land between this read and the
+	// revert, but one made while the health checks ran is kept.
+	latest, _, err := s.readLatest(ctx)
+	if err != nil {
+		return err
+	}
+	if latest.Site != written.Site || latest.Critical != written.Critical {
+		return ErrNewerEdit
+	}
+	_, err = s.write(ctx, previous, nil)
+	return err
+}
+
+// parseHealthCheckTimeout returns the duration in SITE_CONFIG_HEALTH_CHECK_TIMEOUT,
+// or 0 (which disables safe edits) if it is unset.
+func parseHealthCheckTimeout() time.Duration {
+	if healthCheckTimeout == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(healthCheckTimeout)
+	if err != nil {
+		panic(fmt.Sprintf("invalid SITE_CONFIG_HEALTH_CHECK_TIMEOUT %q: %s", healthCheckTimeout, err))
+	}
+	return d
+}
@@ -0,0 +1,136 @@
+package conf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/jsonx"
+	"github.com/sourcegraph/sourcegraph/internal/conf/conftypes"
+)
+
+func TestServerEdit_healthCheck(t *testing.T) {
+	orig := contributedHealthChecks
+	contributedHealthChecks = []healthCheck{{
+		name: "maxReposToSearch",
+		check: func(ctx context.Context, c *Unified) error {
+			if c.MaxReposToSearch > 10 {
+				return errors.New("too many")
+			}
+			return nil
+		},
+	}}
+	defer func() { contributedHealthChecks = orig }()
+
+	ctx := context.Background()
+	site := func(maxReposToSearch int) string {
+		return fmt.Sprintf("{\n  \"maxReposToSearch\": %d\n}", maxReposToSearch)
+	}
+	edit := func(s *Server, value int) error {
+		return s.EditBatch(ctx, EditSourcePackage("conf"), func(current *Unified, raw conftypes.RawUnified) ([]PropertyEdit, error) {
+			return []PropertyEdit{{Path: jsonx.PropertyPath("maxReposToSearch"), Value: value}}, nil
+		})
+	}
+
+	t.Run("passed", func(t *testing.T) {
+		source := &memorySource{raw: conftypes.RawUnified{Critical: "{}", Site: site(1)}}
+		s := newTestServer(t, source)
+		s.HealthCheckTimeout = time.Second
+
+		if err := edit(s, 2); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := source.raw.Site, site(2); got != want {
+			t.Errorf("got site config %q, want %q", got, want)
+		}
+	})
+
+	t.Run("reverted", func(t *testing.T) {
+		source := &memorySource{raw: conftypes.RawUnified{Critical: "{}", Site: site(1)}}
+		s := newTestServer(t, source)
+		s.HealthCheckTimeout = time.Second
+
+		err := edit(s, 20)
+		if e, ok := err.(*EditRevertedError); !ok || e.Check != "maxReposToSearch" {
+			t.Fatalf("got error %v, want *EditRevertedError for maxReposToSearch", err)
+		}
+		if got, want := source.raw.Site, site(1); got != want {
+			t.Errorf("got site config %q, want the previous config %q", got, want)
+		}
+		if got, want := s.Raw().Site, site(1); got != want {
+			t.Errorf("got reloaded site config %q, want the previous config %q", got, want)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		source := &memorySource{raw: conftypes.RawUnified{Critical: "{}", Site: site(1)}}
+		s := newTestServer(t, source)
+
+		if err := edit(s, 20); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := source.raw.Site, site(20); got != want {
+			t.Errorf("got site config %q, want %q", got, want)
+		}
+	})
+}
+
+func TestServerEdit_healthCheckConcurrentWrite(t *testing.T) {
+	ctx := context.Background()
+	site := func(maxReposToSearch int) string {
+		return fmt.Sprintf("{\n  \"maxReposToSearch\": %d\n}", maxReposToSearch)
+	}
+
+	for name, newSource := range map[string]func(raw conftypes.RawUnified) (ConfigurationSource, *memorySource, func(site string)){
+		"versioned": func(raw conftypes.RawUnified) (ConfigurationSource, *memorySource, func(string)) {
+			source := &versionedSource{memorySource: &memorySource{raw: raw}}
+			return source, source.memorySource, source.concurrentWrite
+		},
+		"unversioned": func(raw conftypes.RawUnified) (ConfigurationSource, *memorySource, func(string)) {
+			source := &memorySource{raw: raw}
+			return source, source, func(site string) {
+				source.mu.Lock()
+				source.raw.Site = site
+				source.mu.Unlock()
+			}
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			source, memory, concurrentWrite := newSource(conftypes.RawUnified{Critical: "{}", Site: site(1)})
+
+			orig := contributedHealthChecks
+			contributedHealthChecks = []healthCheck{{
+				name: "maxReposToSearch",
+				check: func(ctx context.Context, c *Unified) error {
+					// Someone else fixes the configuration while the check
+					// runs.
+					concurrentWrite(site(3))
+					return errors.New("too many")
+				},
+			}}
+			defer func() { contributedHealthChecks = orig }()
+
+			s := NewServer(source)
+			if _, err := s.store.MaybeUpdate(memory.raw); err != nil {
+				t.Fatal(err)
+			}
+			s.Start()
+			s.HealthCheckTimeout = time.Second
+
+			err := s.EditBatch(ctx, EditSourcePackage("conf"), func(current *Unified, raw conftypes.RawUnified) ([]PropertyEdit, error) {
+				return []PropertyEdit{{Path: jsonx.PropertyPath("maxReposToSearch"), Value: 20}}, nil
+			})
+			if err == nil {
+				t.Fatal("got no error, want an error")
+			}
+			if _, ok := err.(*EditRevertedError); ok {
+				t.Fatalf("got %v, want the revert to fail", err)
+			}
+			if got, want := memory.raw.Site, site(3); got != want {
+				t.Errorf("got site config %q, want the concurrent change %q", got, want)
+			}
+		})
+	}
+}
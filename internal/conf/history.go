@@ -0,0 +1,106 @@
+package conf
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Revision is a single recorded write of the site configuration.
+type Revision struct {
+	ID           int32      // the unique ID of this revision
+	AuthorUserID *int32     // the user who wrote this revision, or nil if unknown
+	EditSource   EditSource // where the write came from, or "" if unknown
+	Previous     string     // the raw site configuration before this revision, if known
+	Contents     string     // the raw site configuration as of this revision
+	CreatedAt    time.Time  // the date when this revision was written
+}
+
+// ConfigurationHistorySource is implemented by a ConfigurationSource that
+// records every write to the site configuration.
+type ConfigurationHistorySource interface {
+	// History returns at most limit of the most recent revisions, newest
+	// first.
+	History(ctx context.Context, limit int) ([]*Revision, error)
+
+	// Revision returns the revision with the given ID, or nil if there is no
+	// such revision.
+	Revision(ctx context.Context, id int32) (*Revision, error)
+}
+
+// ErrNoHistory is returned by History when the configuration source does not
+// record history.
+var ErrNoHistory = errors.New("configuration source does not record history")
+
+// History returns at most limit of the most recent revisions of the site
+// configuration, newest first. An error is returned if limit is negative.
+func (s *Server) History(ctx context.Context, limit int) ([]*Revision, error) {
+	if limit < 0 {
+		return nil, fmt.Errorf("history limit must not be negative, got %d", limit)
+	}
+	h, ok := s.Source.(ConfigurationHistorySource)
+	if !ok {
+		return nil, ErrNoHistory
+	}
+	return h.History(ctx, limit)
+}
+
+// Rollback restores the site configuration to the contents of the revision
+// with the given ID. Pending migrations (see ContributeMigration) are applied
+// to the restored configuration, and it is validated first: an error is
+// returned if it has any problems. It is then written like an edit, so
+// concurrent changes and failed health checks are handled as in Edit.
+func (s *Server) Rollback(ctx context.Context, id int32) error {
+	h, ok := s.Source.(ConfigurationHistorySource)
+	if !ok {
+		return ErrNoHistory
+	}
+
+	rev, err := h.Revision(ctx, id)
+	if err != nil {
+		return err
+	}
+	if rev == nil {
+		return fmt.Errorf("site configuration revision %d not found", id)
+	}
+
+	raw := s.Raw()
+	restored := raw
+	restored.Site, err = migrateSite(rev.Contents)
+	if err != nil {
+		return err
+	}
+
+	problems, err := Validate(restored)
+	if err != nil {
+		return errors.Wrap(err, "validating site configuration revision")
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("site configuration revision %d is invalid: %s", id, strings.Join(problems.Messages(), "; "))
+	}
+
+	return s.writeEdited(ctx, raw, restored)
+}
+
+// History implements ConfigurationHistorySource. Reads of history are never
+// cached.
+func (c *cachedConfigurationSource) History(ctx context.Context, limit int) ([]*Revision, error) {
+	h, ok := c.source.(ConfigurationHistorySource)
+	if !ok {
+		return nil, ErrNoHistory
+	}
+	return h.History(ctx, limit)
+}
+
+// Revision implements ConfigurationHistorySource. Reads of revisions are never
+// cached.
+func (c *cachedConfigurationSource) Revision(ctx context.Context, id int32) (*Revision, error) {
+	h, ok := c.source.(ConfigurationHistorySource)
+	if !ok {
+		return nil, ErrNoHistory
+	}
+	return h.Revision(ctx, id)
+}
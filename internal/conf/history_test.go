@@ -0,0 +1,114 @@
+package conf
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/conf/conftypes"
+)
+
+type memorySource struct {
+	mu         sync.Mutex
+	raw        conftypes.RawUnified
+	editSource EditSource
+	revisions  map[int32]*Revision
+}
+
+func (s *memorySource) Read(ctx context.Context) (conftypes.RawUnified, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.raw, nil
+}
+
+func (s *memorySource) Write(ctx context.Context, input conftypes.RawUnified) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.raw = input
+	s.editSource = EditSourceFromContext(ctx)
+	return nil
+}
+
+func (s *memorySource) History(ctx context.Context, limit int) ([]*Revision, error) {
+	return nil, nil
+}
+
+func (s *memorySource) Revision(ctx context.Context, id int32) (*Revision, error) {
+	return s.revisions[id], nil
+}
+
+func newTestServer(t *testing.T, source *memorySource) *Server {
+	t.Helper()
+
+	s := NewServer(source)
+	if _, err := s.store.MaybeUpdate(source.raw); err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+	return s
+}
+
+func TestServerHistory_negativeLimit(t *testing.T) {
+	s := NewServer(&memorySource{})
+	if _, err := s.History(context.Background(), -1); err == nil {
+		t.Error("got no error for a negative limit")
+	}
+}
+
+func TestServerRollback(t *testing.T) {
+	source := &memorySource{
+		raw: conftypes.RawUnified{Critical: "{}", Site: `{"maxReposToSearch": 2}`},
+		revisions: map[int32]*Revision{
+			1: {ID: 1, Contents: `{"maxReposToSearch": 1}`},
+			2: {ID: 2, Contents: `{"maxReposToSearch": 1, "doesNotExist": true}`},
+		},
+	}
+	s := newTestServer(t, source)
+	ctx := context.Background()
+
+	if err := s.Rollback(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.Raw().Site, `{"maxReposToSearch": 1}`; got != want {
+		t.Errorf("got site config %q, want %q", got, want)
+	}
+
+	err := s.Rollback(ctx, 2)
+	if err == nil || !strings.Contains(err.Error(), "is invalid") {
+		t.Errorf("got error %v, want invalid revision error", err)
+	}
+
+	err = s.Rollback(ctx, 3)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("got error %v, want not found error", err)
+	}
+
+	if got, want := s.Raw().Site, `{"maxReposToSearch": 1}`; got != want {
+		t.Errorf("got site config %q after failed rollbacks, want %q", got, want)
+	}
+}
+
+func TestServerRollback_migrated(t *testing.T) {
+	orig := contributedMigrations
+	contributedMigrations = []Migration{RenameProperty("automation.readAccess.enabled", "campaigns.readAccess.enabled")}
+	defer func() { contributedMigrations = orig }()
+
+	source := &memorySource{
+		raw: conftypes.RawUnified{Critical: "{}", Site: `{"campaigns.readAccess.enabled": false}`},
+		revisions: map[int32]*Revision{
+			1: {ID: 1, Contents: `{"automation.readAccess.enabled": true}`},
+		},
+	}
+	s := newTestServer(t, source)
+
+	if err := s.Rollback(WithEditSource(context.Background(), EditSourceUI), 1); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.Raw().Site, "{\n  \"campaigns.readAccess.enabled\": true\n}"; got != want {
+		t.Errorf("got site config %q, want the migrated revision %q", got, want)
+	}
+	if got, want := source.editSource, EditSourceUI; got != want {
+		t.Errorf("got edit source %q, want %q", got, want)
+	}
+}
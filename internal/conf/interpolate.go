@@ -0,0 +1,82 @@
+package conf
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/internal/env"
+	"github.com/sourcegraph/sourcegraph/internal/lazyregexp"
+)
+
+var siteConfigAllowedEnvVars = parseAllowedEnvVars(env.Get("SITE_CONFIG_ALLOWED_ENV_VARS", "", "Comma-separated list of environment variables that may be referenced as ${NAME} in string values of the site configuration."))
+
+func parseAllowedEnvVars(s string) map[string]bool {
+	allowed := map[string]bool{}
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			allowed[name] = true
+		}
+	}
+	return allowed
+}
+
+var envReferencePattern = lazyregexp.New(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// interpolateEnv replaces references of the form ${NAME} in s with the value
+// of the environment variable NAME. Only references to allowed variables that
+// are set are replaced; all others are left as is. A reference may be escaped
+// as $${NAME}, which is replaced with the literal ${NAME}.
+func interpolateEnv(s string, allowed map[string]bool, lookupEnv func(string) (string, bool)) string {
+	return envReferencePattern.ReplaceAllStringFunc(s, func(ref string) string {
+		if strings.HasPrefix(ref, "$$") {
+			return ref[1:]
+		}
+		name := ref[2 : len(ref)-1]
+		if !allowed[name] {
+			return ref
+		}
+		if value, ok := lookupEnv(name); ok {
+			return value
+		}
+		return ref
+	})
+}
+
+// interpolateEnvJSON applies interpolateEnv to every string value (but not
+// object key) in the JSON document data.
+func interpolateEnvJSON(data []byte, allowed map[string]bool, lookupEnv func(string) (string, bool)) ([]byte, error) {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+
+	var walk func(v interface{}) interface{}
+	walk = func(v interface{}) interface{} {
+		switch v := v.(type) {
+		case string:
+			return interpolateEnv(v, allowed, lookupEnv)
+		case []interface{}:
+			for i := range v {
+				v[i] = walk(v[i])
+			}
+		case map[string]interface{}:
+			for k := range v {
+				v[k] = walk(v[k])
+			}
+		}
+		return v
+	}
+
+	return json.Marshal(walk(v))
+}
+
+// interpolateSiteConfigEnv interpolates the environment variables allowed by
+// SITE_CONFIG_ALLOWED_ENV_VARS into the JSON document data. It is a no-op if
+// no variables are allowed.
+func interpolateSiteConfigEnv(data []byte) ([]byte, error) {
+	if len(siteConfigAllowedEnvVars) == 0 {
+		return data, nil
+	}
+	return interpolateEnvJSON(data, siteConfigAllowedEnvVars, os.LookupEnv)
+}
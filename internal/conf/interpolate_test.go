@@ -0,0 +1,51 @@
+package conf
+
+import (
+	"testing"
+)
+
+func TestInterpolateEnv(t *testing.T) {
+	allowed := parseAllowedEnvVars("GITHUB_TOKEN, EMPTY")
+	lookupEnv := func(name string) (string, bool) {
+		switch name {
+		case "GITHUB_TOKEN":
+			return "s3cr3t", true
+		case "EMPTY":
+			return "", true
+		case "OTHER":
+			return "other", true
+		}
+		return "", false
+	}
+
+	tests := map[string]string{
+		"${GITHUB_TOKEN}":          "s3cr3t",
+		"token ${GITHUB_TOKEN}!":   "token s3cr3t!",
+		"${EMPTY}":                 "",
+		"${OTHER}":                 "${OTHER}",
+		"${UNSET}":                 "${UNSET}",
+		"$${GITHUB_TOKEN}":         "${GITHUB_TOKEN}",
+		"$GITHUB_TOKEN":            "$GITHUB_TOKEN",
+		"${GITHUB_TOKEN":           "${GITHUB_TOKEN",
+		"${1INVALID}":              "${1INVALID}",
+		"${EMPTY}${GITHUB_TOKEN}x": "s3cr3tx",
+	}
+	for input, want := range tests {
+		if got := interpolateEnv(input, allowed, lookupEnv); got != want {
+			t.Errorf("interpolateEnv(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestInterpolateEnvJSON(t *testing.T) {
+	allowed := parseAllowedEnvVars("TOKEN")
+	lookupEnv := func(name string) (string, bool) { return "value", true }
+
+	got, err := interpolateEnvJSON([]byte(`{"${TOKEN}": ["${TOKEN}", {"a": "${TOKEN}"}], "n": 1}`), allowed, lookupEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"${TOKEN}":["value",{"a":"value"}],"n":1}`; string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,53 @@
+package conf
+
+import (
+	"net"
+	"net/url"
+)
+
+// LintRule checks that the site configuration follows a best practice. It
+// returns a warning for each violation. Unlike validation problems, warnings
+// are about configuration that works but is likely a mistake.
+type LintRule func(Unified) []string
+
+// ContributeLintRule adds the lint rule to the rules that Lint evaluates.
+//
+// It may only be called at init time.
+func ContributeLintRule(rule LintRule) {
+	contributedLintRules = append(contributedLintRules, rule)
+}
+
+var contributedLintRules = []LintRule{lintExternalURL}
+
+// Lint evaluates the lint rules against the site configuration and returns
+// the warnings.
+func Lint(site string) (warnings []string, err error) {
+	raw := Raw()
+	raw.Site = site
+	cfg, err := ParseConfig(raw)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range contributedLintRules {
+		warnings = append(warnings, rule(*cfg)...)
+	}
+	return warnings, nil
+}
+
+// lintExternalURL warns when users access Sourcegraph over an unencrypted
+// connection from another machine.
+func lintExternalURL(c Unified) []string {
+	u, err := url.Parse(c.ExternalURL)
+	if err != nil || u.Scheme != "http" {
+		// Invalid URLs are reported by validation.
+		return nil
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return []string{"externalURL uses http instead of https, so access tokens and session cookies are sent unencrypted"}
+}
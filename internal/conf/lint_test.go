@@ -0,0 +1,48 @@
+package conf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	orig := contributedLintRules
+	defer func() { contributedLintRules = orig }()
+	ContributeLintRule(func(c Unified) []string {
+		if c.MaxReposToSearch > 100 {
+			return []string{"maxReposToSearch is high"}
+		}
+		return nil
+	})
+
+	tests := map[string]struct {
+		site string
+		want []string
+	}{
+		"none": {
+			site: `{"externalURL": "https://sourcegraph.example.com", "maxReposToSearch": 10}`,
+		},
+		"contributed rule": {
+			site: `{"maxReposToSearch": 1000}`,
+			want: []string{"maxReposToSearch is high"},
+		},
+		"http externalURL": {
+			site: `{"externalURL": "http://sourcegraph.example.com"}`,
+			want: []string{"externalURL uses http instead of https, so access tokens and session cookies are sent unencrypted"},
+		},
+		"http localhost": {
+			site: `{"externalURL": "http://127.0.0.1:3080"}`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := Lint(test.site)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got warnings %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/jsonx"
+	"github.com/sourcegraph/sourcegraph/internal/conf/conftypes"
+)
+
+// ErrNewerEdit is returned by a VersionedConfigurationSource's WriteIfUpToDate
+// method (and by Write, if the source checks for this) when the configuration
+// was changed by someone else since it was read. (*Server).Edit merges the
+// edit with the change and retries when it sees this error.
+var ErrNewerEdit = errors.New("someone else has already applied a newer edit")
+
+// EditConflictError is returned by (*Server).Edit when the configuration was
+// changed concurrently and those changes touch the same properties as the
+// edit.
+type EditConflictError struct {
+	// Paths are the conflicting properties, such as "auth.providers".
+	Paths []string
+}
+
+func (e *EditConflictError) Error() string {
+	return fmt.Sprintf("configuration was changed concurrently: conflicting changes to %s", strings.Join(e.Paths, ", "))
+}
+
+// propertyChange is a change to a single property between two JSON values.
+// Objects are compared property by property, and every other value (including
+// arrays) is compared as a whole.
+type propertyChange struct {
+	path   []string
+	value  interface{}
+	remove bool
+}
+
+func (c propertyChange) jsonxPath() jsonx.Path { return jsonx.PropertyPath(c.path...) }
+
+// diffProperties returns the changes that turn old into new.
+func diffProperties(path []string, old, new interface{}) (changes []propertyChange) {
+	oldObj, oldIsObj := old.(map[string]interface{})
+	newObj, newIsObj := new.(map[string]interface{})
+	if !oldIsObj || !newIsObj {
+		if !reflect.DeepEqual(old, new) {
+			changes = append(changes, propertyChange{path: path, value: new})
+		}
+		return changes
+	}
+
+	for k, ov := range oldObj {
+		p := append(path[:len(path):len(path)], k)
+		if nv, ok := newObj[k]; ok {
+			changes = append(changes, diffProperties(p, ov, nv)...)
+		} else {
+			changes = append(changes, propertyChange{path: p, remove: true})
+		}
+	}
+	for k, nv := range newObj {
+		if _, ok := oldObj[k]; !ok {
+			changes = append(changes, propertyChange{path: append(path[:len(path):len(path)], k), value: nv})
+		}
+	}
+	return changes
+}
+
+// isPrefix reports whether a is a prefix of (or equal to) b.
+func isPrefix(a, b []string) bool {
+	if len(a) > len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func parseValue(input string) (interface{}, error) {
+	root, errs := jsonx.ParseTree(input, jsonx.ParseOptions{Comments: true, TrailingCommas: true})
+	if len(errs) > 0 || root == nil {
+		return nil, errors.New("invalid JSON")
+	}
+	return jsonx.NodeValue(*root), nil
+}
+
+// merge3 returns the result of applying the changes from base to ours on top of
+// theirs. Comments and formatting of theirs are preserved outside of the
+// changed properties. If ours and theirs both changed the same property
+// differently, an *EditConflictError is returned.
+func merge3(base, ours, theirs string) (string, error) {
+	if theirs == base {
+		return ours, nil
+	}
+	if ours == base {
+		return theirs, nil
+	}
+
+	var values [3]interface{}
+	for i, input := range []string{base, ours, theirs} {
+		v, err := parseValue(input)
+		if err != nil {
+			return "", err
+		}
+		values[i] = v
+	}
+	ourChanges := diffProperties(nil, values[0], values[1])
+	theirChanges := diffProperties(nil, values[0], values[2])
+	sort.Slice(ourChanges, func(i, j int) bool {
+		return strings.Join(ourChanges[i].path, "\x00") < strings.Join(ourChanges[j].path, "\x00")
+	})
+
+	var (
+		edits     []PropertyEdit
+		conflicts []string
+	)
+	for _, oc := range ourChanges {
+		apply := true
+		for _, tc := range theirChanges {
+			if !isPrefix(oc.path, tc.path) && !isPrefix(tc.path, oc.path) {
+				continue
+			}
+			if len(oc.path) == len(tc.path) && oc.remove == tc.remove && reflect.DeepEqual(oc.value, tc.value) {
+				// Both made the same change.
+				apply = false
+				continue
+			}
+			conflicts = append(conflicts, pathString(oc.jsonxPath()))
+			break
+		}
+		if apply {
+			edits = append(edits, PropertyEdit{Path: oc.jsonxPath(), Value: oc.value, Remove: oc.remove})
+		}
+	}
+	if len(conflicts) > 0 {
+		return "", &EditConflictError{Paths: conflicts}
+	}
+	return applyPropertyEdits(theirs, edits)
+}
+
+// mergeRaw is like merge3 for the site and critical configuration.
+func mergeRaw(base, ours, theirs conftypes.RawUnified) (conftypes.RawUnified, error) {
+	site, err := merge3(base.Site, ours.Site, theirs.Site)
+	if err != nil {
+		return conftypes.RawUnified{}, err
+	}
+	critical, err := merge3(base.Critical, ours.Critical, theirs.Critical)
+	if err != nil {
+		return conftypes.RawUnified{}, err
+	}
+	return conftypes.RawUnified{Site: site, Critical: critical}, nil
+}
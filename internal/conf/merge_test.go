@@ -0,0 +1,235 @@
+package conf
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/jsonx"
+	"github.com/sourcegraph/sourcegraph/internal/conf/conftypes"
+)
+
+func TestMerge3(t *testing.T) {
+	tests := map[string]struct {
+		base, ours, theirs string
+		want               string
+		wantConflicts      []string
+	}{
+		"only ours changed": {
+			base:   `{"a": 1}`,
+			ours:   `{"a": 2}`,
+			theirs: `{"a": 1}`,
+			want:   `{"a": 2}`,
+		},
+		"only theirs changed": {
+			base:   `{"a": 1}`,
+			ours:   `{"a": 1}`,
+			theirs: `{"a": 1, "b": 2}`,
+			want:   `{"a": 1, "b": 2}`,
+		},
+		"different properties": {
+			base: `{
+  // comment
+  "a": 1,
+  "b": {"x": 1, "y": 1}
+}`,
+			ours: `{
+  "a": 2,
+  "b": {"x": 1, "y": 1}
+}`,
+			theirs: `{
+  // comment
+  "a": 1,
+  "b": {"x": 1, "y": 2}
+}`,
+			want: `{
+  // comment
+  "a": 2,
+  "b": {"x": 1, "y": 2}
+}`,
+		},
+		"same change": {
+			base:   `{"a": 1}`,
+			ours:   `{"a": 2}`,
+			theirs: `{"a": 2}`,
+			want:   `{"a": 2}`,
+		},
+		"same property": {
+			base:          `{"a": 1}`,
+			ours:          `{"a": 2}`,
+			theirs:        `{"a": 3}`,
+			wantConflicts: []string{"a"},
+		},
+		"removed parent": {
+			base:          `{"a": {"x": 1}}`,
+			ours:          `{"a": {"x": 2}}`,
+			theirs:        `{}`,
+			wantConflicts: []string{"a.x"},
+		},
+		"array element": {
+			base:          `{"auth.providers": [{"type": "a"}]}`,
+			ours:          `{"auth.providers": [{"type": "b"}]}`,
+			theirs:        `{"auth.providers": [{"type": "a"}, {"type": "c"}]}`,
+			wantConflicts: []string{"auth.providers"},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := merge3(test.base, test.ours, test.theirs)
+			if test.wantConflicts != nil {
+				e, ok := err.(*EditConflictError)
+				if !ok {
+					t.Fatalf("got error %v, want *EditConflictError", err)
+				}
+				if !reflect.DeepEqual(e.Paths, test.wantConflicts) {
+					t.Errorf("got conflicts %q, want %q", e.Paths, test.wantConflicts)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got\n%s\nwant\n%s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestServerEdit_concurrentChange(t *testing.T) {
+	ctx := context.Background()
+	edit := func(s *Server, source *memorySource, concurrent string) error {
+		return s.EditBatch(ctx, EditSourcePackage("conf"), func(current *Unified, raw conftypes.RawUnified) ([]PropertyEdit, error) {
+			// Simulate another frontend writing the configuration after this
+			// edit read it.
+			source.mu.Lock()
+			source.raw.Site = concurrent
+			source.mu.Unlock()
+			return []PropertyEdit{{Path: jsonx.PropertyPath("maxReposToSearch"), Value: 2}}, nil
+		})
+	}
+
+	t.Run("merged", func(t *testing.T) {
+		source := &memorySource{raw: conftypes.RawUnified{Critical: "{}", Site: `{"maxReposToSearch": 1}`}}
+		s := newTestServer(t, source)
+
+		concurrent := `{
+  "maxReposToSearch": 1,
+  "disableAutoGitUpdates": true
+}`
+		if err := edit(s, source, concurrent); err != nil {
+			t.Fatal(err)
+		}
+		want := `{
+  "maxReposToSearch": 2,
+  "disableAutoGitUpdates": true
+}`
+		if got := s.Raw().Site; got != want {
+			t.Errorf("got site config %q, want %q", got, want)
+		}
+		if got, want := source.editSource, EditSourcePackage("conf"); got != want {
+			t.Errorf("got edit source %q, want %q", got, want)
+		}
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		source := &memorySource{raw: conftypes.RawUnified{Critical: "{}", Site: `{"maxReposToSearch": 1}`}}
+		s := newTestServer(t, source)
+
+		err := edit(s, source, `{"maxReposToSearch": 3}`)
+		if _, ok := err.(*EditConflictError); !ok {
+			t.Fatalf("got error %v, want *EditConflictError", err)
+		}
+		if got, want := source.raw.Site, `{"maxReposToSearch": 3}`; got != want {
+			t.Errorf("got site config %q, want the concurrent change %q", got, want)
+		}
+	})
+}
+
+// versionedSource is a memorySource that counts its writes, so that writes can
+// be made conditional on the configuration not having changed.
+type versionedSource struct {
+	*memorySource
+	version int32
+
+	// afterRead, if set, is called once after the next ReadVersion, to
+	// simulate another frontend writing the configuration in the meantime.
+	afterRead func()
+}
+
+func (s *versionedSource) Write(ctx context.Context, input conftypes.RawUnified) error {
+	s.mu.Lock()
+	version := ConfigurationVersion{Site: s.version}
+	s.mu.Unlock()
+	_, err := s.WriteIfUpToDate(ctx, input, version)
+	return err
+}
+
+func (s *versionedSource) ReadVersion(ctx context.Context) (conftypes.RawUnified, ConfigurationVersion, error) {
+	s.mu.Lock()
+	raw, version, afterRead := s.raw, ConfigurationVersion{Site: s.version}, s.afterRead
+	s.afterRead = nil
+	s.mu.Unlock()
+
+	if afterRead != nil {
+		afterRead()
+	}
+	return raw, version, nil
+}
+
+func (s *versionedSource) WriteIfUpToDate(ctx context.Context, input conftypes.RawUnified, version ConfigurationVersion) (ConfigurationVersion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if version.Site != s.version {
+		return ConfigurationVersion{}, ErrNewerEdit
+	}
+	s.version++
+	s.raw = input
+	s.editSource = EditSourceFromContext(ctx)
+	return ConfigurationVersion{Site: s.version}, nil
+}
+
+// concurrentWrite writes site as another frontend would.
+func (s *versionedSource) concurrentWrite(site string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version++
+	s.raw.Site = site
+}
+
+func TestServerEdit_racingWrite(t *testing.T) {
+	source := &versionedSource{memorySource: &memorySource{raw: conftypes.RawUnified{Critical: "{}", Site: `{"maxReposToSearch": 1}`}}}
+	s := NewServer(source)
+	if _, err := s.store.MaybeUpdate(source.raw); err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+
+	// The concurrent write lands after the edit read the latest configuration
+	// but before it is written, so the first write must fail and the edit be
+	// merged again.
+	source.afterRead = func() {
+		source.concurrentWrite(`{
+  "maxReposToSearch": 1,
+  "disableAutoGitUpdates": true
+}`)
+	}
+
+	err := s.EditBatch(context.Background(), EditSourcePackage("conf"), func(current *Unified, raw conftypes.RawUnified) ([]PropertyEdit, error) {
+		return []PropertyEdit{{Path: jsonx.PropertyPath("maxReposToSearch"), Value: 2}}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{
+  "maxReposToSearch": 2,
+  "disableAutoGitUpdates": true
+}`
+	if got := source.raw.Site; got != want {
+		t.Errorf("got site config %q, want %q", got, want)
+	}
+	if got, want := source.version, int32(2); got != want {
+		t.Errorf("got version %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,86 @@
+package conf
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/jsonx"
+)
+
+// Migration migrates a deprecated site configuration field to its
+// replacement.
+type Migration struct {
+	// Description is shown to site admins as a validation problem while the
+	// migration is pending.
+	Description string
+
+	// Edits returns the property edits that migrate the site configuration with
+	// the given parse tree, or none if the migration does not apply to it.
+	Edits func(root *jsonx.Node) []PropertyEdit
+}
+
+// ContributeMigration adds the site configuration migration to the migrations
+// that (*Server).Write, (*Server).Edit and (*Server).Rollback apply
+// automatically, so a configuration is migrated the next time it is saved.
+// Until then, a pending migration is reported as a validation problem.
+//
+// It may only be called at init time.
+func ContributeMigration(m Migration) {
+	contributedMigrations = append(contributedMigrations, m)
+}
+
+var contributedMigrations []Migration
+
+// RenameProperty returns a migration that renames the top-level site
+// configuration property from to to. If both properties are set, the value of
+// to is kept.
+func RenameProperty(from, to string) Migration {
+	return Migration{
+		Description: fmt.Sprintf("The `%s` property was renamed to `%s`. Use that new property name instead. The old name is deprecated and will be removed in a future release.", from, to),
+		Edits: func(root *jsonx.Node) []PropertyEdit {
+			old := jsonx.FindNodeAtLocation(root, jsonx.PropertyPath(from))
+			if old == nil {
+				return nil
+			}
+			var edits []PropertyEdit
+			if jsonx.FindNodeAtLocation(root, jsonx.PropertyPath(to)) == nil {
+				edits = append(edits, PropertyEdit{Path: jsonx.PropertyPath(to), Value: jsonx.NodeValue(*old)})
+			}
+			return append(edits, PropertyEdit{Path: jsonx.PropertyPath(from), Remove: true})
+		},
+	}
+}
+
+func parseSiteTree(site string) *jsonx.Node {
+	root, _ := jsonx.ParseTree(site, jsonx.ParseOptions{Comments: true, TrailingCommas: true})
+	return root
+}
+
+// pendingMigrations returns the contributed migrations that apply to the raw
+// site configuration.
+func pendingMigrations(site string) (pending []Migration) {
+	root := parseSiteTree(site)
+	for _, m := range contributedMigrations {
+		if len(m.Edits(root)) > 0 {
+			pending = append(pending, m)
+		}
+	}
+	return pending
+}
+
+// migrateSite applies the contributed migrations to the raw site configuration
+// in order. Each migration is computed against the output of the previous one.
+func migrateSite(site string) (string, error) {
+	for _, m := range contributedMigrations {
+		edits := m.Edits(parseSiteTree(site))
+		if len(edits) == 0 {
+			continue
+		}
+		var err error
+		site, err = applyPropertyEdits(site, edits)
+		if err != nil {
+			return "", errors.Wrapf(err, "migration %q", m.Description)
+		}
+	}
+	return site, nil
+}
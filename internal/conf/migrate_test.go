@@ -0,0 +1,89 @@
+package conf
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/conf/conftypes"
+)
+
+func TestMigrateSite(t *testing.T) {
+	orig := contributedMigrations
+	contributedMigrations = []Migration{RenameProperty("a", "b")}
+	defer func() { contributedMigrations = orig }()
+
+	tests := map[string]struct {
+		input string
+		want  string
+	}{
+		"not applicable": {
+			input: `{"c": 1}`,
+			want:  `{"c": 1}`,
+		},
+		"rename": {
+			input: `{
+  // keep me
+  "c": 2,
+  "a": {"x": 1}
+}`,
+			want: `{
+  // keep me
+  "c": 2,
+  "b": {
+    "x": 1
+  }
+}`,
+		},
+		"new name already set": {
+			input: `{"a": 1, "b": 2}`,
+			want: `{
+  "b": 2}`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := migrateSite(test.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got\n%s\nwant\n%s", got, test.want)
+			}
+			if pending := pendingMigrations(got); len(pending) != 0 {
+				t.Errorf("got %d pending migrations after migrating, want 0", len(pending))
+			}
+		})
+	}
+}
+
+func TestValidate_pendingMigrations(t *testing.T) {
+	orig := contributedMigrations
+	contributedMigrations = []Migration{RenameProperty("automation.readAccess.enabled", "campaigns.readAccess.enabled")}
+	defer func() { contributedMigrations = orig }()
+
+	problems, err := Validate(conftypes.RawUnified{Site: `{"automation.readAccess.enabled": true}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{contributedMigrations[0].Description}
+	if got := problems.Messages(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got problems %q, want %q", got, want)
+	}
+}
+
+func TestServerWrite_migrates(t *testing.T) {
+	orig := contributedMigrations
+	contributedMigrations = []Migration{RenameProperty("automation.readAccess.enabled", "campaigns.readAccess.enabled")}
+	defer func() { contributedMigrations = orig }()
+
+	source := &memorySource{raw: conftypes.RawUnified{Critical: "{}", Site: "{}"}}
+	s := newTestServer(t, source)
+
+	if err := s.Write(context.Background(), conftypes.RawUnified{Critical: "{}", Site: `{"automation.readAccess.enabled": true}`}); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.Raw().Site, "{\n  \"campaigns.readAccess.enabled\": true\n}"; got != want {
+		t.Errorf("got site config %q, want the migrated configuration %q", got, want)
+	}
+}
@@ -3,6 +3,7 @@ package conf
 import (
 	"encoding/json"
 
+	"github.com/sourcegraph/jsonx"
 	"github.com/sourcegraph/sourcegraph/internal/conf/conftypes"
 	"github.com/sourcegraph/sourcegraph/internal/jsonc"
 	"github.com/sourcegraph/sourcegraph/schema"
@@ -16,6 +17,10 @@ func parseConfigData(data string, cfg interface{}) error {
 		if err != nil {
 			return err
 		}
+		data, err = interpolateSiteConfigEnv(data)
+		if err != nil {
+			return err
+		}
 		if err := json.Unmarshal(data, cfg); err != nil {
 			return err
 		}
@@ -76,3 +81,24 @@ func NeedRestartToApply(before, after *Unified) bool {
 	}
 	return false
 }
+
+// RawLookup returns the value at path in the raw configuration text, which may
+// contain comments and trailing commas, and whether it is set. The value is
+// decoded as by json.Unmarshal into an interface{}, so numbers are float64 and
+// objects are map[string]interface{}. Environment variable references are not
+// substituted.
+//
+// Unlike ParseConfig, RawLookup tolerates syntax errors elsewhere in the text
+// and doesn't decode the whole configuration into schema types, so it is
+// suitable for inspecting configuration that may be invalid.
+func RawLookup(raw string, path jsonx.Path) (value interface{}, found bool) {
+	root, _ := jsonx.ParseTree(raw, jsonx.ParseOptions{Comments: true, TrailingCommas: true})
+	node := jsonx.FindNodeAtLocation(root, path)
+	if node == nil {
+		return nil, false
+	}
+	if err := jsonc.Unmarshal(string([]rune(raw)[node.Offset:node.Offset+node.Length]), &value); err != nil {
+		return nil, false
+	}
+	return value, true
+}
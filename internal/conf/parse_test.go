@@ -0,0 +1,63 @@
+package conf
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/jsonx"
+)
+
+func TestRawLookup(t *testing.T) {
+	const raw = `{
+  // comment
+  "maxReposToSearch": 10,
+  "auth.providers": [
+    {"type": "builtin", "allowSignup": true,},
+  ],
+  "email.smtp": {"host": "${SMTP_HOST}"},
+  "invalid": ,
+}`
+	tests := map[string]struct {
+		path      jsonx.Path
+		want      interface{}
+		wantFound bool
+	}{
+		"number": {
+			path:      jsonx.PropertyPath("maxReposToSearch"),
+			want:      float64(10),
+			wantFound: true,
+		},
+		"array element property": {
+			path:      jsonx.MakePath("auth.providers", 0, "type"),
+			want:      "builtin",
+			wantFound: true,
+		},
+		"object": {
+			path:      jsonx.MakePath("auth.providers", 0),
+			want:      map[string]interface{}{"type": "builtin", "allowSignup": true},
+			wantFound: true,
+		},
+		"not substituted": {
+			path:      jsonx.PropertyPath("email.smtp", "host"),
+			want:      "${SMTP_HOST}",
+			wantFound: true,
+		},
+		"unset": {
+			path: jsonx.PropertyPath("email.address"),
+		},
+		"out of range": {
+			path: jsonx.MakePath("auth.providers", 1),
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, found := RawLookup(raw, test.path)
+			if found != test.wantFound {
+				t.Fatalf("got found %v, want %v", found, test.wantFound)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package conf
+
+import "context"
+
+// EditSource describes where an edit of the configuration came from. It is
+// recorded alongside each write so that the configuration history can tell
+// human edits apart from programmatic ones.
+type EditSource string
+
+const (
+	// EditSourceUI is an edit made by a user in the web app.
+	EditSourceUI EditSource = "ui"
+
+	// EditSourceAPI is an edit made using the API, such as with an access
+	// token.
+	EditSourceAPI EditSource = "api"
+
+	// EditSourceFile is an edit loaded from a configuration file, such as
+	// SITE_CONFIG_FILE.
+	EditSourceFile EditSource = "file"
+)
+
+// EditSourcePackage returns the EditSource for a programmatic edit made by the
+// named package, such as "campaigns".
+func EditSourcePackage(name string) EditSource {
+	return EditSource("package:" + name)
+}
+
+type editSourceKey struct{}
+
+// WithEditSource returns a copy of ctx whose configuration writes are recorded
+// as coming from source.
+func WithEditSource(ctx context.Context, source EditSource) context.Context {
+	return context.WithValue(ctx, editSourceKey{}, source)
+}
+
+// EditSourceFromContext returns the EditSource set with WithEditSource, or ""
+// if there is none. A ConfigurationSource's Write method uses it to record
+// where the write came from.
+func EditSourceFromContext(ctx context.Context) EditSource {
+	source, _ := ctx.Value(editSourceKey{}).(EditSource)
+	return source
+}
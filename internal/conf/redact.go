@@ -0,0 +1,290 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/jsonx"
+	"github.com/sourcegraph/sourcegraph/internal/conf/conftypes"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// RedactedSecret is the placeholder that replaces the values of secret site
+// configuration fields in redacted configuration.
+const RedactedSecret = "REDACTED"
+
+// secretPaths returns the paths of the fields marked with `"secret": true` in
+// the site configuration JSON Schema. A path segment "[]" matches every element
+// of an array.
+var secretPaths = func() func() [][]string {
+	var (
+		once  sync.Once
+		paths [][]string
+	)
+	return func() [][]string {
+		once.Do(func() {
+			var err error
+			paths, err = schemaSecretPaths(schema.SiteSchemaJSON)
+			if err != nil {
+				panic(fmt.Sprintf("parsing site configuration schema: %s", err))
+			}
+
+			// The config of a scoped override is a partial site
+			// configuration, so it has the same secret fields.
+			for _, path := range paths[:len(paths):len(paths)] {
+				paths = append(paths, append([]string{"scopedOverrides", "[]", "config"}, path...))
+			}
+		})
+		return paths
+	}
+}()
+
+// schemaSecretPaths returns the paths of the fields marked with `"secret":
+// true` in the given JSON Schema.
+func schemaSecretPaths(schemaJSON string) ([][]string, error) {
+	var root map[string]interface{}
+	if err := json.Unmarshal([]byte(schemaJSON), &root); err != nil {
+		return nil, err
+	}
+	definitions, _ := root["definitions"].(map[string]interface{})
+
+	var paths [][]string
+	found := map[string]bool{}
+	var walk func(node interface{}, path []string, seen map[string]bool)
+	walk = func(node interface{}, path []string, seen map[string]bool) {
+		n, ok := node.(map[string]interface{})
+		if !ok {
+			return
+		}
+
+		if ref, ok := n["$ref"].(string); ok {
+			const prefix = "#/definitions/"
+			if len(ref) > len(prefix) && ref[:len(prefix)] == prefix && !seen[ref] {
+				seen[ref] = true
+				walk(definitions[ref[len(prefix):]], path, seen)
+				delete(seen, ref)
+			}
+		}
+
+		if secret, _ := n["secret"].(bool); secret {
+			if key := strings.Join(path, "\x00"); !found[key] {
+				found[key] = true
+				paths = append(paths, append([]string(nil), path...))
+			}
+		}
+
+		if props, ok := n["properties"].(map[string]interface{}); ok {
+			for name, prop := range props {
+				walk(prop, append(path, name), seen)
+			}
+		}
+
+		switch items := n["items"].(type) {
+		case map[string]interface{}:
+			walk(items, append(path, "[]"), seen)
+		case []interface{}:
+			for _, item := range items {
+				walk(item, append(path, "[]"), seen)
+			}
+		}
+
+		for _, keyword := range []string{"oneOf", "anyOf", "allOf"} {
+			if subschemas, ok := n[keyword].([]interface{}); ok {
+				for _, s := range subschemas {
+					walk(s, path, seen)
+				}
+			}
+		}
+	}
+	walk(root, nil, map[string]bool{})
+	return paths, nil
+}
+
+// secretLocations returns the locations of the non-empty string values in the
+// parse tree root that match the given secret paths.
+func secretLocations(root *jsonx.Node, paths [][]string) (locations []jsonx.Path) {
+	var collect func(node *jsonx.Node, pattern []string, location jsonx.Path)
+	collect = func(node *jsonx.Node, pattern []string, location jsonx.Path) {
+		if node == nil {
+			return
+		}
+		if len(pattern) == 0 {
+			if s, ok := node.Value.(string); ok && node.Type == jsonx.String && s != "" {
+				locations = append(locations, append(jsonx.Path(nil), location...))
+			}
+			return
+		}
+
+		segment, rest := pattern[0], pattern[1:]
+		if segment == "[]" {
+			if node.Type != jsonx.Array {
+				return
+			}
+			for i, child := range node.Children {
+				collect(child, rest, append(location, jsonx.Segment{Index: i}))
+			}
+			return
+		}
+
+		if node.Type != jsonx.Object {
+			return
+		}
+		for _, prop := range node.Children {
+			if len(prop.Children) == 2 && prop.Children[0].Value == segment {
+				collect(prop.Children[1], rest, append(location, jsonx.Segment{IsProperty: true, Property: segment}))
+			}
+		}
+	}
+
+	for _, path := range paths {
+		collect(root, path, nil)
+	}
+	return locations
+}
+
+// RedactSecrets returns the raw site configuration input with the values of all
+// secret fields replaced by RedactedSecret. Comments and formatting are
+// preserved.
+func RedactSecrets(input string) (string, error) {
+	root, _ := jsonx.ParseTree(input, jsonx.ParseOptions{Comments: true, TrailingCommas: true})
+
+	var edits []jsonx.Edit
+	for _, location := range secretLocations(root, secretPaths()) {
+		edits = append(edits, replaceString(jsonx.FindNodeAtLocation(root, location), RedactedSecret))
+	}
+	return applyEdits(input, edits)
+}
+
+// UnredactSecrets returns the raw site configuration input with every secret
+// field whose value is RedactedSecret restored to its value in the raw site
+// configuration current. It is used to preserve unmodified secrets when a
+// configuration that was read with RedactSecrets is written back.
+//
+// A secret inside an array element is only restored if the rest of the
+// element is unchanged, so that reordering or editing array elements can never
+// move a secret to a different element. In that case an error is returned and
+// the secret must be entered again.
+func UnredactSecrets(input, current string) (string, error) {
+	options := jsonx.ParseOptions{Comments: true, TrailingCommas: true}
+	inputRoot, _ := jsonx.ParseTree(input, options)
+	currentRoot, _ := jsonx.ParseTree(current, options)
+
+	var edits []jsonx.Edit
+	for _, location := range secretLocations(inputRoot, secretPaths()) {
+		node := jsonx.FindNodeAtLocation(inputRoot, location)
+		if node.Value != RedactedSecret {
+			continue
+		}
+
+		orig := jsonx.FindNodeAtLocation(currentRoot, location)
+		if orig == nil || orig.Type != jsonx.String {
+			return "", fmt.Errorf("no existing value for redacted secret %s", pathString(location))
+		}
+
+		if inArray(location) {
+			parent := location[:len(location)-1]
+			if !sameExceptRedacted(jsonx.FindNodeAtLocation(inputRoot, parent), jsonx.FindNodeAtLocation(currentRoot, parent)) {
+				return "", fmt.Errorf("cannot restore redacted secret %s because %s changed, enter the secret again", pathString(location), pathString(parent))
+			}
+		}
+
+		edits = append(edits, replaceString(node, orig.Value.(string)))
+	}
+
+	output, err := applyEdits(input, edits)
+	if err != nil {
+		return "", errors.Wrap(err, "restoring redacted secrets")
+	}
+	return output, nil
+}
+
+// replaceString returns the edit that replaces the string value node with
+// value. Unlike jsonx.ComputePropertyEdit, it leaves the formatting of the
+// surrounding object untouched.
+func replaceString(node *jsonx.Node, value string) jsonx.Edit {
+	content, _ := json.Marshal(value)
+	return jsonx.Edit{Offset: node.Offset, Length: node.Length, Content: string(content)}
+}
+
+// applyEdits applies the non-overlapping edits to input in any order.
+func applyEdits(input string, edits []jsonx.Edit) (string, error) {
+	sort.Slice(edits, func(i, j int) bool { return edits[i].Offset < edits[j].Offset })
+	return jsonx.ApplyEdits(input, edits...)
+}
+
+func inArray(location jsonx.Path) bool {
+	for _, s := range location {
+		if !s.IsProperty {
+			return true
+		}
+	}
+	return false
+}
+
+// sameExceptRedacted reports whether the objects a and b have the same
+// properties, ignoring properties whose value in a is RedactedSecret.
+func sameExceptRedacted(a, b *jsonx.Node) bool {
+	if a == nil || b == nil || a.Type != jsonx.Object || b.Type != jsonx.Object {
+		return false
+	}
+
+	av, _ := jsonx.NodeValue(*a).(map[string]interface{})
+	bv, _ := jsonx.NodeValue(*b).(map[string]interface{})
+	for k, v := range av {
+		if v == RedactedSecret {
+			delete(av, k)
+			delete(bv, k)
+		}
+	}
+	return reflect.DeepEqual(av, bv)
+}
+
+// GetRedacted returns a copy of the configuration with the values of all
+// secret fields, and the password in the PostgreSQL DSN of the service
+// connections, replaced by RedactedSecret. Unlike Get, the returned value is
+// safe to show to users or to log.
+func GetRedacted() (*Unified, error) {
+	c := Get()
+
+	data, err := json.Marshal(c.SiteConfiguration)
+	if err != nil {
+		return nil, err
+	}
+	redacted, err := RedactSecrets(string(data))
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Unified{
+		ServiceConnections: conftypes.ServiceConnections{
+			GitServers:  append([]string(nil), c.ServiceConnections.GitServers...),
+			PostgresDSN: redactDSN(c.ServiceConnections.PostgresDSN),
+		},
+	}
+	if err := json.Unmarshal([]byte(redacted), &r.SiteConfiguration); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+var dsnPassword = regexp.MustCompile(`(^|\s)password=('(\\.|[^'])*'|\S*)`)
+
+// redactDSN returns the PostgreSQL data source name with its password, if
+// any, replaced by RedactedSecret. Both URLs and key/value connection strings
+// are supported.
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), RedactedSecret)
+		}
+		return u.String()
+	}
+	return dsnPassword.ReplaceAllString(dsn, "${1}password="+RedactedSecret)
+}
@@ -0,0 +1,113 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+var siteSchema = struct {
+	mu sync.Mutex
+
+	// properties are the contributed top-level property schemas, keyed by
+	// property name.
+	properties map[string]json.RawMessage
+
+	// version is incremented each time a property is contributed.
+	version int
+
+	// cached is the merged site schema for version, or "" if it has not been
+	// computed yet.
+	cached string
+}{}
+
+// ContributeSiteSchemaProperty adds a top-level property with the given JSON
+// Schema to the site configuration schema. The property is accepted by
+// validation and offered by the site configuration editor's autocompletion.
+//
+// Unlike the other Contribute functions, it may be called at any time, such as
+// when a custom language server is registered at runtime. Contributing the
+// same property again replaces its schema.
+func ContributeSiteSchemaProperty(name string, propertySchema json.RawMessage) error {
+	var v map[string]interface{}
+	if err := json.Unmarshal(propertySchema, &v); err != nil {
+		return errors.Wrapf(err, "invalid JSON Schema for site configuration property %q", name)
+	}
+	if _, ok := builtinSiteSchemaProperties()[name]; ok {
+		return fmt.Errorf("site configuration property %q is already defined", name)
+	}
+
+	siteSchema.mu.Lock()
+	defer siteSchema.mu.Unlock()
+	if siteSchema.properties == nil {
+		siteSchema.properties = map[string]json.RawMessage{}
+	}
+	siteSchema.properties[name] = propertySchema
+	siteSchema.version++
+	siteSchema.cached = ""
+	return nil
+}
+
+// SiteSchema returns the site configuration JSON Schema, including the
+// contributed properties, and a version that changes each time a property is
+// contributed.
+func SiteSchema() (schemaJSON string, version int) {
+	siteSchema.mu.Lock()
+	defer siteSchema.mu.Unlock()
+	if len(siteSchema.properties) == 0 {
+		return schema.SiteSchemaJSON, siteSchema.version
+	}
+	if siteSchema.cached == "" {
+		merged, err := mergeSiteSchema(siteSchema.properties)
+		if err != nil {
+			// The built-in schema and the contributed properties were checked
+			// to be valid JSON, so this should never happen.
+			panic(err)
+		}
+		siteSchema.cached = merged
+	}
+	return siteSchema.cached, siteSchema.version
+}
+
+var (
+	builtinSiteSchemaPropertiesOnce sync.Once
+	builtinSiteSchemaPropertiesMap  map[string]json.RawMessage
+)
+
+func builtinSiteSchemaProperties() map[string]json.RawMessage {
+	builtinSiteSchemaPropertiesOnce.Do(func() {
+		var s struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		}
+		if err := json.Unmarshal([]byte(schema.SiteSchemaJSON), &s); err != nil {
+			panic(err)
+		}
+		builtinSiteSchemaPropertiesMap = s.Properties
+	})
+	return builtinSiteSchemaPropertiesMap
+}
+
+// mergeSiteSchema returns the built-in site schema with the given top-level
+// property schemas added.
+func mergeSiteSchema(properties map[string]json.RawMessage) (string, error) {
+	var s map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(schema.SiteSchemaJSON), &s); err != nil {
+		return "", err
+	}
+	merged := make(map[string]json.RawMessage, len(builtinSiteSchemaProperties())+len(properties))
+	for name, p := range builtinSiteSchemaProperties() {
+		merged[name] = p
+	}
+	for name, p := range properties {
+		merged[name] = p
+	}
+	var err error
+	if s["properties"], err = json.Marshal(merged); err != nil {
+		return "", err
+	}
+	b, err := json.MarshalIndent(s, "", "  ")
+	return string(b), err
+}
@@ -0,0 +1,61 @@
+package conf
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/conf/conftypes"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestContributeSiteSchemaProperty(t *testing.T) {
+	defer func() {
+		siteSchema.properties = nil
+		siteSchema.cached = ""
+	}()
+
+	if got, _ := SiteSchema(); got != schema.SiteSchemaJSON {
+		t.Fatal("got modified site schema, want the built-in schema when nothing is contributed")
+	}
+	_, before := SiteSchema()
+
+	if err := ContributeSiteSchemaProperty("maxReposToSearch", json.RawMessage(`{"type": "string"}`)); err == nil {
+		t.Error("got no error contributing a built-in property")
+	}
+	if err := ContributeSiteSchemaProperty("x.enabled", json.RawMessage(`{`)); err == nil {
+		t.Error("got no error contributing an invalid schema")
+	}
+	if err := ContributeSiteSchemaProperty("x.enabled", json.RawMessage(`{"type": "boolean"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	schemaJSON, after := SiteSchema()
+	if after == before {
+		t.Errorf("got unchanged version %d after contributing a property", after)
+	}
+	var s struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal([]byte(schemaJSON), &s); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Properties["maxReposToSearch"]; !ok {
+		t.Error("built-in property missing from merged schema")
+	}
+	var got interface{}
+	if err := json.Unmarshal(s.Properties["x.enabled"], &got); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]interface{}{"type": "boolean"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got contributed property schema %v, want %v", got, want)
+	}
+
+	problems, err := Validate(conftypes.RawUnified{Site: `{"x.enabled": "yes"}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"x.enabled: Invalid type. Expected: boolean, given: string"}; !reflect.DeepEqual(problems.Messages(), want) {
+		t.Errorf("got problems %q, want %q", problems.Messages(), want)
+	}
+}
@@ -0,0 +1,161 @@
+package conf
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+// Scope identifies the repository and code host that a site configuration
+// property is read for, so that the matching entries of the "scopedOverrides"
+// site configuration property apply. The zero Scope matches no overrides.
+type Scope struct {
+	// Repo is the name of the repository, such as "github.com/foo/bar".
+	Repo string
+
+	// ExternalServiceKind is the kind of the repository's code host, such as
+	// "GITHUB".
+	ExternalServiceKind string
+}
+
+// Effective returns the value of the site configuration field at path for the
+// given scope. It is the value of the last entry in "scopedOverrides" that
+// matches scope and sets the field, or the top-level value if there is none.
+//
+// The path and the returned value are the same as for WatchPath, such as
+// "search.index.enabled" or "experimentalFeatures::<name>". Service
+// connections cannot be overridden.
+func Effective(path string, scope Scope) interface{} {
+	return effective(Get(), path, scope)
+}
+
+func effective(c *Unified, path string, scope Scope) interface{} {
+	value := jsonFields(c)[path]
+	for _, o := range c.ScopedOverrides {
+		if !overrideMatches(o, scope) {
+			continue
+		}
+		if v, ok := overrideValue(o.Config, path); ok {
+			value = v
+		}
+	}
+	return value
+}
+
+// overrideMatches reports whether the override applies to scope. An override
+// must match all of the criteria it specifies.
+func overrideMatches(o *schema.ScopedOverride, scope Scope) bool {
+	if o == nil || (len(o.Repos) == 0 && len(o.ExternalServiceKinds) == 0) {
+		return false
+	}
+	if len(o.ExternalServiceKinds) > 0 {
+		var found bool
+		for _, kind := range o.ExternalServiceKinds {
+			if scope.ExternalServiceKind != "" && strings.EqualFold(kind, scope.ExternalServiceKind) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(o.Repos) > 0 {
+		var found bool
+		for _, pattern := range o.Repos {
+			if re := compileRepoPattern(pattern); re != nil && scope.Repo != "" && re.MatchString(scope.Repo) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+var repoPatterns = struct {
+	sync.Mutex
+	m map[string]*regexp.Regexp
+}{m: map[string]*regexp.Regexp{}}
+
+// compileRepoPattern returns the compiled regular expression, or nil if it is
+// invalid. Invalid patterns are reported by the JSON Schema validation.
+func compileRepoPattern(pattern string) *regexp.Regexp {
+	repoPatterns.Lock()
+	defer repoPatterns.Unlock()
+	re, ok := repoPatterns.m[pattern]
+	if !ok {
+		re, _ = regexp.Compile(pattern)
+		repoPatterns.m[pattern] = re
+	}
+	return re
+}
+
+// overrideValue returns the value of the field at path in the override's
+// config, and whether the config sets it.
+func overrideValue(config map[string]interface{}, path string) (interface{}, bool) {
+	if strings.HasPrefix(path, "serviceConnections::") {
+		return nil, false
+	}
+	key := path
+	if name := strings.TrimPrefix(path, "experimentalFeatures::"); name != path {
+		key = "experimentalFeatures"
+		features, _ := config[key].(map[string]interface{})
+		if _, ok := features[name]; !ok {
+			return nil, false
+		}
+	} else if _, ok := config[key]; !ok {
+		return nil, false
+	}
+
+	// Decode the value into its Go type by decoding it as a site
+	// configuration.
+	b, err := json.Marshal(map[string]interface{}{key: config[key]})
+	if err != nil {
+		return nil, false
+	}
+	var site schema.SiteConfiguration
+	if err := json.Unmarshal(b, &site); err != nil {
+		return nil, false
+	}
+	return getJSONFields(site, "")[path], true
+}
+
+// validateScopedOverrides validates the config of each scoped override against
+// the site configuration schema.
+func validateScopedOverrides(cfg Unified) (problems Problems) {
+	siteSchemaJSON, _ := SiteSchema()
+	for i, o := range cfg.ScopedOverrides {
+		if o == nil {
+			continue
+		}
+		prefix := fmt.Sprintf("scopedOverrides.%d", i)
+		if _, ok := o.Config["scopedOverrides"]; ok {
+			problems = append(problems, NewSiteProblem(fmt.Sprintf("%s.config: scopedOverrides cannot be overridden", prefix)))
+			continue
+		}
+		b, err := json.Marshal(o.Config)
+		if err != nil {
+			continue
+		}
+		messages, err := doValidate(string(b), siteSchemaJSON)
+		if err != nil {
+			problems = append(problems, NewSiteProblem(fmt.Sprintf("%s.config: %s", prefix, err)))
+			continue
+		}
+		for _, m := range messages {
+			// Errors about the config object itself have the key path "(root)".
+			if m = strings.TrimPrefix(m, "(root)"); !strings.HasPrefix(m, ":") {
+				m = "." + m
+			}
+			problems = append(problems, NewSiteProblem(prefix+".config"+m))
+		}
+	}
+	return problems
+}
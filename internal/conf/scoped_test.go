@@ -0,0 +1,88 @@
+package conf
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/internal/conf/conftypes"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestEffective(t *testing.T) {
+	var site schema.SiteConfiguration
+	if err := json.Unmarshal([]byte(`{
+  "maxReposToSearch": 10,
+  "experimentalFeatures": {"structuralSearch": "disabled"},
+  "scopedOverrides": [
+    {"repos": ["^github\\.com/a/"], "config": {"maxReposToSearch": 20}},
+    {"externalServiceKinds": ["GITHUB"], "config": {"experimentalFeatures": {"structuralSearch": "enabled"}}},
+    {"repos": ["^github\\.com/a/b$"], "externalServiceKinds": ["GITHUB"], "config": {"maxReposToSearch": 30}}
+  ]
+}`), &site); err != nil {
+		t.Fatal(err)
+	}
+	c := &Unified{SiteConfiguration: site}
+
+	tests := map[string]struct {
+		path  string
+		scope Scope
+		want  interface{}
+	}{
+		"no scope": {
+			path: "maxReposToSearch",
+			want: 10,
+		},
+		"repo match": {
+			path:  "maxReposToSearch",
+			scope: Scope{Repo: "github.com/a/c"},
+			want:  20,
+		},
+		"later override takes precedence": {
+			path:  "maxReposToSearch",
+			scope: Scope{Repo: "github.com/a/b", ExternalServiceKind: "GITHUB"},
+			want:  30,
+		},
+		"all criteria must match": {
+			path:  "maxReposToSearch",
+			scope: Scope{Repo: "github.com/a/b", ExternalServiceKind: "GITLAB"},
+			want:  20,
+		},
+		"experimental feature": {
+			path:  "experimentalFeatures::structuralSearch",
+			scope: Scope{ExternalServiceKind: "github"},
+			want:  "enabled",
+		},
+		"override does not set field": {
+			path:  "experimentalFeatures::structuralSearch",
+			scope: Scope{Repo: "github.com/a/c"},
+			want:  "disabled",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := effective(c, test.path, test.scope); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestValidate_scopedOverrides(t *testing.T) {
+	problems, err := Validate(conftypes.RawUnified{Site: `{
+  "scopedOverrides": [
+    {"repos": ["("], "config": {"maxReposToSearch": "x", "notAProperty": 1}}
+  ]
+}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"scopedOverrides.0.repos.0: Does not match format 'regex'",
+		"scopedOverrides.0.config: Additional property notAProperty is not allowed",
+		"scopedOverrides.0.config.maxReposToSearch: Invalid type. Expected: integer, given: string",
+	}
+	if got := problems.Messages(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got problems %q, want %q", got, want)
+	}
+}
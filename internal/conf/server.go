@@ -2,8 +2,10 @@ package conf
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"math/rand"
+	"strings"
 	"sync"
 	"time"
 
@@ -20,10 +22,53 @@ type ConfigurationSource interface {
 	Read(ctx context.Context) (conftypes.RawUnified, error)
 }
 
+// ChangeNotifier is optionally implemented by a ConfigurationSource that can
+// report writes to the configuration, including writes made by other frontend
+// replicas. Changes are then picked up within seconds instead of at the next
+// poll.
+type ChangeNotifier interface {
+	// NotifyChanges registers ch to receive a value whenever the
+	// configuration may have changed. Sends do not block, so a buffered
+	// channel with capacity 1 coalesces changes that arrive while the
+	// receiver is busy.
+	NotifyChanges(ch chan<- struct{})
+}
+
+// ConfigurationVersion identifies the revisions of the critical and site
+// configuration that a VersionedConfigurationSource read or wrote.
+type ConfigurationVersion struct {
+	Critical, Site int32
+}
+
+// VersionedConfigurationSource is optionally implemented by a
+// ConfigurationSource that can make a write conditional on the configuration
+// not having changed since it was read. (*Server).Edit then merges a
+// concurrent change instead of overwriting it.
+type VersionedConfigurationSource interface {
+	// ReadVersion reads the configuration and its version, bypassing any
+	// cache.
+	ReadVersion(ctx context.Context) (conftypes.RawUnified, ConfigurationVersion, error)
+
+	// WriteIfUpToDate writes the configuration if it is still at version, and
+	// returns the version that was written. Otherwise it writes nothing and
+	// returns ErrNewerEdit.
+	WriteIfUpToDate(ctx context.Context, data conftypes.RawUnified, version ConfigurationVersion) (ConfigurationVersion, error)
+}
+
+// ErrUnversioned is returned by ReadVersion and WriteIfUpToDate when the
+// configuration source does not support conditional writes.
+var ErrUnversioned = errors.New("configuration source does not support conditional writes")
+
 // Server provides access and manages modifications to the site configuration.
 type Server struct {
 	Source ConfigurationSource
 
+	// HealthCheckTimeout enables safe edits if it is positive. After Edit or
+	// EditBatch writes an edit, the contributed health checks (see
+	// ContributeHealthCheck) must pass within this duration, or the edit is
+	// reverted.
+	HealthCheckTimeout time.Duration
+
 	store *store
 
 	needRestartMu sync.RWMutex
@@ -55,19 +100,49 @@ func (s *Server) Raw() conftypes.RawUnified {
 	return s.store.Raw()
 }
 
-// Write writes the JSON config file to the config file's path. If the JSON configuration is
-// invalid, an error is returned.
+// Write writes the JSON config file to the config file's path, along with any pending
+// migrations (see ContributeMigration). Secrets that are RedactedSecret keep their
+// current values (see UnredactSecrets). If the JSON configuration is invalid, an error is
+// returned.
 func (s *Server) Write(ctx context.Context, input conftypes.RawUnified) error {
+	site, err := UnredactSecrets(input.Site, s.store.Raw().Site)
+	if err != nil {
+		return err
+	}
+	site, err = migrateSite(site)
+	if err != nil {
+		return err
+	}
+	input.Site = site
+
+	_, err = s.write(ctx, input, nil)
+	return err
+}
+
+// write writes input like Write. If version is not nil, input is only written
+// if the configuration is still at version (see VersionedConfigurationSource),
+// and the version that was written is returned.
+func (s *Server) write(ctx context.Context, input conftypes.RawUnified, version *ConfigurationVersion) (*ConfigurationVersion, error) {
 	// Parse the configuration so that we can diff it (this also validates it
 	// is proper JSON).
 	_, err := ParseConfig(input)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = s.Source.Write(ctx, input)
+	if version == nil {
+		err = s.Source.Write(ctx, input)
+	} else {
+		v, ok := s.Source.(VersionedConfigurationSource)
+		if !ok {
+			return nil, ErrUnversioned
+		}
+		var written ConfigurationVersion
+		written, err = v.WriteIfUpToDate(ctx, input, *version)
+		version = &written
+	}
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Wait for the change to the configuration file to be detected. Otherwise
@@ -77,7 +152,7 @@ func (s *Server) Write(ctx context.Context, input conftypes.RawUnified) error {
 	s.fileWrite <- doneReading
 	<-doneReading
 
-	return nil
+	return version, nil
 }
 
 // Edits describes some JSON edits to apply to site or critical configuration.
@@ -86,7 +161,22 @@ type Edits struct {
 }
 
 // Edit invokes the provided function to compute edits to the site
-// configuration. It then applies and writes them.
+// configuration. It then applies and writes them, along with any pending
+// migrations (see ContributeMigration).
+//
+// If the configuration is changed by someone else in the meantime, the edits
+// are merged with those changes. If both changed the same property, an
+// *EditConflictError is returned and nothing is written.
+//
+// Secrets that the edits leave as RedactedSecret, such as those in the output
+// of GetRedacted, keep their values in the configuration the edits are merged
+// onto (see UnredactSecrets).
+//
+// If safe edits are enabled (see Server.HealthCheckTimeout) and a health check
+// fails after the write, the previous configuration is restored and an
+// *EditRevertedError is returned.
+//
+// The write is recorded as coming from source.
 //
 // The computation function is provided the current configuration, which should
 // NEVER be modified in any way. Always copy values.
@@ -94,7 +184,7 @@ type Edits struct {
 // TODO(slimsag): Currently, edits may only be applied via the frontend. It may
 // make sense to allow non-frontend services to apply edits as well. To do this
 // we would need to pipe writes through the frontend's internal httpapi.
-func (s *Server) Edit(ctx context.Context, computeEdits func(current *Unified, raw conftypes.RawUnified) (Edits, error)) error {
+func (s *Server) Edit(ctx context.Context, source EditSource, computeEdits func(current *Unified, raw conftypes.RawUnified) (Edits, error)) error {
 	// TODO@ggilmore: There is a race condition here (also present in the existing library).
 	// Current and raw could be inconsistent. Another thing to offload to configStore?
 	// Snapshot method?
@@ -116,18 +206,160 @@ func (s *Server) Edit(ctx context.Context, computeEdits func(current *Unified, r
 	if err != nil {
 		return errors.Wrap(err, "jsonx.ApplyEdits Site")
 	}
+	newSite, err = migrateSite(newSite)
+	if err != nil {
+		return err
+	}
 
-	// TODO@ggilmore: Another race condition (also present in the existing library). Locks
-	// aren't held between applying the edits and writing the config file,
-	// so the newConfig could be outdated.
-	err = s.Write(ctx, conftypes.RawUnified{
+	return s.writeEdited(WithEditSource(ctx, source), raw, conftypes.RawUnified{
 		Site:     newSite,
 		Critical: newCritical,
 	})
+}
+
+// maxEditAttempts is the number of times writeEdited tries to write an edit
+// before giving up when the configuration keeps changing concurrently.
+const maxEditAttempts = 3
+
+// writeEdited writes edited, which is the result of editing the raw
+// configuration base. If the configuration was changed by someone else since
+// base was read, the edit is merged with those changes (see merge3) before it
+// is written. An *EditConflictError is returned if the changes conflict.
+//
+// If the source is a VersionedConfigurationSource, the merged configuration is
+// only written if nobody changed it after it was read, and the edit is merged
+// again otherwise. Other sources keep the last write. Redacted secrets in the
+// merged configuration are restored from the latest configuration.
+func (s *Server) writeEdited(ctx context.Context, base, edited conftypes.RawUnified) error {
+	for attempt := 1; ; attempt++ {
+		latest, version, err := s.readLatest(ctx)
+		if err != nil {
+			return errors.Wrap(err, "reading latest configuration")
+		}
+		merged, err := mergeRaw(base, edited, latest)
+		if err != nil {
+			return err
+		}
+		merged.Site, err = UnredactSecrets(merged.Site, latest.Site)
+		if err != nil {
+			return err
+		}
+
+		written, err := s.write(ctx, merged, version)
+		if errors.Cause(err) == ErrNewerEdit && attempt < maxEditAttempts {
+			base, edited = latest, merged
+			continue
+		}
+		if err != nil {
+			return errors.Wrap(err, "conf.Write")
+		}
+		return s.checkEdit(ctx, latest, merged, written)
+	}
+}
+
+// readLatest reads the configuration from the source, bypassing the cache of
+// the frontend's source, so that writeEdited sees concurrent changes as soon
+// as possible. The version is nil if the source is not a
+// VersionedConfigurationSource.
+func (s *Server) readLatest(ctx context.Context) (conftypes.RawUnified, *ConfigurationVersion, error) {
+	source := s.Source
+	if c, ok := source.(*cachedConfigurationSource); ok {
+		source = c.source
+	}
+	if v, ok := source.(VersionedConfigurationSource); ok {
+		raw, version, err := v.ReadVersion(ctx)
+		if err != nil {
+			return raw, nil, err
+		}
+		return raw, &version, nil
+	}
+	raw, err := source.Read(ctx)
+	return raw, nil, err
+}
+
+// PropertyEdit describes an edit to a single property of the site
+// configuration. If Remove is true, the property is removed and Value is
+// ignored.
+type PropertyEdit struct {
+	Path   jsonx.Path
+	Value  interface{}
+	Remove bool
+}
+
+// EditBatch invokes the provided function to compute property edits to the
+// site configuration. Unlike Edit, the edits may target any number of
+// properties: they are applied in order against the site configuration and
+// the result is written out, along with any pending migrations, in a single
+// write-and-reload cycle. Concurrent changes, redacted secrets and failed
+// health checks are handled as in Edit. The write is recorded as coming from source.
+//
+// The computation function is provided the current configuration, which should
+// NEVER be modified in any way. Always copy values.
+func (s *Server) EditBatch(ctx context.Context, source EditSource, computeEdits func(current *Unified, raw conftypes.RawUnified) ([]PropertyEdit, error)) error {
+	current := s.store.LastValid()
+	raw := s.store.Raw()
+
+	edits, err := computeEdits(current, raw)
 	if err != nil {
-		return errors.Wrap(err, "conf.Write")
+		return errors.Wrap(err, "computeEdits")
 	}
-	return nil
+
+	newSite, err := applyPropertyEdits(raw.Site, edits)
+	if err != nil {
+		return err
+	}
+	newSite, err = migrateSite(newSite)
+	if err != nil {
+		return err
+	}
+
+	return s.writeEdited(WithEditSource(ctx, source), raw, conftypes.RawUnified{
+		Site:     newSite,
+		Critical: raw.Critical,
+	})
+}
+
+// applyPropertyEdits applies the property edits to the input JSON in order.
+// Each edit is computed against the output of the previous one, so edits to
+// unrelated properties never invalidate each other's offsets.
+func applyPropertyEdits(input string, edits []PropertyEdit) (string, error) {
+	for _, e := range edits {
+		var (
+			computed []jsonx.Edit
+			err      error
+		)
+		if e.Remove {
+			computed, _, err = jsonx.ComputePropertyRemoval(input, e.Path, FormatOptions)
+		} else {
+			computed, _, err = jsonx.ComputePropertyEdit(input, e.Path, e.Value, nil, FormatOptions)
+		}
+		if err != nil {
+			return "", errors.Wrapf(err, "computing edit for %s", pathString(e.Path))
+		}
+
+		input, err = jsonx.ApplyEdits(input, computed...)
+		if err != nil {
+			return "", errors.Wrapf(err, "jsonx.ApplyEdits %s", pathString(e.Path))
+		}
+	}
+	return input, nil
+}
+
+// pathString returns a human-readable representation of path, such as
+// "auth.providers[0].type".
+func pathString(path jsonx.Path) string {
+	var b strings.Builder
+	for _, s := range path {
+		if s.IsProperty {
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(s.Property)
+		} else {
+			fmt.Fprintf(&b, "[%d]", s.Index)
+		}
+	}
+	return b.String()
 }
 
 // Start initializes the server instance.
@@ -141,6 +373,12 @@ func (s *Server) Start() {
 // server.Write() is called.
 func (s *Server) watchSource() {
 	ctx := context.Background()
+
+	changes := make(chan struct{}, 1)
+	if n, ok := s.Source.(ChangeNotifier); ok {
+		n.NotifyChanges(changes)
+	}
+
 	for {
 		jitter := time.Duration(rand.Int63n(5 * int64(time.Second)))
 
@@ -150,6 +388,12 @@ func (s *Server) watchSource() {
 			// File was changed on FS, so check now.
 		case <-time.After(jitter):
 			// File possibly changed on FS, so check now.
+		case <-changes:
+			// The configuration was written, possibly by another frontend,
+			// so read it now without using a cached read.
+			if c, ok := s.Source.(*cachedConfigurationSource); ok {
+				c.invalidate()
+			}
 		}
 
 		err := s.updateFromSource(ctx)
@@ -0,0 +1,110 @@
+package conf
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/jsonx"
+	"github.com/sourcegraph/sourcegraph/internal/conf/conftypes"
+)
+
+func TestApplyPropertyEdits(t *testing.T) {
+	input := `{
+  // The external URL.
+  "externalURL": "https://example.com",
+  "disablePublicRepoRedirects": true,
+}`
+
+	got, err := applyPropertyEdits(input, []PropertyEdit{
+		{Path: jsonx.PropertyPath("externalURL"), Value: "https://sourcegraph.example.com"},
+		{Path: jsonx.PropertyPath("disablePublicRepoRedirects"), Remove: true},
+		{Path: jsonx.PropertyPath("maxReposToSearch"), Value: 10},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{
+  // The external URL.
+  "externalURL": "https://sourcegraph.example.com",
+  "maxReposToSearch": 10,
+}`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestPathString(t *testing.T) {
+	got := pathString(jsonx.MakePath("auth.providers", 0, "type"))
+	if want := "auth.providers[0].type"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+type notifyingSource struct {
+	*memorySource
+	registered chan chan<- struct{}
+}
+
+func (s *notifyingSource) NotifyChanges(ch chan<- struct{}) { s.registered <- ch }
+
+func TestServer_notifyChanges(t *testing.T) {
+	source := &notifyingSource{
+		memorySource: &memorySource{raw: conftypes.RawUnified{Critical: "{}", Site: `{"maxReposToSearch": 1}`}},
+		registered:   make(chan chan<- struct{}, 1),
+	}
+	cached := &cachedConfigurationSource{source: source, ttl: time.Hour}
+	raw, err := cached.Read(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(cached)
+	if _, err := s.store.MaybeUpdate(raw); err != nil {
+		t.Fatal(err)
+	}
+	s.Start()
+	changes := <-source.registered
+
+	// Simulate a write by another frontend. The cache would hide it for an
+	// hour without the notification.
+	source.mu.Lock()
+	source.raw.Site = `{"maxReposToSearch": 2}`
+	source.mu.Unlock()
+	changes <- struct{}{}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for s.Raw().Site != `{"maxReposToSearch": 2}` {
+		if time.Now().After(deadline) {
+			t.Fatalf("got site config %q, want the notified change", s.Raw().Site)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServerEditBatch_redacted(t *testing.T) {
+	source := &memorySource{raw: conftypes.RawUnified{
+		Critical: "{}",
+		Site:     `{"email.smtp": {"host": "smtp.example.com", "password": "hunter2"}}`,
+	}}
+	s := newTestServer(t, source)
+
+	// Edit the SMTP server config as read from the redacted configuration.
+	err := s.EditBatch(context.Background(), EditSourceUI, func(current *Unified, raw conftypes.RawUnified) ([]PropertyEdit, error) {
+		return []PropertyEdit{{
+			Path:  jsonx.PropertyPath("email.smtp"),
+			Value: map[string]string{"host": "smtp2.example.com", "password": RedactedSecret},
+		}}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ParseConfig(s.Raw())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.EmailSmtp == nil || got.EmailSmtp.Host != "smtp2.example.com" || got.EmailSmtp.Password != "hunter2" {
+		t.Errorf("got SMTP config %+v, want the edited host and the stored password", got.EmailSmtp)
+	}
+}
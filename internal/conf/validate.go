@@ -167,7 +167,8 @@ func (ps Problems) ExternalService() (problems Problems) {
 // Validate validates the configuration against the JSON Schema and other
 // custom validation checks.
 func Validate(input conftypes.RawUnified) (problems Problems, err error) {
-	siteProblems, err := doValidate(input.Site, schema.SiteSchemaJSON)
+	siteSchemaJSON, _ := SiteSchema()
+	siteProblems, err := doValidate(input.Site, siteSchemaJSON)
 	if err != nil {
 		return nil, err
 	}
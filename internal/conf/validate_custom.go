@@ -21,12 +21,44 @@ func ContributeValidator(f Validator) {
 
 var contributedValidators []Validator
 
+// FieldValidator validates the value of a single site configuration field.
+type FieldValidator func(value interface{}) Problems
+
+// ContributeFieldValidator adds a validator for the site configuration field at path to the
+// validation process. It is called with the field's value (which is the zero value of the field's
+// Go type when the field is unset) each time the site configuration is validated. Any problems it
+// returns are shown as validation problems.
+//
+// The path is a JSON field name as accepted by WatchPath, such as "auth.providers".
+//
+// It may only be called at init time.
+func ContributeFieldValidator(path string, f FieldValidator) {
+	contributedFieldValidators = append(contributedFieldValidators, fieldValidator{path: path, validate: f})
+}
+
+type fieldValidator struct {
+	path     string
+	validate FieldValidator
+}
+
+var contributedFieldValidators []fieldValidator
+
 func validateCustomRaw(normalizedInput conftypes.RawUnified) (problems Problems, err error) {
+	site, err := interpolateSiteConfigEnv([]byte(normalizedInput.Site))
+	if err != nil {
+		return nil, err
+	}
+
 	var cfg Unified
-	if err := json.Unmarshal([]byte(normalizedInput.Site), &cfg.SiteConfiguration); err != nil {
+	if err := json.Unmarshal(site, &cfg.SiteConfiguration); err != nil {
 		return nil, err
 	}
-	return validateCustom(cfg), nil
+	problems = validateCustom(cfg)
+
+	for _, m := range pendingMigrations(normalizedInput.Site) {
+		problems = append(problems, NewSiteProblem(m.Description))
+	}
+	return problems, nil
 }
 
 // validateCustom validates the site config using custom validation steps that are not
@@ -62,10 +94,19 @@ func validateCustom(cfg Unified) (problems Problems) {
 		}
 	}
 
+	problems = append(problems, validateScopedOverrides(cfg)...)
+
 	for _, f := range contributedValidators {
 		problems = append(problems, f(cfg)...)
 	}
 
+	if len(contributedFieldValidators) > 0 {
+		fields := jsonFields(&cfg)
+		for _, v := range contributedFieldValidators {
+			problems = append(problems, v.validate(fields[v.path])...)
+		}
+	}
+
 	return problems
 }
 
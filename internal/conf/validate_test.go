@@ -1,6 +1,7 @@
 package conf
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 
@@ -84,6 +85,40 @@ func TestValidateCustom(t *testing.T) {
 	}
 }
 
+func TestContributeFieldValidator(t *testing.T) {
+	defer func(orig []fieldValidator) { contributedFieldValidators = orig }(contributedFieldValidators)
+	contributedFieldValidators = nil
+
+	var got []interface{}
+	ContributeFieldValidator("maxReposToSearch", func(value interface{}) Problems {
+		got = append(got, value)
+		if value.(int) < 0 {
+			return NewSiteProblems("maxReposToSearch must not be negative")
+		}
+		return nil
+	})
+
+	problems, err := validateCustomRaw(conftypes.RawUnified{Site: `{"maxReposToSearch": -1}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"maxReposToSearch must not be negative"}; !reflect.DeepEqual(problems.Messages(), want) {
+		t.Errorf("got problems %q, want %q", problems.Messages(), want)
+	}
+
+	problems, err = validateCustomRaw(conftypes.RawUnified{Site: `{}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) > 0 {
+		t.Errorf("got unexpected problems %q", problems.Messages())
+	}
+
+	if want := []interface{}{-1, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("validator called with %v, want %v", got, want)
+	}
+}
+
 func TestProblems(t *testing.T) {
 	siteProblems := NewSiteProblems(
 		"siteProblem1",
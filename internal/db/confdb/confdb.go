@@ -22,6 +22,9 @@ type Config struct {
 	Contents  string    // the raw JSON content (with comments and trailing commas allowed)
 	CreatedAt time.Time // the date when this config was created
 	UpdatedAt time.Time // the date when this config was updated
+
+	AuthorUserID *int32 // the user who saved this config, or nil if unknown
+	EditSource   string // where the edit that saved this config came from, or "" if unknown
 }
 
 // SiteConfig contains the contents of a site config along with associated metadata.
@@ -43,7 +46,7 @@ var ErrNewerEdit = errors.New("someone else has already applied a newer edit")
 //
 // 🚨 SECURITY: This method does NOT verify the user is an admin. The caller is
 // responsible for ensuring this or that the response never makes it to a user.
-func SiteCreateIfUpToDate(ctx context.Context, lastID *int32, contents string) (latest *SiteConfig, err error) {
+func SiteCreateIfUpToDate(ctx context.Context, lastID *int32, authorUserID *int32, editSource, contents string) (latest *SiteConfig, err error) {
 	tx, done, err := newTransaction(ctx)
 	if err != nil {
 		return nil, err
@@ -58,7 +61,7 @@ func SiteCreateIfUpToDate(ctx context.Context, lastID *int32, contents string) (
 		lastID = newLastID
 	}
 
-	criticalSite, err := createIfUpToDate(ctx, tx, typeSite, lastID, contents)
+	criticalSite, err := createIfUpToDate(ctx, tx, typeSite, lastID, authorUserID, editSource, contents)
 	return (*SiteConfig)(criticalSite), err
 }
 
@@ -71,7 +74,7 @@ func SiteCreateIfUpToDate(ctx context.Context, lastID *int32, contents string) (
 //
 // 🚨 SECURITY: This method does NOT verify the user is an admin. The caller is
 // responsible for ensuring this or that the response never makes it to a user.
-func CriticalCreateIfUpToDate(ctx context.Context, lastID *int32, contents string) (latest *CriticalConfig, err error) {
+func CriticalCreateIfUpToDate(ctx context.Context, lastID *int32, authorUserID *int32, editSource, contents string) (latest *CriticalConfig, err error) {
 	tx, done, err := newTransaction(ctx)
 	if err != nil {
 		return nil, err
@@ -86,10 +89,65 @@ func CriticalCreateIfUpToDate(ctx context.Context, lastID *int32, contents strin
 		lastID = newLastID
 	}
 
-	criticalSite, err := createIfUpToDate(ctx, tx, typeCritical, lastID, contents)
+	criticalSite, err := createIfUpToDate(ctx, tx, typeCritical, lastID, authorUserID, editSource, contents)
 	return (*CriticalConfig)(criticalSite), err
 }
 
+// CreateIfUpToDate saves the given critical and site config contents to the
+// database in a single transaction iff the supplied "lastCritical" and
+// "lastSite" IDs are equal to the ones that were most recently saved to the
+// database. Otherwise nothing is saved and ErrNewerEdit is returned. A config
+// whose contents are unchanged is not saved again, so that the history only
+// contains real edits.
+//
+// The critical and site configs that were most recently saved to the database
+// are returned. An error is returned if either contents is invalid JSON.
+//
+// 🚨 SECURITY: This method does NOT verify the user is an admin. The caller is
+// responsible for ensuring this or that the response never makes it to a user.
+func CreateIfUpToDate(ctx context.Context, lastCritical, lastSite *int32, authorUserID *int32, editSource, critical, site string) (latestCritical *CriticalConfig, latestSite *SiteConfig, err error) {
+	for _, contents := range []string{critical, site} {
+		if err := validateJSON(contents); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	tx, done, err := newTransaction(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer done()
+
+	// Block other writes of either config until the transaction ends, so that
+	// both IDs are still the latest when the configs are saved.
+	if _, err := tx.ExecContext(ctx, "LOCK TABLE critical_and_site_config IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+		return nil, nil, err
+	}
+
+	// Check both IDs before saving either config, so that nothing is saved if
+	// one of them is outdated.
+	c, err := latestIfUpToDate(ctx, tx, typeCritical, confdefaults.Default.Critical, lastCritical)
+	if err != nil {
+		return nil, nil, err
+	}
+	s, err := latestIfUpToDate(ctx, tx, typeSite, confdefaults.Default.Site, lastSite)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if c.Contents != critical {
+		if c, err = createIfUpToDate(ctx, tx, typeCritical, &c.ID, authorUserID, editSource, critical); err != nil {
+			return nil, nil, err
+		}
+	}
+	if s.Contents != site {
+		if s, err = createIfUpToDate(ctx, tx, typeSite, &s.ID, authorUserID, editSource, site); err != nil {
+			return nil, nil, err
+		}
+	}
+	return (*CriticalConfig)(c), (*SiteConfig)(s), nil
+}
+
 // SiteGetLatest returns the site config that was most recently saved to the database.
 // This returns nil, nil if there is not yet a site config in the database.
 //
@@ -132,6 +190,50 @@ func CriticalGetLatest(ctx context.Context) (latest *CriticalConfig, err error)
 	return (*CriticalConfig)(critical), err
 }
 
+// SiteListHistory returns the site configs that were most recently saved to
+// the database, newest first. At most limit configs are returned.
+//
+// 🚨 SECURITY: This method does NOT verify the user is an admin. The caller is
+// responsible for ensuring this or that the response never makes it to a user.
+func SiteListHistory(ctx context.Context, limit int) ([]*SiteConfig, error) {
+	q := sqlf.Sprintf("SELECT s.id, s.type, s.contents, s.created_at, s.updated_at, s.author_user_id, s.edit_source FROM critical_and_site_config s WHERE type=%s ORDER BY id DESC LIMIT %s", typeSite, limit)
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := parseQueryRows(ctx, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]*SiteConfig, 0, len(versions))
+	for _, v := range versions {
+		history = append(history, (*SiteConfig)(v))
+	}
+	return history, nil
+}
+
+// SiteGetByID returns the site config with the given ID. This returns nil, nil
+// if there is no site config with that ID.
+//
+// 🚨 SECURITY: This method does NOT verify the user is an admin. The caller is
+// responsible for ensuring this or that the response never makes it to a user.
+func SiteGetByID(ctx context.Context, id int32) (*SiteConfig, error) {
+	q := sqlf.Sprintf("SELECT s.id, s.type, s.contents, s.created_at, s.updated_at, s.author_user_id, s.edit_source FROM critical_and_site_config s WHERE type=%s AND id=%s", typeSite, id)
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := parseQueryRows(ctx, rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) != 1 {
+		return nil, nil
+	}
+	return (*SiteConfig)(versions[0]), nil
+}
+
 func newTransaction(ctx context.Context) (tx queryable, done func(), err error) {
 	rtx, err := dbconn.Global.BeginTx(ctx, nil)
 	if err != nil {
@@ -161,21 +263,52 @@ func addDefault(ctx context.Context, tx queryable, configType configType, conten
 	}
 
 	// Create the default.
-	latest, err = createIfUpToDate(ctx, tx, configType, nil, contents)
+	latest, err = createIfUpToDate(ctx, tx, configType, nil, nil, "", contents)
 	if err != nil {
 		return nil, err
 	}
 	return &latest.ID, nil
 }
 
-func createIfUpToDate(ctx context.Context, tx queryable, configType configType, lastID *int32, contents string) (latest *Config, err error) {
-	// Validate JSON syntax before saving.
+// latestIfUpToDate returns the config of the given type that was most recently
+// saved to the database, creating the default if there is none yet. It returns
+// ErrNewerEdit if the latest config is not the one with the supplied "lastID".
+func latestIfUpToDate(ctx context.Context, tx queryable, configType configType, defaultContents string, lastID *int32) (*Config, error) {
+	newLastID, err := addDefault(ctx, tx, configType, defaultContents)
+	if err != nil {
+		return nil, err
+	}
+	if newLastID != nil {
+		lastID = newLastID
+	}
+
+	latest, err := getLatest(ctx, tx, configType)
+	if err != nil {
+		return nil, err
+	}
+	if lastID != nil && latest.ID != *lastID {
+		return nil, ErrNewerEdit
+	}
+	return latest, nil
+}
+
+// validateJSON validates the JSON syntax of config contents before saving.
+func validateJSON(contents string) error {
 	if _, errs := jsonx.Parse(contents, jsonx.ParseOptions{Comments: true, TrailingCommas: true}); len(errs) > 0 {
-		return nil, fmt.Errorf("invalid settings JSON: %v", errs)
+		return fmt.Errorf("invalid settings JSON: %v", errs)
+	}
+	return nil
+}
+
+func createIfUpToDate(ctx context.Context, tx queryable, configType configType, lastID *int32, authorUserID *int32, editSource, contents string) (latest *Config, err error) {
+	if err := validateJSON(contents); err != nil {
+		return nil, err
 	}
 
 	new := Config{
-		Contents: contents,
+		Contents:     contents,
+		AuthorUserID: authorUserID,
+		EditSource:   editSource,
 	}
 
 	latest, err = getLatest(ctx, tx, configType)
@@ -188,17 +321,45 @@ func createIfUpToDate(ctx context.Context, tx queryable, configType configType,
 
 	err = tx.QueryRowContext(
 		ctx,
-		"INSERT INTO critical_and_site_config(type, contents) VALUES($1, $2) RETURNING id, created_at, updated_at",
-		configType, new.Contents,
+		"INSERT INTO critical_and_site_config(type, contents, author_user_id, edit_source) VALUES($1, $2, $3, $4) RETURNING id, created_at, updated_at",
+		configType, new.Contents, new.AuthorUserID, new.EditSource,
 	).Scan(&new.ID, &new.CreatedAt, &new.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
+
+	// The notification is delivered when the transaction commits.
+	if _, err := tx.ExecContext(ctx, "NOTIFY "+changesChannel); err != nil {
+		return nil, err
+	}
 	return &new, nil
 }
 
+// changesChannel is the PostgreSQL notification channel on which every write
+// of the critical or site configuration is announced.
+const changesChannel = "critical_and_site_config_changes"
+
+// ListenForChanges calls f whenever the critical or site configuration is
+// written by any frontend. It is also called when a lost connection to the DB
+// is restored, because notifications may have been missed in the meantime.
+//
+// The dataSource is interpreted as in dbconn.ConnectToDB.
+func ListenForChanges(dataSource string, f func()) error {
+	l := dbconn.NewListener(dataSource)
+	if err := l.Listen(changesChannel); err != nil {
+		l.Close()
+		return err
+	}
+	go func() {
+		for range l.Notify {
+			f()
+		}
+	}()
+	return nil
+}
+
 func getLatest(ctx context.Context, tx queryable, configType configType) (*Config, error) {
-	q := sqlf.Sprintf("SELECT s.id, s.type, s.contents, s.created_at, s.updated_at FROM critical_and_site_config s WHERE type=%s ORDER BY id DESC LIMIT 1", configType)
+	q := sqlf.Sprintf("SELECT s.id, s.type, s.contents, s.created_at, s.updated_at, s.author_user_id, s.edit_source FROM critical_and_site_config s WHERE type=%s ORDER BY id DESC LIMIT 1", configType)
 	rows, err := tx.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
 	if err != nil {
 		return nil, err
@@ -219,7 +380,7 @@ func parseQueryRows(ctx context.Context, rows *sql.Rows) ([]*Config, error) {
 	defer rows.Close()
 	for rows.Next() {
 		f := Config{}
-		err := rows.Scan(&f.ID, &f.Type, &f.Contents, &f.CreatedAt, &f.UpdatedAt)
+		err := rows.Scan(&f.ID, &f.Type, &f.Contents, &f.CreatedAt, &f.UpdatedAt, &f.AuthorUserID, &f.EditSource)
 		if err != nil {
 			return nil, err
 		}
@@ -234,6 +395,7 @@ func parseQueryRows(ctx context.Context, rows *sql.Rows) ([]*Config, error) {
 // queryable allows us to reuse the same logic for certain operations both
 // inside and outside an explicit transaction.
 type queryable interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
 	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
 	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
 }
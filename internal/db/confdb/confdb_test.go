@@ -36,7 +36,7 @@ func TestCriticalCreate_RejectInvalidJSON(t *testing.T) {
 
 	malformedJSON := "[This is malformed.}"
 
-	_, err := CriticalCreateIfUpToDate(ctx, nil, malformedJSON)
+	_, err := CriticalCreateIfUpToDate(ctx, nil, nil, "", malformedJSON)
 
 	if err == nil || !strings.Contains(err.Error(), "invalid settings JSON") {
 		t.Fatalf("expected parse error after creating configuration with malformed JSON, got: %+v", err)
@@ -160,7 +160,7 @@ func TestCriticalCreateIfUpToDate(t *testing.T) {
 			dbtesting.SetupGlobalTestDB(t)
 			ctx := context.Background()
 			for _, p := range test.sequence {
-				output, err := CriticalCreateIfUpToDate(ctx, &p.input.lastID, p.input.contents)
+				output, err := CriticalCreateIfUpToDate(ctx, &p.input.lastID, nil, "", p.input.contents)
 				if err != nil {
 					if err == p.expected.err {
 						continue
@@ -198,3 +198,130 @@ func TestCriticalCreateIfUpToDate(t *testing.T) {
 		})
 	}
 }
+
+func TestSiteListHistory(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	dbtesting.SetupGlobalTestDB(t)
+	ctx := context.Background()
+
+	latest, err := SiteGetLatest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, contents := range []string{`{"a": 1}`, `{"a": 2}`} {
+		latest, err = SiteCreateIfUpToDate(ctx, &latest.ID, nil, "api", contents)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	history, err := SiteListHistory(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(history) != 2 {
+		t.Fatalf("got %d configs, want 2", len(history))
+	}
+	if history[0].Contents != `{"a": 2}` || history[1].Contents != `{"a": 1}` {
+		t.Errorf("got history contents %q, %q, want newest first", history[0].Contents, history[1].Contents)
+	}
+	if history[0].AuthorUserID != nil {
+		t.Errorf("got author %d, want nil", *history[0].AuthorUserID)
+	}
+	if history[0].EditSource != "api" {
+		t.Errorf("got edit source %q, want %q", history[0].EditSource, "api")
+	}
+}
+
+func TestSiteGetByID(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	dbtesting.SetupGlobalTestDB(t)
+	ctx := context.Background()
+
+	latest, err := SiteGetLatest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := SiteGetByID(ctx, latest.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.Contents != latest.Contents {
+		t.Fatalf("got %+v, want %+v", got, latest)
+	}
+
+	got, err = SiteGetByID(ctx, latest.ID+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Errorf("got %+v, want nil for unknown ID", got)
+	}
+}
+
+func TestCreateIfUpToDate(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+
+	dbtesting.SetupGlobalTestDB(t)
+	ctx := context.Background()
+
+	critical, err := CriticalGetLatest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	site, err := SiteGetLatest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	staleSiteID := site.ID
+
+	newCritical, newSite, err := CreateIfUpToDate(ctx, &critical.ID, &site.ID, nil, "api", `{"a": 1}`, `{"b": 1}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newCritical.Contents != `{"a": 1}` || newSite.Contents != `{"b": 1}` {
+		t.Fatalf("got contents %q, %q, want the written configs", newCritical.Contents, newSite.Contents)
+	}
+
+	// A stale site ID must reject the whole write, including the up-to-date
+	// critical config.
+	_, _, err = CreateIfUpToDate(ctx, &newCritical.ID, &staleSiteID, nil, "api", `{"a": 2}`, `{"b": 2}`)
+	if err != ErrNewerEdit {
+		t.Fatalf("got error %v, want ErrNewerEdit", err)
+	}
+
+	latestCritical, err := CriticalGetLatest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latestCritical.ID != newCritical.ID || latestCritical.Contents != `{"a": 1}` {
+		t.Errorf("got critical config %+v after rejected write, want %+v", latestCritical, newCritical)
+	}
+	latestSite, err := SiteGetLatest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latestSite.ID != newSite.ID {
+		t.Errorf("got site config %+v after rejected write, want %+v", latestSite, newSite)
+	}
+
+	// Unchanged configs are not saved again.
+	sameCritical, _, err := CreateIfUpToDate(ctx, &newCritical.ID, &newSite.ID, nil, "api", `{"a": 1}`, `{"b": 3}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sameCritical.ID != newCritical.ID {
+		t.Errorf("got critical config ID %d for unchanged contents, want %d", sameCritical.ID, newCritical.ID)
+	}
+}
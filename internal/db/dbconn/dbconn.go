@@ -137,6 +137,19 @@ func isDatabaseLikelyStartingUp(err error) bool {
 	return false
 }
 
+// NewListener returns a listener for PostgreSQL notifications (see
+// https://www.postgresql.org/docs/current/sql-notify.html) on the DB. It
+// reconnects automatically if the connection is lost.
+//
+// The dataSource is interpreted as in ConnectToDB.
+func NewListener(dataSource string) *pq.Listener {
+	return pq.NewListener(buildConnectionString(dataSource), time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			log15.Warn("PostgreSQL notification listener error.", "error", err)
+		}
+	})
+}
+
 var registerOnce sync.Once
 
 // Open creates a new DB handle with the given schema by connecting to
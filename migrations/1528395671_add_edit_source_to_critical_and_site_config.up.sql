@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE critical_and_site_config ADD COLUMN IF NOT EXISTS edit_source text NOT NULL DEFAULT '';
+
+COMMIT;
@@ -106,6 +106,10 @@
 // 1528395668_campaign_description_nullable.up.sql (143B)
 // 1528395669_add_synced_at_to_perms_tables.down.sql (121B)
 // 1528395669_add_synced_at_to_perms_tables.up.sql (143B)
+// 1528395670_add_author_user_id_to_critical_and_site_config.down.sql (92B)
+// 1528395670_add_author_user_id_to_critical_and_site_config.up.sql (143B)
+// 1528395671_add_edit_source_to_critical_and_site_config.down.sql (89B)
+// 1528395671_add_edit_source_to_critical_and_site_config.up.sql (117B)
 
 package migrations
 
@@ -2294,6 +2298,86 @@ func _1528395669_add_synced_at_to_perms_tablesUpSql() (*asset, error) {
 	return a, nil
 }
 
+var __1528395670_add_author_user_id_to_critical_and_site_configDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x5c\x00\xa3\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x72\x69\x74\x69\x63\x61\x6c\x5f\x61\x6e\x64\x5f\x73\x69\x74\x65\x5f\x63\x6f\x6e\x66\x69\x67\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x61\x75\x74\x68\x6f\x72\x5f\x75\x73\x65\x72\x5f\x69\x64\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x6c\x4d\xa7\xb5\x5c\x00\x00\x00")
+
+func _1528395670_add_author_user_id_to_critical_and_site_configDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395670_add_author_user_id_to_critical_and_site_configDownSql,
+		"1528395670_add_author_user_id_to_critical_and_site_config.down.sql",
+	)
+}
+
+func _1528395670_add_author_user_id_to_critical_and_site_configDownSql() (*asset, error) {
+	bytes, err := _1528395670_add_author_user_id_to_critical_and_site_configDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395670_add_author_user_id_to_critical_and_site_config.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x2a, 0x17, 0x7b, 0x60, 0xa7, 0xc0, 0xf4, 0xfc, 0xe7, 0xf0, 0x71, 0xf7, 0xc3, 0x44, 0xa9, 0xf9, 0x90, 0x58, 0x88, 0x3e, 0xa9, 0xb5, 0x83, 0x4e, 0x63, 0xbe, 0x6a, 0x89, 0x45, 0x45, 0x8b, 0x1e}}
+	return a, nil
+}
+
+var __1528395670_add_author_user_id_to_critical_and_site_configUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x8f\x00\x70\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x72\x69\x74\x69\x63\x61\x6c\x5f\x61\x6e\x64\x5f\x73\x69\x74\x65\x5f\x63\x6f\x6e\x66\x69\x67\x20\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x4e\x4f\x54\x20\x45\x58\x49\x53\x54\x53\x20\x61\x75\x74\x68\x6f\x72\x5f\x75\x73\x65\x72\x5f\x69\x64\x20\x69\x6e\x74\x65\x67\x65\x72\x20\x52\x45\x46\x45\x52\x45\x4e\x43\x45\x53\x20\x75\x73\x65\x72\x73\x28\x69\x64\x29\x20\x4f\x4e\x20\x44\x45\x4c\x45\x54\x45\x20\x53\x45\x54\x20\x4e\x55\x4c\x4c\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x6e\x91\xd8\x47\x8f\x00\x00\x00")
+
+func _1528395670_add_author_user_id_to_critical_and_site_configUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395670_add_author_user_id_to_critical_and_site_configUpSql,
+		"1528395670_add_author_user_id_to_critical_and_site_config.up.sql",
+	)
+}
+
+func _1528395670_add_author_user_id_to_critical_and_site_configUpSql() (*asset, error) {
+	bytes, err := _1528395670_add_author_user_id_to_critical_and_site_configUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395670_add_author_user_id_to_critical_and_site_config.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x12, 0x46, 0xfb, 0x20, 0xeb, 0xb3, 0xa0, 0xed, 0x87, 0x38, 0x8b, 0x1, 0xe3, 0x81, 0xd9, 0xb5, 0x9c, 0xd7, 0x5f, 0xf7, 0x92, 0x20, 0x83, 0xc1, 0x26, 0x6d, 0x22, 0x21, 0x1a, 0x63, 0xf5, 0x98}}
+	return a, nil
+}
+
+var __1528395671_add_edit_source_to_critical_and_site_configDownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x59\x00\xa6\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x72\x69\x74\x69\x63\x61\x6c\x5f\x61\x6e\x64\x5f\x73\x69\x74\x65\x5f\x63\x6f\x6e\x66\x69\x67\x20\x44\x52\x4f\x50\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x45\x58\x49\x53\x54\x53\x20\x65\x64\x69\x74\x5f\x73\x6f\x75\x72\x63\x65\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\x77\xb2\xc9\x13\x59\x00\x00\x00")
+
+func _1528395671_add_edit_source_to_critical_and_site_configDownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395671_add_edit_source_to_critical_and_site_configDownSql,
+		"1528395671_add_edit_source_to_critical_and_site_config.down.sql",
+	)
+}
+
+func _1528395671_add_edit_source_to_critical_and_site_configDownSql() (*asset, error) {
+	bytes, err := _1528395671_add_edit_source_to_critical_and_site_configDownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395671_add_edit_source_to_critical_and_site_config.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xb1, 0xdd, 0x30, 0xbc, 0x25, 0x94, 0x63, 0x1d, 0x6e, 0xaf, 0xb4, 0xd2, 0x61, 0x8f, 0x52, 0xf5, 0xde, 0xd3, 0x22, 0x39, 0xb8, 0x21, 0xe1, 0x32, 0xda, 0x27, 0x7c, 0xdb, 0xcd, 0x3a, 0x5e, 0xaf}}
+	return a, nil
+}
+
+var __1528395671_add_edit_source_to_critical_and_site_configUpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x75\x00\x8a\xff\x42\x45\x47\x49\x4e\x3b\x0a\x0a\x41\x4c\x54\x45\x52\x20\x54\x41\x42\x4c\x45\x20\x63\x72\x69\x74\x69\x63\x61\x6c\x5f\x61\x6e\x64\x5f\x73\x69\x74\x65\x5f\x63\x6f\x6e\x66\x69\x67\x20\x41\x44\x44\x20\x43\x4f\x4c\x55\x4d\x4e\x20\x49\x46\x20\x4e\x4f\x54\x20\x45\x58\x49\x53\x54\x53\x20\x65\x64\x69\x74\x5f\x73\x6f\x75\x72\x63\x65\x20\x74\x65\x78\x74\x20\x4e\x4f\x54\x20\x4e\x55\x4c\x4c\x20\x44\x45\x46\x41\x55\x4c\x54\x20\x27\x27\x3b\x0a\x0a\x43\x4f\x4d\x4d\x49\x54\x3b\x0a\x03\x00\xaf\xa3\x24\x8d\x75\x00\x00\x00")
+
+func _1528395671_add_edit_source_to_critical_and_site_configUpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395671_add_edit_source_to_critical_and_site_configUpSql,
+		"1528395671_add_edit_source_to_critical_and_site_config.up.sql",
+	)
+}
+
+func _1528395671_add_edit_source_to_critical_and_site_configUpSql() (*asset, error) {
+	bytes, err := _1528395671_add_edit_source_to_critical_and_site_configUpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395671_add_edit_source_to_critical_and_site_config.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x5, 0xdf, 0x2b, 0x67, 0xd6, 0xd7, 0xbb, 0x16, 0x34, 0x35, 0x4f, 0x7, 0x84, 0x94, 0x96, 0xd7, 0x51, 0x84, 0xdb, 0xf, 0xbd, 0xf, 0x8f, 0x89, 0x24, 0xf2, 0x20, 0xc0, 0x45, 0x78, 0x89, 0xa2}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -2491,6 +2575,10 @@ var _bindata = map[string]func() (*asset, error){
 	"1528395668_campaign_description_nullable.up.sql":                         _1528395668_campaign_description_nullableUpSql,
 	"1528395669_add_synced_at_to_perms_tables.down.sql":                       _1528395669_add_synced_at_to_perms_tablesDownSql,
 	"1528395669_add_synced_at_to_perms_tables.up.sql":                         _1528395669_add_synced_at_to_perms_tablesUpSql,
+	"1528395670_add_author_user_id_to_critical_and_site_config.down.sql":      _1528395670_add_author_user_id_to_critical_and_site_configDownSql,
+	"1528395670_add_author_user_id_to_critical_and_site_config.up.sql":        _1528395670_add_author_user_id_to_critical_and_site_configUpSql,
+	"1528395671_add_edit_source_to_critical_and_site_config.down.sql":         _1528395671_add_edit_source_to_critical_and_site_configDownSql,
+	"1528395671_add_edit_source_to_critical_and_site_config.up.sql":           _1528395671_add_edit_source_to_critical_and_site_configUpSql,
 }
 
 // AssetDir returns the file names below a certain
@@ -2640,6 +2728,10 @@ var _bintree = &bintree{nil, map[string]*bintree{
 	"1528395668_campaign_description_nullable.up.sql":                         {_1528395668_campaign_description_nullableUpSql, map[string]*bintree{}},
 	"1528395669_add_synced_at_to_perms_tables.down.sql":                       {_1528395669_add_synced_at_to_perms_tablesDownSql, map[string]*bintree{}},
 	"1528395669_add_synced_at_to_perms_tables.up.sql":                         {_1528395669_add_synced_at_to_perms_tablesUpSql, map[string]*bintree{}},
+	"1528395670_add_author_user_id_to_critical_and_site_config.down.sql":      {_1528395670_add_author_user_id_to_critical_and_site_configDownSql, map[string]*bintree{}},
+	"1528395670_add_author_user_id_to_critical_and_site_config.up.sql":        {_1528395670_add_author_user_id_to_critical_and_site_configUpSql, map[string]*bintree{}},
+	"1528395671_add_edit_source_to_critical_and_site_config.down.sql":         {_1528395671_add_edit_source_to_critical_and_site_configDownSql, map[string]*bintree{}},
+	"1528395671_add_edit_source_to_critical_and_site_config.up.sql":           {_1528395671_add_edit_source_to_critical_and_site_configUpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
@@ -842,6 +842,14 @@ type SMTPServerConfig struct {
 	// Username description: The username to use when communicating with the SMTP server.
 	Username string `json:"username,omitempty"`
 }
+type ScopedOverride struct {
+	// Config description: The site configuration properties to override.
+	Config map[string]interface{} `json:"config"`
+	// ExternalServiceKinds description: The kinds of code hosts that the override applies to, such as "GITHUB". If repos is also set, the override applies only to matching repositories on these code hosts.
+	ExternalServiceKinds []string `json:"externalServiceKinds,omitempty"`
+	// Repos description: Regular expressions matching the names of the repositories that the override applies to, such as "^github\.com/myorg/".
+	Repos []string `json:"repos,omitempty"`
+}
 type SearchSavedQueries struct {
 	// Description description: Description of this saved query
 	Description string `json:"description"`
@@ -1037,6 +1045,8 @@ type SiteConfiguration struct {
 	PermissionsUserMapping *PermissionsUserMapping `json:"permissions.userMapping,omitempty"`
 	// RepoListUpdateInterval description: Interval (in minutes) for checking code hosts (such as GitHub, Gitolite, etc.) for new repositories.
 	RepoListUpdateInterval int `json:"repoListUpdateInterval,omitempty"`
+	// ScopedOverrides description: Overrides of site configuration properties that apply only to some repositories or code hosts. When several overrides match, later overrides take precedence over earlier ones, and all matching overrides take precedence over the top-level value. Overridden values replace the top-level value as a whole; objects and arrays are not merged. Overrides only take effect for properties that are read for a specific repository or code host, and no built-in properties are read this way yet.
+	ScopedOverrides []*ScopedOverride `json:"scopedOverrides,omitempty"`
 	// SearchIndexEnabled description: Whether indexed search is enabled. If unset Sourcegraph detects the environment to decide if indexed search is enabled. Indexed search is RAM heavy, and is disabled by default in the single docker image. All other environments will have it enabled by default. The size of all your repository working copies is the amount of additional RAM required.
 	SearchIndexEnabled *bool `json:"search.index.enabled,omitempty"`
 	// SearchIndexSymbolsEnabled description: Whether indexed symbol search is enabled. This is contingent on the indexed search configuration, and is true by default for instances with indexed search enabled. Enabling this will cause every repository to re-index, which is a time consuming (several hours) operation. Additionally, it requires more storage and ram to accommodate the added symbols information in the search index.
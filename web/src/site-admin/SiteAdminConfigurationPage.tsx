@@ -10,7 +10,7 @@ import { PageTitle } from '../components/PageTitle'
 import { DynamicallyImportedMonacoSettingsEditor } from '../settings/DynamicallyImportedMonacoSettingsEditor'
 import { refreshSiteFlags } from '../site/backend'
 import { eventLogger } from '../tracking/eventLogger'
-import { fetchSite, reloadSite, updateSiteConfiguration } from './backend'
+import { fetchSite, fetchSiteConfigurationSchema, reloadSite, updateSiteConfiguration } from './backend'
 import { ErrorAlert } from '../components/alerts'
 import * as jsonc from '@sqs/jsonc-parser'
 import { setProperty } from '@sqs/jsonc-parser/lib/edit'
@@ -199,6 +199,12 @@ interface Props extends RouteComponentProps<{}> {
 interface State {
     site?: GQL.ISite
     loading: boolean
+
+    /**
+     * The site configuration JSON Schema. It is initially the schema bundled with the web app, and is
+     * replaced by the server's schema (which includes properties contributed at runtime) once loaded.
+     */
+    jsonSchema: { $id: string }
     error?: Error
 
     saving?: boolean
@@ -214,6 +220,7 @@ const EXPECTED_RELOAD_WAIT = 7 * 1000 // 7 seconds
 export class SiteAdminConfigurationPage extends React.Component<Props, State> {
     public state: State = {
         loading: true,
+        jsonSchema: siteSchemaJSON,
         restartToApply: window.context.needServerRestart,
     }
 
@@ -238,6 +245,14 @@ export class SiteAdminConfigurationPage extends React.Component<Props, State> {
         )
         this.remoteRefreshes.next()
 
+        this.subscriptions.add(
+            fetchSiteConfigurationSchema().subscribe(
+                jsonSchema => this.setState({ jsonSchema }),
+                // Keep using the bundled schema.
+                error => console.error(error)
+            )
+        )
+
         this.subscriptions.add(
             this.remoteUpdates
                 .pipe(
@@ -367,6 +382,25 @@ export class SiteAdminConfigurationPage extends React.Component<Props, State> {
                 </div>
             )
         }
+        if (
+            this.state.site &&
+            this.state.site.configuration &&
+            this.state.site.configuration.lintWarnings &&
+            this.state.site.configuration.lintWarnings.length > 0
+        ) {
+            alerts.push(
+                <div key="lint-warnings" className="alert alert-warning site-admin-configuration-page__alert">
+                    <p>The last-saved config works, but may not be what you intended:</p>
+                    <ul>
+                        {this.state.site.configuration.lintWarnings.map((e, i) => (
+                            <li key={i} className="site-admin-configuration-page__alert-item">
+                                {e}
+                            </li>
+                        ))}
+                    </ul>
+                </div>
+            )
+        }
 
         // Avoid user confusion with values.yaml properties mixed in with site config properties.
         const contents =
@@ -429,7 +463,7 @@ export class SiteAdminConfigurationPage extends React.Component<Props, State> {
                     <div>
                         <DynamicallyImportedMonacoSettingsEditor
                             value={contents || ''}
-                            jsonSchema={siteSchemaJSON}
+                            jsonSchema={this.state.jsonSchema}
                             canEdit={true}
                             saving={this.state.saving}
                             loading={isReloading || this.state.saving}
@@ -6,6 +6,20 @@ import * as GQL from '../../../shared/src/graphql/schema'
 import { resetAllMemoizationCaches } from '../../../shared/src/util/memoizeObservable'
 import { mutateGraphQL, queryGraphQL } from '../backend/graphql'
 import { Settings } from '../../../shared/src/settings/settings'
+import { checkOk } from '../../../shared/src/backend/fetch'
+import { fromFetch } from '../../../shared/src/graphql/fromFetch'
+
+/**
+ * Fetches the site configuration JSON Schema, including properties that were contributed at runtime
+ * (such as custom language servers).
+ */
+export function fetchSiteConfigurationSchema(): Observable<{ $id: string }> {
+    return fromFetch(
+        '/.api/site-configuration/schema',
+        { credentials: 'include', headers: window.context.xhrHeaders },
+        response => checkOk(response).json()
+    )
+}
 
 /**
  * Fetches all users.
@@ -298,6 +312,7 @@ export function fetchSite(): Observable<GQL.ISite> {
                     id
                     effectiveContents
                     validationMessages
+                    lintWarnings
                 }
             }
         }